@@ -154,8 +154,14 @@ func compileRuntime(universe *Scope) *AstPackage {
 
 func makePkg(pkg *AstPackage, universe *Scope) *AstPackage {
 	resolveIdents(pkg, universe)
+	composeInterfaces(pkg.dynamicTypes)
 	attachMethodsToTypes(pkg.methods, pkg.scope)
+	promoteMethods(pkg)
 	inferTypes(pkg.uninferredGlobals, pkg.uninferredLocals)
+	if pkg.name != IRuntimePkgName {
+		// the runtime treats slices and strings as raw structs
+		checkTypes(pkg)
+	}
 	calcStructSize(pkg.dynamicTypes)
 	return pkg
 }
@@ -315,12 +321,15 @@ func build(pkgUniverse *AstPackage, pkgUnsafe *AstPackage, pkgIRuntime *AstPacka
 
 	//  Do restructuring of local nodes
 	for _, pkg := range packages {
-		for _, fnc := range pkg.funcs {
-			fnc = walkFunc(fnc)
+		walkingPkg = pkg
+		// functions may be added while walking
+		for i := 0; i < len(pkg.funcs); i++ {
+			walkFunc(pkg.funcs[i])
 		}
 	}
+	walkingPkg = nil
 
-	symbolTable.uniquedDTypes = uniqueDynamicTypes(dynamicTypes)
+	symbolTable.uniquedDTypes, symbolTable.underlyingDTypes = uniqueDynamicTypes(dynamicTypes)
 
 	program := &Program{}
 	program.packages = packages
@@ -37,12 +37,13 @@ func (bs *ByteStream) get() (byte, error) {
 		return 0, errors.New("EOF")
 	}
 	r := bs.source[bs.nextIndex]
+	bs.nextIndex++
 	if r == '\n' {
 		bs.line++
-		bs.column = 1
+		bs.column = 0
+	} else {
+		bs.column++
 	}
-	bs.nextIndex++
-	bs.column++
 	return r, nil
 }
 
@@ -51,5 +52,12 @@ func (bs *ByteStream) unget() {
 	r := bs.source[bs.nextIndex]
 	if r == '\n' {
 		bs.line--
+		// count the bytes of the previous line
+		bs.column = 0
+		for i := bs.nextIndex - 1; i >= 0 && bs.source[i] != '\n'; i-- {
+			bs.column++
+		}
+	} else {
+		bs.column--
 	}
 }
@@ -0,0 +1,1657 @@
+// Type checker
+package main
+
+import (
+	"os"
+
+	"github.com/DQNEO/minigo/util"
+)
+
+// the go compiler also gives up after 10 errors
+const maxTypeErrors = 10
+
+// kinds of untyped constants and values
+const (
+	untypedBool   string = "untyped bool"
+	untypedInt    string = "untyped int"
+	untypedRune   string = "untyped rune"
+	untypedFloat  string = "untyped float"
+	untypedString string = "untyped string"
+	untypedNil    string = "untyped nil"
+)
+
+// errors found by checkTypes, prefixed with their positions
+var typeErrors []string
+
+// the package being checked. Its own names are not qualified in messages.
+var checkingPkg identifier
+
+// the name of a constant whose expression is repeated from the previous spec.
+// The errors in the expression are reported at the name.
+var repeatedConstTok *Token
+
+func typeErrorf(tok *Token, format string, v ...interface{}) {
+	if repeatedConstTok != nil {
+		tok = repeatedConstTok
+	}
+	msg := Sprintf(format, v...)
+	if tok != nil {
+		msg = Sprintf("%s:%d:%d: %s", tok.filename, tok.line, tok.column, msg)
+	}
+	typeErrors = append(typeErrors, msg)
+}
+
+// checkTypes validates the declarations and the function bodies of pkg before the code generation.
+// All the errors found are reported at once, and then the compilation fails.
+func checkTypes(pkg *AstPackage) {
+	checkingPkg = pkg.name
+	for _, f := range pkg.files {
+		for _, decl := range f.DeclList {
+			if decl.vardecl != nil {
+				checkStmt(decl.vardecl)
+			} else if decl.constdecl != nil {
+				checkConstDecl(decl.constdecl)
+			} else if decl.funcdecl != nil {
+				checkFunc(decl.funcdecl)
+			}
+		}
+		for _, funcLiteral := range f.funcLiterals {
+			checkFunc(funcLiteral)
+		}
+	}
+	if len(typeErrors) > 0 {
+		reportTypeErrors()
+	}
+}
+
+func reportTypeErrors() {
+	for i, msg := range typeErrors {
+		if i == maxTypeErrors {
+			os.Stderr.Write([]byte("too many errors\n"))
+			break
+		}
+		os.Stderr.Write([]byte(msg + "\n"))
+	}
+	os.Exit(1)
+}
+
+func checkFunc(f *DeclFunc) {
+	if f.isWrapper {
+		return
+	}
+	checkStmtList(f.body)
+}
+
+func checkStmtList(stmtList *StmtSatementList) {
+	if stmtList == nil {
+		return
+	}
+	for _, stmt := range stmtList.stmts {
+		checkStmt(stmt)
+	}
+}
+
+func checkStmt(stmt Stmt) {
+	switch stmt.(type) {
+	case *DeclConst:
+		checkConstDecl(stmt.(*DeclConst))
+	case *DeclVar:
+		s := stmt.(*DeclVar)
+		if s.initval != nil && checkExpr(s.initval) {
+			checkAssignable(s.initval, s.variable.getGtype(), "variable declaration")
+		}
+	case *StmtAssignment:
+		s := stmt.(*StmtAssignment)
+		checkAssignment(s.lefts, s.rights, false)
+	case *StmtShortVarDecl:
+		s := stmt.(*StmtShortVarDecl)
+		checkAssignment(s.lefts, s.rights, true)
+	case *StmtReturn:
+		checkReturn(stmt.(*StmtReturn))
+	case *StmtIf:
+		s := stmt.(*StmtIf)
+		checkStmt(s.simplestmt)
+		checkCond(s.cond, "if")
+		checkStmt(s.then)
+		checkStmt(s.els)
+	case *StmtFor:
+		s := stmt.(*StmtFor)
+		if s.rng != nil {
+			checkExpr(s.rng.rangeexpr)
+		}
+		if s.cls != nil {
+			checkStmt(s.cls.init)
+			if cond, ok := s.cls.cond.(*StmtExpr); ok {
+				checkCond(cond.expr, "for")
+			} else {
+				checkStmt(s.cls.cond)
+			}
+			checkStmt(s.cls.post)
+		}
+		checkStmtList(s.block)
+	case *StmtSwitch:
+		checkSwitch(stmt.(*StmtSwitch))
+	case *StmtSelect:
+		s := stmt.(*StmtSelect)
+		for _, clause := range s.cases {
+			checkStmt(clause.comm)
+			checkStmtList(clause.compound)
+		}
+		checkStmtList(s.dflt)
+	case *StmtSatementList:
+		checkStmtList(stmt.(*StmtSatementList))
+	case *StmtLabeled:
+		checkStmt(stmt.(*StmtLabeled).stmt)
+	case *StmtExpr:
+		checkExpr(stmt.(*StmtExpr).expr)
+	case *StmtGo:
+		checkExpr(stmt.(*StmtGo).expr)
+	case *StmtDefer:
+		checkExpr(stmt.(*StmtDefer).expr)
+	case *StmtInc:
+		checkIncDec(stmt.(*StmtInc).operand, "++")
+	case *StmtDec:
+		checkIncDec(stmt.(*StmtDec).operand, "--")
+	case *StmtSend:
+		s := stmt.(*StmtSend)
+		chOk := checkExpr(s.ch)
+		if checkExpr(s.value) && chOk {
+			chType := s.ch.getGtype()
+			if chType.getKind() == G_CHAN {
+				checkAssignable(s.value, chType.Underlying().elementType, "send")
+			}
+		}
+	}
+}
+
+// a constant needs a constant value, which must be representable by the type of a typed constant.
+// Each value is checked with the iota of its constant.
+func checkConstDecl(decl *DeclConst) {
+	for _, cnst := range decl.consts {
+		constIotaIndex = cnst.iotaIndex
+		if cnst.repeated {
+			repeatedConstTok = cnst.tok
+		}
+		if checkExpr(cnst.val) && checkSingleValue(cnst.val) {
+			if evalConst(cnst.val) == nil {
+				typeErrorf(exprPos(cnst.val), "%s is not constant", operandString(cnst.val))
+			} else if cnst.gtype != nil {
+				checkAssignable(cnst.val, cnst.gtype, "constant declaration")
+			}
+		}
+		constIotaIndex = -1
+		repeatedConstTok = nil
+	}
+}
+
+func checkAssignment(lefts []Expr, rights []Expr, isShortVarDecl bool) {
+	ok := checkExprs(rights)
+	if !isShortVarDecl && !checkExprs(lefts) {
+		ok = false
+	}
+	if !ok {
+		return
+	}
+	if len(lefts) == 2 && len(rights) == 1 && isCommaOk(rights[0]) {
+		// v, ok = m[k]
+		return
+	}
+	if !checkValueCount(lefts, rights) || len(lefts) != len(rights) {
+		return
+	}
+	context := "assignment"
+	if len(lefts) > 1 {
+		context = "multiple assignment"
+	}
+	for i, left := range lefts {
+		right := rights[i]
+		if len(rights) > 1 && !checkSingleValue(right) {
+			continue
+		}
+		if isShortVarDecl {
+			kind := untypedKind(right)
+			if kind == untypedNil {
+				typeErrorf(exprPos(right), "use of untyped nil in assignment")
+			} else if len(kind) > 0 {
+				// the constant is converted to its default type
+				checkAssignable(right, defaultType(kind), context)
+			}
+			continue
+		}
+		if isUnderScore(left) || !checkAssignTarget(left) {
+			continue
+		}
+		checkAssignable(right, left.getGtype(), context)
+	}
+}
+
+// Each left-hand side operand must be addressable or a map index expression
+func checkAssignTarget(left Expr) bool {
+	if !checkSingleValue(left) {
+		return false
+	}
+	if index, ok := left.(*ExprIndex); ok && index.collection.getGtype().getKind() == G_MAP {
+		return true
+	}
+	if isAddressable(left) {
+		return true
+	}
+	if field, ok := left.(*ExprStructField); ok {
+		if index, ok := field.strct.(*ExprIndex); ok && index.collection.getGtype().getKind() == G_MAP {
+			typeErrorf(exprPos(left), "cannot assign to struct field %s in map", exprString(left))
+			return false
+		}
+	}
+	typeErrorf(exprPos(left), "cannot assign to %s (neither addressable nor a map index expression)", exprString(left))
+	return false
+}
+
+// https://golang.org/ref/spec#Address_operators
+func isAddressable(e Expr) bool {
+	switch e.(type) {
+	case *Relation:
+		_, ok := e.(*Relation).expr.(*ExprVariable)
+		return ok
+	case *ExprVariable:
+		return true
+	case *ExprUop:
+		return e.(*ExprUop).op == "*"
+	case *ExprIndex:
+		collection := e.(*ExprIndex).collection
+		switch collection.getGtype().getKind() {
+		case G_SLICE, G_POINTER:
+			return true
+		case G_ARRAY:
+			return isAddressable(collection)
+		}
+	case *ExprStructField:
+		field := e.(*ExprStructField)
+		if field.getMethodSig() != nil {
+			// a method value
+			return false
+		}
+		strct := field.strct
+		return strct.getGtype().getKind() == G_POINTER || isAddressable(strct)
+	}
+	return false
+}
+
+// the number of values on the right must match the number of variables on the left
+func checkValueCount(lefts []Expr, rights []Expr) bool {
+	if len(rights) == 1 {
+		results, isCall := callResults(rights[0])
+		if isCall {
+			if len(results) == len(lefts) {
+				return true
+			}
+			if len(results) == 0 {
+				typeErrorf(exprPos(rights[0]), "%s (no value) used as value", exprString(rights[0]))
+				return false
+			}
+			typeErrorf(exprPos(rights[0]), "assignment mismatch: %s but %s returns %s",
+				plural(len(lefts), "variable"), calleeString(rights[0]), plural(len(results), "value"))
+			return false
+		}
+	}
+	if len(lefts) != len(rights) {
+		typeErrorf(exprPos(rights[0]), "assignment mismatch: %s but %s",
+			plural(len(lefts), "variable"), plural(len(rights), "value"))
+		return false
+	}
+	return true
+}
+
+func checkReturn(s *StmtReturn) {
+	if !checkExprs(s.exprs) {
+		return
+	}
+	want := s.rettypes
+	if len(s.exprs) == 1 {
+		results, isCall := callResults(s.exprs[0])
+		if isCall && len(results) != 1 {
+			// return f()
+			call := s.exprs[0]
+			if len(results) < len(want) {
+				typeErrorf(exprPos(call), "not enough return values\n\thave %s\n\twant %s", typesString(results), typesString(want))
+			} else if len(results) > len(want) {
+				typeErrorf(exprPos(call), "too many return values\n\thave %s\n\twant %s", typesString(results), typesString(want))
+			} else {
+				for i, result := range results {
+					if !checkAssignableValue(call, result, want[i], "return statement") {
+						return
+					}
+				}
+			}
+			return
+		}
+	}
+	if len(s.exprs) < len(want) {
+		pos := s.tok
+		if len(s.exprs) > 0 {
+			pos = exprPos(s.exprs[len(s.exprs)-1])
+		}
+		typeErrorf(pos, "not enough return values\n\thave %s\n\twant %s", argTypesString(s.exprs), typesString(want))
+		return
+	}
+	if len(s.exprs) > len(want) {
+		typeErrorf(exprPos(s.exprs[len(want)]), "too many return values\n\thave %s\n\twant %s", argTypesString(s.exprs), typesString(want))
+		return
+	}
+	for i, expr := range s.exprs {
+		checkAssignable(expr, want[i], "return statement")
+	}
+}
+
+func checkCond(cond Expr, stmtName string) {
+	if cond == nil || !checkExpr(cond) {
+		return
+	}
+	kind := untypedKind(cond)
+	if kind == untypedBool {
+		return
+	}
+	if kind == "" {
+		gtype := cond.getGtype()
+		if !isKnownType(gtype) || gtype.getKind() == G_BOOL {
+			return
+		}
+	}
+	typeErrorf(exprPos(cond), "non-boolean condition in %s statement", stmtName)
+}
+
+func checkIncDec(operand Expr, op string) {
+	if !checkExpr(operand) || !checkAssignTarget(operand) {
+		return
+	}
+	gtype := operand.getGtype()
+	if isKnownType(gtype) && !gtype.isNumeric() {
+		typeErrorf(exprPos(operand), "invalid operation: %s%s (non-numeric type %s)", exprString(operand), op, gtype.typeString())
+	}
+}
+
+func checkSwitch(s *StmtSwitch) {
+	condOk := checkExpr(s.cond)
+	for _, cse := range s.cases {
+		for _, e := range cse.exprs {
+			if checkExpr(e) && condOk && s.cond != nil && !s.isTypeSwitch() {
+				if !matchOperands(e, s.cond, true) {
+					typeErrorf(exprPos(e), "invalid case %s in switch on %s (mismatched types %s and %s)",
+						exprString(e), exprString(s.cond), operandTypeString(e), operandTypeString(s.cond))
+				}
+			}
+		}
+		checkStmtList(cse.compound)
+	}
+	checkStmtList(s.dflt)
+}
+
+func checkExprs(exprs []Expr) bool {
+	var ok bool = true
+	for _, e := range exprs {
+		if !checkExpr(e) {
+			ok = false
+		}
+	}
+	return ok
+}
+
+// checkExpr checks e and its operands. It returns false if an error is found.
+func checkExpr(e Expr) bool {
+	switch e.(type) {
+	case nil:
+		return true
+	case *Relation:
+		rel := e.(*Relation)
+		if variable, ok := rel.expr.(*ExprVariable); ok && variable.gtype == nil {
+			// the declaration has an error
+			return false
+		}
+	case *ExprFuncallOrConversion:
+		funcall := e.(*ExprFuncallOrConversion)
+		if !checkExprs(funcall.args) {
+			return false
+		}
+		return checkFuncall(funcall)
+	case *ExprMethodcall:
+		methodCall := e.(*ExprMethodcall)
+		ok := checkExpr(methodCall.receiver)
+		if !checkExprs(methodCall.args) || !ok {
+			return false
+		}
+		return checkMethodcall(methodCall)
+	case *ExprFuncValueCall:
+		call := e.(*ExprFuncValueCall)
+		ok := checkExpr(call.fn)
+		if !checkExprs(call.args) || !ok {
+			return false
+		}
+		return checkArguments(call, exprString(call.fn), call.args, getSignature(call.fn).params)
+	case *ExprBinop:
+		binop := e.(*ExprBinop)
+		ok := checkExpr(binop.left)
+		if !checkExpr(binop.right) || !ok {
+			return false
+		}
+		return checkBinop(binop)
+	case *ExprUop:
+		uop := e.(*ExprUop)
+		if !checkExpr(uop.operand) {
+			return false
+		}
+		return checkUop(uop)
+	case *ExprSlice:
+		slice := e.(*ExprSlice)
+		if !checkExpr(slice.collection) {
+			return false
+		}
+		return checkIndexValue(slice.low) && checkIndexValue(slice.high) && checkIndexValue(slice.max)
+	case *ExprIndex:
+		index := e.(*ExprIndex)
+		ok := checkExpr(index.collection)
+		if !checkExpr(index.index) || !ok {
+			return false
+		}
+		collectionType := index.collection.getGtype()
+		if collectionType.getKind() == G_POINTER {
+			// an element of *[n]T
+			collectionType = collectionType.Underlying().origType
+		}
+		if collectionType.getKind() == G_MAP {
+			return checkAssignable(index.index, collectionType.Underlying().mapKey, "map index")
+		}
+		return checkIndexValue(index.index) && checkConstIndex(index.index, collectionType)
+	case *ExprSliceLiteral:
+		lit := e.(*ExprSliceLiteral)
+		return checkElements(lit.values, lit.gtype.Underlying().elementType)
+	case *ExprArrayLiteral:
+		lit := e.(*ExprArrayLiteral)
+		return checkElements(lit.values, lit.gtype.Underlying().elementType)
+	case *ExprStructLiteral:
+		return checkStructLiteral(e.(*ExprStructLiteral))
+	case *ExprMapLiteral:
+		lit := e.(*ExprMapLiteral)
+		var ok bool = true
+		for _, element := range lit.elements {
+			keyOk := checkExpr(element.key) && checkAssignable(element.key, lit.gtype.Underlying().mapKey, "map literal")
+			valueOk := checkExpr(element.value) && checkAssignable(element.value, lit.gtype.Underlying().mapValue, "map literal")
+			if !keyOk || !valueOk {
+				ok = false
+			}
+		}
+		return ok
+	case *ExprStructField:
+		return checkStructField(e.(*ExprStructField))
+	case *ExprTypeAssertion:
+		return checkExpr(e.(*ExprTypeAssertion).expr)
+	case *ExprTypeSwitchGuard:
+		return checkExpr(e.(*ExprTypeSwitchGuard).expr)
+	case *ExprVaArg:
+		return checkExpr(e.(*ExprVaArg).expr)
+	case *ExprChanRecv:
+		return checkExpr(e.(*ExprChanRecv).ch)
+	}
+	return true
+}
+
+func checkElements(values []Expr, elementType *Gtype) bool {
+	var ok bool = true
+	for _, value := range values {
+		if !checkExpr(value) || !checkAssignable(value, elementType, "array or slice literal") {
+			ok = false
+		}
+	}
+	return ok
+}
+
+func checkStructLiteral(lit *ExprStructLiteral) bool {
+	strct := lit.getGtype()
+	var ok bool = true
+	for _, field := range lit.fields {
+		if !checkExpr(field.value) {
+			ok = false
+			continue
+		}
+		var fieldType *Gtype
+		for _, f := range strct.Underlying().fields {
+			if string(f.fieldname) == string(field.key) {
+				fieldType = f
+			}
+		}
+		if fieldType == nil {
+			typeErrorf(field.tok, "unknown field %s in struct literal of type %s", string(field.key), strct.typeString())
+			ok = false
+			continue
+		}
+		if !checkAssignable(field.value, fieldType, "struct literal") {
+			ok = false
+		}
+	}
+	return ok
+}
+
+// the field of a selector must exist
+func checkStructField(e *ExprStructField) bool {
+	if !checkExpr(e.strct) {
+		return false
+	}
+	if e.getGtype() != nil {
+		return true
+	}
+	typeErrorf(e.token(), "%s undefined (type %s has no field or method %s)", exprString(e), e.strct.getGtype().typeString(), e.fieldname)
+	return false
+}
+
+// an index or a slice bound must be an integer
+func checkIndexValue(index Expr) bool {
+	if index == nil {
+		return true
+	}
+	if !checkExpr(index) || !checkSingleValue(index) {
+		return false
+	}
+	kind := untypedKind(index)
+	if kind == untypedInt || kind == untypedRune || (kind == untypedFloat && !isTruncated(index)) {
+		return true
+	}
+	if kind != "" {
+		typeErrorf(exprPos(index), "cannot convert %s to type int", operandString(index))
+		return false
+	}
+	gtype := index.getGtype()
+	if isKnownType(gtype) && !gtype.isInteger() {
+		typeErrorf(exprPos(index), "invalid argument: index %s must be integer", operandString(index))
+		return false
+	}
+	return true
+}
+
+// a constant index must not be negative, and must be in range of an array
+func checkConstIndex(index Expr, collectionType *Gtype) bool {
+	c := evalConst(index)
+	if c == nil || !c.isNumeric() {
+		return true
+	}
+	n := c.intPart()
+	if n.sign() < 0 {
+		typeErrorf(exprPos(index), "invalid argument: index %s (constant of type int) must not be negative", exprString(index))
+		return false
+	}
+	if collectionType.getKind() != G_ARRAY {
+		return true
+	}
+	length := collectionType.Underlying().length
+	if bigCmp(n, newBigInt(length)) >= 0 {
+		typeErrorf(exprPos(index), "invalid argument: index %s out of bounds [0:%d]", exprString(index), length)
+		return false
+	}
+	return true
+}
+
+func checkFuncall(funcall *ExprFuncallOrConversion) bool {
+	if funcall.typ != nil || funcall.rel.expr == nil {
+		return checkConversion(funcall)
+	}
+	if _, ok := funcall.rel.expr.(*ExprFuncRef); !ok {
+		// call of a func value
+		return checkArguments(funcall, exprString(funcall.rel), funcall.args, getSignature(funcall.rel.expr).params)
+	}
+	decl := funcall.getFuncDef()
+	if decl == builtinAppend {
+		if len(funcall.args) != 2 || funcall.args[0].getGtype().getKind() != G_SLICE {
+			return true
+		}
+		if _, ok := funcall.args[1].(*ExprVaArg); ok {
+			// append(s, t...)
+			return true
+		}
+		return checkAssignable(funcall.args[1], funcall.args[0].getGtype().Underlying().elementType, "argument to append")
+	}
+	if len(decl.builtinname) > 0 || decl.pkg == IRuntimePkgName {
+		// builtin functions are generic
+		return true
+	}
+	return checkArguments(funcall, exprString(funcall.rel), funcall.args, decl.params)
+}
+
+// T(x) can not convert x to an interface which it does not implement,
+// and a constant x must be representable by T.
+func checkConversion(funcall *ExprFuncallOrConversion) bool {
+	var toGtype *Gtype = funcall.typ
+	if toGtype == nil {
+		toGtype = &Gtype{
+			kind:     G_NAMED,
+			relation: funcall.rel,
+		}
+	}
+	if len(funcall.args) != 1 {
+		return true
+	}
+	arg := funcall.args[0]
+	if c := evalConst(arg); c != nil && c.isNumeric() && toGtype.isNumeric() {
+		switch c.representable(toGtype) {
+		case "truncated":
+			typeErrorf(exprPos(arg), "cannot convert %s to type %s", operandString(arg), toGtype.typeString())
+			return false
+		case "overflows":
+			typeErrorf(exprPos(arg), "constant %s overflows %s", c.String(), toGtype.typeString())
+			return false
+		}
+		return true
+	}
+	if toGtype.getKind() != G_INTERFACE || !checkSingleValue(arg) || untypedKind(arg) != "" {
+		return true
+	}
+	msg := implementsError(arg.getGtype(), toGtype)
+	if len(msg) > 0 {
+		typeErrorf(exprPos(arg), "cannot convert %s to type %s: %s", operandString(arg), toGtype.typeString(), msg)
+		return false
+	}
+	return true
+}
+
+func checkMethodcall(methodCall *ExprMethodcall) bool {
+	callee := exprString(methodCall.receiver) + "." + string(methodCall.fname)
+	if field := methodCall.getFuncField(); field != nil {
+		return checkArguments(methodCall, callee, methodCall.args, field.Underlying().sig.params)
+	}
+	gtype := methodCall.receiver.getGtype()
+	named := gtype
+	if gtype.kind == G_POINTER {
+		named = gtype.origType
+	}
+	if gtype.kind != G_INTERFACE && (named == nil || named.kind != G_NAMED) {
+		// only named types and interfaces have methods
+		typeErrorf(methodCall.token(), "%s undefined (type %s has no field or method %s)", callee, gtype.typeString(), methodCall.fname)
+		return false
+	}
+	origType := methodCall.getOrigType()
+	if origType.getKind() == G_INTERFACE {
+		sig, ok := imethodGet(origType.imethods, methodCall.fname)
+		if !ok {
+			return true
+		}
+		return checkArguments(methodCall, callee, methodCall.args, sig.params)
+	}
+	if _, ok := methodGet(origType.methods, methodCall.fname); !ok {
+		if _, ambiguous := origType.lookupPromoted(methodCall.fname); !ambiguous {
+			typeErrorf(methodCall.token(), "%s undefined (type %s has no field or method %s)", callee, gtype.typeString(), methodCall.fname)
+			return false
+		}
+	}
+	return checkArguments(methodCall, callee, methodCall.args, methodCall.getMethod().funcdef.params)
+}
+
+func isVariadicParams(params []*ExprVariable) bool {
+	return len(params) > 0 && params[len(params)-1].isVariadic
+}
+
+// the type of the i-th argument of a call
+func paramType(params []*ExprVariable, i int) *Gtype {
+	if isVariadicParams(params) && i >= len(params)-1 {
+		return params[len(params)-1].gtype.elementType
+	}
+	return params[i].gtype
+}
+
+// checkArguments checks the number and the types of the arguments of a call
+func checkArguments(call Expr, callee string, args []Expr, params []*ExprVariable) bool {
+	if len(args) == 1 {
+		results, isCall := callResults(args[0])
+		if isCall && len(results) > 1 {
+			// f(g()) where g returns multiple values
+			return checkTupleArguments(callee, args[0], results, params)
+		}
+	}
+	var spread bool
+	if len(args) > 0 {
+		if _, ok := args[len(args)-1].(*ExprVaArg); ok {
+			spread = true
+		}
+	}
+	variadic := isVariadicParams(params)
+	minArgs := len(params)
+	if variadic && !spread {
+		minArgs = len(params) - 1
+	}
+	if len(args) < minArgs {
+		pos := exprPos(call)
+		if len(args) > 0 {
+			pos = exprPos(args[len(args)-1])
+		}
+		typeErrorf(pos, "not enough arguments in call to %s\n\thave %s\n\twant %s", callee, argTypesString(args), paramsString(params))
+		return false
+	}
+	if len(args) > len(params) && (!variadic || spread) {
+		typeErrorf(exprPos(args[len(params)]), "too many arguments in call to %s\n\thave %s\n\twant %s", callee, argTypesString(args), paramsString(params))
+		return false
+	}
+	var ok bool = true
+	for i, arg := range args {
+		var to *Gtype
+		if vaArg, isVaArg := arg.(*ExprVaArg); isVaArg {
+			// f(s...)
+			arg = vaArg.expr
+			to = params[i].gtype
+		} else {
+			to = paramType(params, i)
+		}
+		if !checkAssignable(arg, to, "argument to "+callee) {
+			ok = false
+		}
+	}
+	return ok
+}
+
+func checkTupleArguments(callee string, call Expr, results []*Gtype, params []*ExprVariable) bool {
+	variadic := isVariadicParams(params)
+	if (variadic && len(results) < len(params)-1) || (!variadic && len(results) < len(params)) {
+		typeErrorf(exprPos(call), "not enough arguments in call to %s\n\thave %s\n\twant %s", callee, typesString(results), paramsString(params))
+		return false
+	}
+	if !variadic && len(results) > len(params) {
+		typeErrorf(exprPos(call), "too many arguments in call to %s\n\thave %s\n\twant %s", callee, typesString(results), paramsString(params))
+		return false
+	}
+	for i, result := range results {
+		if !checkAssignableValue(call, result, paramType(params, i), "argument to "+callee) {
+			return false
+		}
+	}
+	return true
+}
+
+func isComparisonOp(op string) bool {
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		return true
+	}
+	return false
+}
+
+func checkBinop(e *ExprBinop) bool {
+	if !checkSingleValue(e.left) || !checkSingleValue(e.right) {
+		return false
+	}
+	if e.op == "<<" || e.op == ">>" {
+		return checkShift(e) && checkConstOverflow(e)
+	}
+	isComparison := isComparisonOp(e.op)
+	if !isKnownOperand(e.left) || !isKnownOperand(e.right) {
+		return true
+	}
+	if !matchOperands(e.left, e.right, isComparison) {
+		pos := exprPos(e.left)
+		if isComparison {
+			pos = exprPos(e.right)
+		}
+		typeErrorf(pos, "invalid operation: %s (mismatched types %s and %s)",
+			exprString(e), operandTypeString(e.left), operandTypeString(e.right))
+		return false
+	}
+	lkind := untypedKind(e.left)
+	rkind := untypedKind(e.right)
+	if !checkRepresentable(e.left, e.right) || !checkRepresentable(e.right, e.left) {
+		return false
+	}
+
+	// the operand which decides the type of the operation
+	var x Expr = e.left
+	var gtype *Gtype
+	kind := lkind
+	if lkind == "" {
+		gtype = e.left.getGtype()
+	} else if rkind == "" {
+		x = e.right
+		gtype = e.right.getGtype()
+		kind = ""
+	} else if untypedRank(rkind) > untypedRank(lkind) {
+		kind = rkind
+	}
+	if !operatorDefined(e.op, gtype, kind) {
+		typeErrorf(exprPos(x), "invalid operation: operator %s not defined on %s", e.op, operandString(x))
+		return false
+	}
+	if e.op == "/" || e.op == "%" {
+		// a float variable divided by zero is an infinity or NaN
+		y := evalConst(e.right)
+		if y != nil && y.isZero() && (evalConst(e.left) != nil || e.left.getGtype().isInteger()) {
+			typeErrorf(exprPos(e.right), "invalid operation: division by zero")
+			return false
+		}
+	}
+	if (e.op == "==" || e.op == "!=") && gtype != nil && lkind != untypedNil && rkind != untypedNil {
+		switch gtype.getKind() {
+		case G_SLICE, G_MAP, G_FUNC:
+			typeErrorf(exprPos(e.left), "invalid operation: %s (%s can only be compared to nil)", exprString(e), kindName(gtype))
+			return false
+		}
+	}
+	return checkConstOverflow(e)
+}
+
+// the result of an operation on typed constants must be representable by the type
+func checkConstOverflow(e Expr) bool {
+	c := evalConst(e)
+	if c == nil || c.gtype == nil || c.representable(c.gtype) != "overflows" {
+		return true
+	}
+	typeErrorf(exprPos(e), "%s (constant %s of %s) overflows %s", exprString(e), c.String(), typeDescription(c.gtype), c.gtype.typeString())
+	return false
+}
+
+// matchOperands reports whether x and y can be operands of a binary operation
+func matchOperands(x Expr, y Expr, isComparison bool) bool {
+	xkind := untypedKind(x)
+	ykind := untypedKind(y)
+	if xkind == "" && ykind == "" {
+		xtype := x.getGtype()
+		ytype := y.getGtype()
+		if !isKnownType(xtype) || !isKnownType(ytype) || identicalTypes(xtype, ytype) {
+			return true
+		}
+		// an interface value can be compared to a value which implements it
+		return isComparison && (assignableType(xtype, ytype) || assignableType(ytype, xtype))
+	}
+	if xkind == "" {
+		return untypedConvertible(ykind, x.getGtype())
+	}
+	if ykind == "" {
+		return untypedConvertible(xkind, y.getGtype())
+	}
+	return untypedRank(xkind) > 0 && untypedRank(ykind) > 0 || xkind == ykind
+}
+
+// an untyped constant converted to the type of the other operand must be representable by it
+func checkRepresentable(cnst Expr, other Expr) bool {
+	if untypedKind(other) != "" {
+		return true
+	}
+	c := evalConst(cnst)
+	gtype := other.getGtype()
+	if c == nil || c.gtype != nil || !isKnownType(gtype) || !gtype.isNumeric() {
+		return true
+	}
+	switch c.representable(gtype) {
+	case "truncated":
+		typeErrorf(exprPos(cnst), "%s truncated to %s", operandString(cnst), gtype.typeString())
+		return false
+	case "overflows":
+		typeErrorf(exprPos(cnst), "%s overflows %s", operandString(cnst), gtype.typeString())
+		return false
+	}
+	return true
+}
+
+func operatorDefined(op string, gtype *Gtype, kind string) bool {
+	var numeric bool
+	var integer bool
+	var str bool
+	var boolean bool
+	if gtype != nil {
+		numeric = gtype.isNumeric()
+		integer = gtype.isInteger()
+		str = gtype.getKind() == G_STRING
+		boolean = gtype.getKind() == G_BOOL
+	} else {
+		numeric = untypedRank(kind) > 0
+		integer = kind == untypedInt || kind == untypedRune
+		str = kind == untypedString
+		boolean = kind == untypedBool
+	}
+	switch op {
+	case "+":
+		return numeric || str
+	case "-", "*", "/":
+		return numeric
+	case "%":
+		return integer
+	case "&&", "||":
+		return boolean
+	case "<", "<=", ">", ">=":
+		return numeric || str
+	}
+	return gtype != nil || kind != untypedNil
+}
+
+func checkShift(e *ExprBinop) bool {
+	if untypedKind(e.right) == "" {
+		gtype := e.right.getGtype()
+		if isKnownType(gtype) && !gtype.isInteger() {
+			typeErrorf(exprPos(e.right), "invalid operation: shift count %s must be integer", operandString(e.right))
+			return false
+		}
+	}
+	kind := untypedKind(e.left)
+	var shiftable bool = kind == untypedInt || kind == untypedRune
+	if kind == "" {
+		gtype := e.left.getGtype()
+		shiftable = !isKnownType(gtype) || gtype.isInteger()
+	} else if kind == untypedFloat {
+		shiftable = !isTruncated(e.left)
+	}
+	if !shiftable {
+		typeErrorf(exprPos(e.left), "invalid operation: shifted operand %s must be integer", operandString(e.left))
+		return false
+	}
+	return true
+}
+
+func checkUop(e *ExprUop) bool {
+	if !checkSingleValue(e.operand) || !isKnownOperand(e.operand) {
+		return true
+	}
+	kind := untypedKind(e.operand)
+	var gtype *Gtype
+	if kind == "" {
+		gtype = e.operand.getGtype()
+	}
+	switch e.op {
+	case "-", "+":
+		if untypedRank(kind) > 0 || (gtype != nil && gtype.isNumeric()) {
+			return checkConstOverflow(e)
+		}
+	case "!":
+		if kind == untypedBool || (gtype != nil && gtype.getKind() == G_BOOL) {
+			return true
+		}
+	case "*":
+		if gtype != nil && gtype.getKind() == G_POINTER {
+			return true
+		}
+		typeErrorf(exprPos(e.operand), "invalid operation: cannot indirect %s", operandString(e.operand))
+		return false
+	default:
+		return true
+	}
+	typeErrorf(exprPos(e.operand), "invalid operation: operator %s not defined on %s", e.op, operandString(e.operand))
+	return false
+}
+
+// a call of a function without results, or with multiple results, has no single value
+func checkSingleValue(e Expr) bool {
+	results, isCall := callResults(e)
+	if !isCall || len(results) == 1 {
+		return true
+	}
+	if len(results) == 0 {
+		typeErrorf(exprPos(e), "%s (no value) used as value", exprString(e))
+	} else {
+		typeErrorf(exprPos(e), "multiple-value %s (value of type %s) in single-value context", exprString(e), typesString(results))
+	}
+	return false
+}
+
+// checkAssignable reports an error unless the value of e can be assigned to a variable of type to
+func checkAssignable(e Expr, to *Gtype, context string) bool {
+	if !isKnownType(to) {
+		return true
+	}
+	if !checkSingleValue(e) {
+		return false
+	}
+	kind := untypedKind(e)
+	if kind == untypedNil {
+		if to.isNilable() {
+			return true
+		}
+		typeErrorf(exprPos(e), "cannot use nil as %s value in %s", to.typeString(), context)
+		return false
+	}
+	if kind == "" {
+		return checkAssignableValue(e, e.getGtype(), to, context)
+	}
+
+	if to.getKind() == G_INTERFACE {
+		// the constant is converted to its default type
+		dflt := defaultType(kind)
+		msg := implementsError(dflt, to)
+		if len(msg) > 0 {
+			typeErrorf(exprPos(e), "cannot use %s as %s value in %s: %s", typedOperandString(e, dflt), to.typeString(), context, msg)
+			return false
+		}
+		to = dflt
+	}
+	if !untypedConvertible(kind, to) {
+		typeErrorf(exprPos(e), "cannot use %s as %s value in %s", operandString(e), to.typeString(), context)
+		return false
+	}
+	if c := evalConst(e); c != nil && to.isNumeric() {
+		reason := c.representable(to)
+		if len(reason) > 0 {
+			typeErrorf(exprPos(e), "cannot use %s as %s value in %s (%s)", operandString(e), to.typeString(), context, reason)
+			return false
+		}
+	}
+	return true
+}
+
+// checkAssignableValue checks a value of type from produced by e
+func checkAssignableValue(e Expr, from *Gtype, to *Gtype, context string) bool {
+	if !isKnownType(from) || !isKnownType(to) || assignableType(from, to) || isRuntimeCall(e) {
+		return true
+	}
+	var operand string
+	if _, isCall := callResults(e); isCall {
+		operand = Sprintf("%s (value of %s)", exprString(e), typeDescription(from))
+	} else {
+		operand = operandString(e)
+	}
+	var reason string
+	if to.getKind() == G_INTERFACE {
+		reason = ": " + implementsError(from, to)
+	} else if from.getKind() == G_INTERFACE && implements(to, from) {
+		reason = ": need type assertion"
+	}
+	typeErrorf(exprPos(e), "cannot use %s as %s value in %s%s", operand, to.typeString(), context, reason)
+	return false
+}
+
+// https://golang.org/ref/spec#Assignability
+func assignableType(from *Gtype, to *Gtype) bool {
+	if identicalTypes(from, to) {
+		return true
+	}
+	if to.getKind() == G_INTERFACE {
+		return implements(from, to)
+	}
+	// identical underlying types and at least one of them is not a named type
+	if isNamedType(from) && isNamedType(to) {
+		return false
+	}
+	return identicalTypes(from.Underlying(), to.Underlying())
+}
+
+func isNamedType(gtype *Gtype) bool {
+	gtype = gtype.unwrapPredeclared()
+	return gtype.kind == G_NAMED || (G_INT <= gtype.kind && gtype.kind <= G_FLOAT32) || gtype.kind == G_STRING
+}
+
+// whether the type is resolved and inferred
+func isKnownType(gtype *Gtype) bool {
+	if gtype == nil || gtype.kind == G_DEPENDENT {
+		return false
+	}
+	kind := gtype.getKind()
+	return kind != G_UNKOWNE && kind != G_NAMED
+}
+
+func isKnownOperand(e Expr) bool {
+	return untypedKind(e) != "" || isKnownType(e.getGtype())
+}
+
+func implements(gtype *Gtype, iface *Gtype) bool {
+	msg := implementsError(gtype, iface)
+	return len(msg) == 0
+}
+
+// implementsError returns why a value of gtype can not be converted to the interface iface,
+// or an empty string if it can.
+func implementsError(gtype *Gtype, iface *Gtype) string {
+	var names []string
+	for name, _ := range iface.Underlying().imethods {
+		names = append(names, string(name))
+	}
+	util.SortStrings(names)
+	for _, sname := range names {
+		name := identifier(sname)
+		want, _ := imethodGet(iface.Underlying().imethods, name)
+		var reason string
+		if gtype.getKind() == G_INTERFACE {
+			have, ok := imethodGet(gtype.Underlying().imethods, name)
+			if !ok {
+				reason = Sprintf("missing method %s", sname)
+			} else if !identicalSignatures(have, want) {
+				reason = Sprintf("wrong type for method %s", sname)
+			}
+		} else {
+			named := gtype
+			if gtype.kind == G_POINTER {
+				named = gtype.origType
+			}
+			var ref *ExprFuncRef
+			var ok bool
+			if named.kind == G_NAMED && named.relation.gtype != nil {
+				ref, ok = methodGet(named.relation.gtype.methods, name)
+			}
+			if !ok {
+				reason = Sprintf("missing method %s", sname)
+			} else if gtype.kind != G_POINTER && ref.funcdef.hasPointerReceiver() {
+				reason = Sprintf("method %s has pointer receiver", sname)
+			} else if !identicalSignatures(ref.funcdef.getSignature(), want) {
+				reason = Sprintf("wrong type for method %s", sname)
+			}
+		}
+		if len(reason) > 0 {
+			return Sprintf("%s does not implement %s (%s)", gtype.typeString(), iface.typeString(), reason)
+		}
+	}
+	return ""
+}
+
+// untypedKind returns the kind of an untyped constant or value like "untyped int",
+// or an empty string if e has a type.
+func untypedKind(e Expr) string {
+	if c := evalConst(e); c != nil {
+		return c.kind
+	}
+	e = unwrapRel(e)
+	switch e.(type) {
+	case *ExprNilLiteral:
+		return untypedNil
+	case *ExprUop:
+		uop := e.(*ExprUop)
+		if uop.op == "!" && untypedKind(uop.operand) == untypedBool {
+			return untypedBool
+		}
+	case *ExprBinop:
+		binop := e.(*ExprBinop)
+		if isComparisonOp(binop.op) {
+			return untypedBool
+		}
+		left := untypedKind(binop.left)
+		if binop.op == "<<" || binop.op == ">>" {
+			// a non-constant shift of an untyped constant
+			return left
+		}
+		if (binop.op == "&&" || binop.op == "||") && left == untypedBool && untypedKind(binop.right) == untypedBool {
+			return untypedBool
+		}
+	}
+	return ""
+}
+
+// the order of numeric kinds. An operation on two numeric kinds results in the later one.
+func untypedRank(kind string) int {
+	switch kind {
+	case untypedInt:
+		return 1
+	case untypedRune:
+		return 2
+	case untypedFloat:
+		return 3
+	}
+	return 0
+}
+
+func defaultType(kind string) *Gtype {
+	switch kind {
+	case untypedBool:
+		return gBool
+	case untypedRune:
+		return gInt32
+	case untypedFloat:
+		return gFloat64
+	case untypedString:
+		return gString
+	}
+	return gInt
+}
+
+// whether an untyped constant or value of kind can be converted to gtype
+func untypedConvertible(kind string, gtype *Gtype) bool {
+	if !isKnownType(gtype) || gtype.getKind() == G_INTERFACE {
+		return true
+	}
+	switch kind {
+	case untypedBool:
+		return gtype.getKind() == G_BOOL
+	case untypedString:
+		return gtype.getKind() == G_STRING
+	case untypedNil:
+		return gtype.isNilable()
+	}
+	return gtype.isNumeric()
+}
+
+// whether a numeric constant has a fractional part
+func isTruncated(e Expr) bool {
+	c := evalConst(e)
+	return c != nil && c.isNumeric() && !c.isIntegral()
+}
+
+// v, ok = x
+func isCommaOk(e Expr) bool {
+	switch e.(type) {
+	case *ExprIndex:
+		return e.(*ExprIndex).collection.getGtype().getKind() == G_MAP
+	case *ExprTypeAssertion, *ExprChanRecv:
+		return true
+	}
+	return false
+}
+
+// callResults returns the result types of a function call.
+// isCall is false if e is not a function call.
+func callResults(e Expr) ([]*Gtype, bool) {
+	var results []*Gtype
+	switch e.(type) {
+	case *ExprFuncallOrConversion:
+		funcall := e.(*ExprFuncallOrConversion)
+		if funcall.typ != nil || funcall.rel.expr == nil {
+			// conversion
+			return results, false
+		}
+		return funcall.getRettypes(), true
+	case *ExprMethodcall:
+		return e.(*ExprMethodcall).getRettypes(), true
+	case *ExprFuncValueCall:
+		return e.(*ExprFuncValueCall).getRettypes(), true
+	}
+	return results, false
+}
+
+// the runtime functions like malloc return raw addresses
+func isRuntimeCall(e Expr) bool {
+	funcall, ok := e.(*ExprFuncallOrConversion)
+	if !ok || funcall.typ != nil {
+		return false
+	}
+	ref, ok := funcall.rel.expr.(*ExprFuncRef)
+	return ok && ref.funcdef.pkg == IRuntimePkgName
+}
+
+// the called function of a call expression like "f" or "x.m"
+func calleeString(call Expr) string {
+	switch call.(type) {
+	case *ExprFuncallOrConversion:
+		return exprString(call.(*ExprFuncallOrConversion).rel)
+	case *ExprMethodcall:
+		methodCall := call.(*ExprMethodcall)
+		return exprString(methodCall.receiver) + "." + string(methodCall.fname)
+	case *ExprFuncValueCall:
+		return exprString(call.(*ExprFuncValueCall).fn)
+	}
+	return exprString(call)
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return Sprintf("%d %s", n, word)
+	}
+	return Sprintf("%d %ss", n, word)
+}
+
+// (int, string)
+func typesString(gtypes []*Gtype) string {
+	var s string = "("
+	for i, gtype := range gtypes {
+		if i > 0 {
+			s = s + ", "
+		}
+		s = s + gtype.typeString()
+	}
+	return s + ")"
+}
+
+// (int, ...string)
+func paramsString(params []*ExprVariable) string {
+	var s string = "("
+	for i, param := range params {
+		if i > 0 {
+			s = s + ", "
+		}
+		if param.isVariadic {
+			s = s + "..." + param.gtype.elementType.typeString()
+		} else {
+			s = s + param.gtype.typeString()
+		}
+	}
+	return s + ")"
+}
+
+// the types of arguments. Untyped numeric constants are shown as "number".
+func argTypesString(args []Expr) string {
+	var s string = "("
+	for i, arg := range args {
+		if i > 0 {
+			s = s + ", "
+		}
+		kind := untypedKind(arg)
+		switch kind {
+		case "":
+			s = s + arg.getGtype().typeString()
+		case untypedString:
+			s = s + "string"
+		case untypedBool:
+			s = s + "bool"
+		case untypedNil:
+			s = s + "nil"
+		default:
+			s = s + "number"
+		}
+	}
+	return s + ")"
+}
+
+func operandTypeString(e Expr) string {
+	kind := untypedKind(e)
+	if kind != "" {
+		return kind
+	}
+	return e.getGtype().typeString()
+}
+
+// "x (variable of type int)", "1 (untyped int constant)" etc.
+func operandString(e Expr) string {
+	s := exprString(e)
+	kind := untypedKind(e)
+	if kind == untypedNil {
+		return "nil"
+	}
+	if kind != "" {
+		if !isConstExpr(e) {
+			return Sprintf("%s (%s value)", s, kind)
+		}
+		val := constValueString(e, kind)
+		if len(val) == 0 || val == s {
+			return Sprintf("%s (%s constant)", s, kind)
+		}
+		return Sprintf("%s (%s constant %s)", s, kind, val)
+	}
+	gtype := e.getGtype()
+	if cnst, ok := unwrapRel(e).(*ExprConstVariable); ok {
+		return typedOperandString(cnst, gtype)
+	}
+	return Sprintf("%s (%s of %s)", s, operandMode(unwrapRel(e)), typeDescription(gtype))
+}
+
+// a constant of gtype like "c (constant 3 of type int)"
+func typedOperandString(e Expr, gtype *Gtype) string {
+	s := exprString(e)
+	var val string
+	if c := evalConst(e); c != nil {
+		val = c.String()
+	}
+	if len(val) == 0 || val == s {
+		return Sprintf("%s (constant of %s)", s, typeDescription(gtype))
+	}
+	return Sprintf("%s (constant %s of %s)", s, val, typeDescription(gtype))
+}
+
+func isConstExpr(e Expr) bool {
+	return evalConst(e) != nil
+}
+
+// the value of an untyped constant in the Go syntax, or an empty string if it is unknown
+func constValueString(e Expr, kind string) string {
+	c := evalConst(e)
+	if c == nil || c.kind != kind {
+		return ""
+	}
+	return c.String()
+}
+
+// whether e denotes a variable, a map element or a value
+func operandMode(e Expr) string {
+	switch e.(type) {
+	case *ExprVariable:
+		return "variable"
+	case *ExprStructField:
+		field := e.(*ExprStructField)
+		if field.getMethodSig() != nil {
+			return "value"
+		}
+		strct := unwrapRel(field.strct)
+		if strct.getGtype().getKind() == G_POINTER || operandMode(strct) == "variable" {
+			return "variable"
+		}
+	case *ExprIndex:
+		index := e.(*ExprIndex)
+		switch index.collection.getGtype().getKind() {
+		case G_MAP:
+			return "map index expression"
+		case G_SLICE, G_POINTER:
+			return "variable"
+		case G_ARRAY:
+			return operandMode(unwrapRel(index.collection))
+		}
+	case *ExprUop:
+		if e.(*ExprUop).op == "*" {
+			return "variable"
+		}
+	}
+	return "value"
+}
+
+// "type int", or "struct type T" for a defined type T
+func typeDescription(gtype *Gtype) string {
+	if gtype.unwrapPredeclared().kind == G_NAMED {
+		return kindName(gtype) + " type " + gtype.typeString()
+	}
+	return "type " + gtype.typeString()
+}
+
+// the kind of the underlying type like "struct" or "int"
+func kindName(gtype *Gtype) string {
+	switch gtype.getKind() {
+	case G_STRUCT:
+		return "struct"
+	case G_INTERFACE:
+		return "interface"
+	case G_SLICE:
+		return "slice"
+	case G_ARRAY:
+		return "array"
+	case G_MAP:
+		return "map"
+	case G_POINTER:
+		return "pointer"
+	case G_FUNC:
+		return "func"
+	case G_CHAN:
+		return "chan"
+	}
+	return gtype.Underlying().typeString()
+}
+
+// a string literal in the Go syntax
+func quoteString(b []byte) string {
+	var r []byte
+	r = append(r, '"')
+	for _, c := range b {
+		switch c {
+		case '"', '\\':
+			r = append(r, '\\')
+			r = append(r, c)
+		case '\n':
+			r = append(r, '\\')
+			r = append(r, 'n')
+		case '\t':
+			r = append(r, '\\')
+			r = append(r, 't')
+		default:
+			r = append(r, c)
+		}
+	}
+	r = append(r, '"')
+	return string(r)
+}
+
+// the position where an expression begins
+// https://golang.org/ref/spec#Operator_precedence
+func precedence(op string) int {
+	switch op {
+	case "*", "/", "%", "<<", ">>", "&", "&^":
+		return 5
+	case "+", "-", "|", "^":
+		return 4
+	case "==", "!=", "<", "<=", ">", ">=":
+		return 3
+	case "&&":
+		return 2
+	}
+	return 1
+}
+
+func exprPos(e Expr) *Token {
+	switch e.(type) {
+	case *Relation:
+		return e.(*Relation).tok
+	case *ExprBinop:
+		return exprPos(e.(*ExprBinop).left)
+	case *ExprFuncallOrConversion:
+		funcall := e.(*ExprFuncallOrConversion)
+		if funcall.rel != nil && funcall.rel.tok != nil {
+			return funcall.rel.tok
+		}
+	case *ExprMethodcall:
+		return exprPos(e.(*ExprMethodcall).receiver)
+	case *ExprFuncValueCall:
+		return exprPos(e.(*ExprFuncValueCall).fn)
+	case *ExprStructField:
+		return exprPos(e.(*ExprStructField).strct)
+	case *ExprIndex:
+		return exprPos(e.(*ExprIndex).collection)
+	case *ExprSlice:
+		return exprPos(e.(*ExprSlice).collection)
+	case *ExprTypeAssertion:
+		return exprPos(e.(*ExprTypeAssertion).expr)
+	case *ExprVaArg:
+		return exprPos(e.(*ExprVaArg).expr)
+	case *ExprStructLiteral:
+		return e.(*ExprStructLiteral).strctname.tok
+	}
+	return e.token()
+}
+
+func exprsString(exprs []Expr) string {
+	var s string
+	for i, e := range exprs {
+		if i > 0 {
+			s = s + ", "
+		}
+		s = s + exprString(e)
+	}
+	return s
+}
+
+// composite literals are abbreviated like T{…}
+func compositeString(gtype *Gtype, n int) string {
+	if n == 0 {
+		return gtype.typeString() + "{}"
+	}
+	return gtype.typeString() + "{…}"
+}
+
+// exprString returns e in the Go syntax for diagnostics
+func exprString(e Expr) string {
+	switch e.(type) {
+	case nil:
+		return ""
+	case *Relation:
+		rel := e.(*Relation)
+		var pkg identifier
+		switch rel.expr.(type) {
+		case *ExprFuncRef:
+			decl := rel.expr.(*ExprFuncRef).funcdef
+			if len(decl.builtinname) == 0 && decl.pkg != IRuntimePkgName {
+				pkg = decl.pkg
+			}
+		case *ExprVariable:
+			variable := rel.expr.(*ExprVariable)
+			if variable.isGlobal {
+				pkg = variable.pkg
+			}
+		}
+		if len(pkg) > 0 && pkg != checkingPkg && pkg != "builtin" {
+			return string(pkg) + "." + string(rel.name)
+		}
+		return string(rel.name)
+	case *ExprVariable:
+		return string(e.(*ExprVariable).varname)
+	case *ExprConstVariable:
+		return string(e.(*ExprConstVariable).name)
+	case *ExprFuncRef:
+		return string(e.(*ExprFuncRef).funcdef.fname)
+	case *ExprNilLiteral:
+		return "nil"
+	case *IrExprBoolVal:
+		if e.(*IrExprBoolVal).bol {
+			return "true"
+		}
+		return "false"
+	case *ExprNumberLiteral:
+		lit := e.(*ExprNumberLiteral)
+		if lit.isRune {
+			return "'" + string(rune(lit.val)) + "'"
+		}
+		if lit.tok != nil {
+			return lit.tok.sval
+		}
+		return Sprintf("%d", lit.val)
+	case *ExprFloatLiteral:
+		return e.(*ExprFloatLiteral).val
+	case *ExprStringLiteral:
+		return quoteString(e.(*ExprStringLiteral).val)
+	case *ExprFuncallOrConversion:
+		funcall := e.(*ExprFuncallOrConversion)
+		args := exprsString(funcall.args)
+		if funcall.typ != nil {
+			return "(" + funcall.typ.typeString() + ")(" + args + ")"
+		}
+		if funcall.typarg != nil {
+			if len(args) > 0 {
+				args = ", " + args
+			}
+			args = funcall.typarg.typeString() + args
+		}
+		return exprString(funcall.rel) + "(" + args + ")"
+	case *ExprMethodcall:
+		methodCall := e.(*ExprMethodcall)
+		return exprString(methodCall.receiver) + "." + string(methodCall.fname) + "(" + exprsString(methodCall.args) + ")"
+	case *ExprFuncValueCall:
+		call := e.(*ExprFuncValueCall)
+		return exprString(call.fn) + "(" + exprsString(call.args) + ")"
+	case *ExprBinop:
+		binop := e.(*ExprBinop)
+		left := exprString(binop.left)
+		if operand, ok := binop.left.(*ExprBinop); ok && precedence(operand.op) < precedence(binop.op) {
+			left = "(" + left + ")"
+		}
+		right := exprString(binop.right)
+		if operand, ok := binop.right.(*ExprBinop); ok && precedence(operand.op) <= precedence(binop.op) {
+			right = "(" + right + ")"
+		}
+		return left + " " + binop.op + " " + right
+	case *ExprUop:
+		uop := e.(*ExprUop)
+		return uop.op + exprString(uop.operand)
+	case *ExprStructField:
+		field := e.(*ExprStructField)
+		return exprString(field.strct) + "." + string(field.fieldname)
+	case *ExprIndex:
+		index := e.(*ExprIndex)
+		return exprString(index.collection) + "[" + exprString(index.index) + "]"
+	case *ExprSlice:
+		slice := e.(*ExprSlice)
+		s := exprString(slice.collection) + "[" + exprString(slice.low) + ":" + exprString(slice.high)
+		if slice.max != nil {
+			s = s + ":" + exprString(slice.max)
+		}
+		return s + "]"
+	case *ExprStructLiteral:
+		lit := e.(*ExprStructLiteral)
+		return compositeString(lit.getGtype(), len(lit.fields))
+	case *ExprSliceLiteral:
+		lit := e.(*ExprSliceLiteral)
+		return compositeString(lit.gtype, len(lit.values))
+	case *ExprArrayLiteral:
+		lit := e.(*ExprArrayLiteral)
+		return compositeString(lit.gtype, len(lit.values))
+	case *ExprMapLiteral:
+		lit := e.(*ExprMapLiteral)
+		return compositeString(lit.gtype, len(lit.elements))
+	case *ExprFuncLiteral:
+		return "(func" + e.(*ExprFuncLiteral).funcdef.getSignature().typeString() + " literal)"
+	case *ExprTypeAssertion:
+		assertion := e.(*ExprTypeAssertion)
+		return exprString(assertion.expr) + ".(" + assertion.gtype.typeString() + ")"
+	case *ExprTypeSwitchGuard:
+		return exprString(e.(*ExprTypeSwitchGuard).expr) + ".(type)"
+	case *ExprChanRecv:
+		return "<-" + exprString(e.(*ExprChanRecv).ch)
+	case *ExprVaArg:
+		return exprString(e.(*ExprVaArg).expr) + "..."
+	}
+	return e.token().sval
+}
@@ -0,0 +1,932 @@
+// Constant expressions are evaluated at compile time.
+// https://golang.org/ref/spec#Constant_expressions
+//
+// Untyped integer constants have arbitrary precision,
+// and untyped float constants are kept as exact fractions.
+package main
+
+// the base of the digits of bigInt
+const bigBase = 10000
+
+// bigInt is an integer of arbitrary precision.
+// digits are in base bigBase, the least significant first, without leading zeros.
+type bigInt struct {
+	neg    bool
+	digits []int
+}
+
+func newBigInt(n int) *bigInt {
+	r := &bigInt{}
+	if n < 0 {
+		r.neg = true
+	}
+	for n != 0 {
+		d := n % bigBase
+		if d < 0 {
+			d = -d
+		}
+		r.digits = append(r.digits, d)
+		n = n / bigBase
+	}
+	return r
+}
+
+func makeBigInt(neg bool, digits []int) *bigInt {
+	digits = trimDigits(digits)
+	return &bigInt{
+		neg:    neg && len(digits) > 0,
+		digits: digits,
+	}
+}
+
+// parses an integer literal like 255, 0xff, 0o377, 0377 or 0b1111_1111
+func parseBigInt(s string) *bigInt {
+	base := 10
+	if len(s) >= 2 && s[0] == '0' {
+		switch s[1] {
+		case 'x', 'X':
+			base = 16
+			s = s[2:]
+		case 'o', 'O':
+			base = 8
+			s = s[2:]
+		case 'b', 'B':
+			base = 2
+			s = s[2:]
+		default:
+			base = 8
+			s = s[1:]
+		}
+	}
+	r := newBigInt(0)
+	for _, c := range []byte(s) {
+		if c != '_' {
+			r = bigAdd(bigMul(r, newBigInt(base)), newBigInt(digitVal(c)))
+		}
+	}
+	return r
+}
+
+func trimDigits(x []int) []int {
+	n := len(x)
+	for n > 0 && x[n-1] == 0 {
+		n--
+	}
+	return x[:n]
+}
+
+func cmpDigits(x []int, y []int) int {
+	if len(x) != len(y) {
+		if len(x) < len(y) {
+			return -1
+		}
+		return 1
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		if x[i] != y[i] {
+			if x[i] < y[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func addDigits(x []int, y []int) []int {
+	var r []int
+	var carry int
+	for i := 0; i < len(x) || i < len(y) || carry > 0; i++ {
+		d := carry
+		if i < len(x) {
+			d = d + x[i]
+		}
+		if i < len(y) {
+			d = d + y[i]
+		}
+		r = append(r, d%bigBase)
+		carry = d / bigBase
+	}
+	return r
+}
+
+// x - y where x >= y
+func subDigits(x []int, y []int) []int {
+	var r []int
+	var borrow int
+	for i := 0; i < len(x); i++ {
+		d := x[i] - borrow
+		if i < len(y) {
+			d = d - y[i]
+		}
+		borrow = 0
+		if d < 0 {
+			d = d + bigBase
+			borrow = 1
+		}
+		r = append(r, d)
+	}
+	return trimDigits(r)
+}
+
+func mulDigits(x []int, y []int) []int {
+	var r []int
+	for i := 0; i < len(x)+len(y); i++ {
+		r = append(r, 0)
+	}
+	for i := 0; i < len(x); i++ {
+		var carry int
+		for j := 0; j < len(y); j++ {
+			d := r[i+j] + x[i]*y[j] + carry
+			r[i+j] = d % bigBase
+			carry = d / bigBase
+		}
+		r[i+len(y)] = carry
+	}
+	return trimDigits(r)
+}
+
+// the quotient and the remainder of x / y, where y is not zero
+func divDigits(x []int, y []int) ([]int, []int) {
+	var q []int
+	for i := 0; i < len(x); i++ {
+		q = append(q, 0)
+	}
+	var r []int
+	for i := len(x) - 1; i >= 0; i-- {
+		// r = r * bigBase + x[i]
+		shifted := []int{x[i]}
+		for _, d := range r {
+			shifted = append(shifted, d)
+		}
+		r = trimDigits(shifted)
+		// the largest d such that y * d <= r
+		lo := 0
+		hi := bigBase - 1
+		for lo < hi {
+			mid := (lo + hi + 1) / 2
+			if cmpDigits(mulDigits(y, []int{mid}), r) <= 0 {
+				lo = mid
+			} else {
+				hi = mid - 1
+			}
+		}
+		r = subDigits(r, mulDigits(y, []int{lo}))
+		q[i] = lo
+	}
+	return trimDigits(q), r
+}
+
+func (x *bigInt) isZero() bool {
+	return len(x.digits) == 0
+}
+
+func (x *bigInt) sign() int {
+	if x.isZero() {
+		return 0
+	}
+	if x.neg {
+		return -1
+	}
+	return 1
+}
+
+func bigNeg(x *bigInt) *bigInt {
+	return makeBigInt(!x.neg, x.digits)
+}
+
+func bigAbs(x *bigInt) *bigInt {
+	return makeBigInt(false, x.digits)
+}
+
+func bigAdd(x *bigInt, y *bigInt) *bigInt {
+	if x.neg == y.neg {
+		return makeBigInt(x.neg, addDigits(x.digits, y.digits))
+	}
+	if cmpDigits(x.digits, y.digits) >= 0 {
+		return makeBigInt(x.neg, subDigits(x.digits, y.digits))
+	}
+	return makeBigInt(y.neg, subDigits(y.digits, x.digits))
+}
+
+func bigSub(x *bigInt, y *bigInt) *bigInt {
+	return bigAdd(x, bigNeg(y))
+}
+
+func bigMul(x *bigInt, y *bigInt) *bigInt {
+	return makeBigInt(x.neg != y.neg, mulDigits(x.digits, y.digits))
+}
+
+// the truncated quotient and the remainder like the / and % operators
+func bigQuoRem(x *bigInt, y *bigInt) (*bigInt, *bigInt) {
+	q, r := divDigits(x.digits, y.digits)
+	return makeBigInt(x.neg != y.neg, q), makeBigInt(x.neg, r)
+}
+
+func bigQuo(x *bigInt, y *bigInt) *bigInt {
+	q, _ := bigQuoRem(x, y)
+	return q
+}
+
+func bigCmp(x *bigInt, y *bigInt) int {
+	return bigSub(x, y).sign()
+}
+
+// base ** n
+func bigPow(base int, n int) *bigInt {
+	r := newBigInt(1)
+	b := newBigInt(base)
+	for ; n > 0; n = n / 2 {
+		if n%2 == 1 {
+			r = bigMul(r, b)
+		}
+		b = bigMul(b, b)
+	}
+	return r
+}
+
+// x >> n rounds toward negative infinity
+func bigShr(x *bigInt, n int) *bigInt {
+	q, r := bigQuoRem(x, bigPow(2, n))
+	if x.neg && !r.isZero() {
+		q = bigSub(q, newBigInt(1))
+	}
+	return q
+}
+
+func bigGcd(x *bigInt, y *bigInt) *bigInt {
+	a := bigAbs(x)
+	b := bigAbs(y)
+	for !b.isZero() {
+		_, r := bigQuoRem(a, b)
+		a = b
+		b = r
+	}
+	return a
+}
+
+// the value as a 64-bit integer. Values up to 1<<64-1 wrap around.
+func (x *bigInt) toInt() int {
+	var n int
+	for i := len(x.digits) - 1; i >= 0; i-- {
+		if x.neg {
+			n = n*bigBase - x.digits[i]
+		} else {
+			n = n*bigBase + x.digits[i]
+		}
+	}
+	return n
+}
+
+// the decimal representation
+func (x *bigInt) String() string {
+	if x.isZero() {
+		return "0"
+	}
+	var b []byte
+	if x.neg {
+		b = append(b, '-')
+	}
+	top := len(x.digits) - 1
+	for i := top; i >= 0; i-- {
+		d := x.digits[i]
+		for div := bigBase / 10; div >= 1; div = div / 10 {
+			if i == top && d < div && div > 1 {
+				// leading zeros
+				continue
+			}
+			b = append(b, byte('0'+d/div%10))
+		}
+	}
+	return string(b)
+}
+
+// constant is the value of a constant expression
+type constant struct {
+	kind  string // the kind of an untyped constant
+	gtype *Gtype // the type of a typed constant
+	bol   bool
+	num   *bigInt // an integer, or the numerator of a float
+	den   *bigInt // the denominator of a number, which is positive
+	str   string
+}
+
+// the index of iota while checking a constant declaration, or -1
+var constIotaIndex int = -1
+
+func newNumConst(kind string, gtype *Gtype, num *bigInt, den *bigInt) *constant {
+	if den.neg {
+		num = bigNeg(num)
+		den = bigNeg(den)
+	}
+	g := bigGcd(num, den)
+	if !g.isZero() && bigCmp(g, newBigInt(1)) != 0 {
+		num = bigQuo(num, g)
+		den = bigQuo(den, g)
+	}
+	return &constant{
+		kind:  kind,
+		gtype: gtype,
+		num:   num,
+		den:   den,
+	}
+}
+
+func newIntConst(n int) *constant {
+	return newNumConst(untypedInt, nil, newBigInt(n), newBigInt(1))
+}
+
+func (c *constant) isInt() bool {
+	if c.gtype != nil {
+		return c.gtype.isInteger()
+	}
+	return c.kind == untypedInt || c.kind == untypedRune
+}
+
+func (c *constant) isFloat() bool {
+	if c.gtype != nil {
+		return c.gtype.isFloat()
+	}
+	return c.kind == untypedFloat
+}
+
+func (c *constant) isNumeric() bool {
+	return c.num != nil
+}
+
+func (c *constant) isBool() bool {
+	if c.gtype != nil {
+		return c.gtype.getKind() == G_BOOL
+	}
+	return c.kind == untypedBool
+}
+
+func (c *constant) isString() bool {
+	if c.gtype != nil {
+		return c.gtype.getKind() == G_STRING
+	}
+	return c.kind == untypedString
+}
+
+// whether a number has no fractional part
+func (c *constant) isIntegral() bool {
+	return c.isNumeric() && bigCmp(c.den, newBigInt(1)) == 0
+}
+
+func (c *constant) isZero() bool {
+	return c.isNumeric() && c.num.isZero()
+}
+
+// the type of the constant, or the default type of an untyped constant
+func (c *constant) getGtype() *Gtype {
+	if c.gtype != nil {
+		return c.gtype
+	}
+	return defaultType(c.kind)
+}
+
+// the integer part of a number
+func (c *constant) intPart() *bigInt {
+	return bigQuo(c.num, c.den)
+}
+
+// the value as a 64-bit integer for the machine code
+func (c *constant) toInt() int {
+	if c.isBool() {
+		if c.bol {
+			return 1
+		}
+		return 0
+	}
+	return c.intPart().toInt()
+}
+
+// the value as a signed 64-bit integer for the assembler.
+// Values up to 1<<64-1 wrap around.
+func (c *constant) intText() string {
+	if c.isBool() {
+		return Sprintf("%d", c.toInt())
+	}
+	n := c.intPart()
+	if bigCmp(n, bigPow(2, 63)) >= 0 {
+		n = bigSub(n, bigPow(2, 64))
+	}
+	return n.String()
+}
+
+// evalConst returns the value of a constant expression, or nil if e is not constant
+func evalConst(e Expr) *constant {
+	return evalConstIota(e, constIotaIndex)
+}
+
+func evalConstIota(e Expr, iotaIndex int) *constant {
+	e = unwrapRel(e)
+	switch e.(type) {
+	case *IrExprBoolVal:
+		return &constant{
+			kind: untypedBool,
+			bol:  e.(*IrExprBoolVal).bol,
+		}
+	case *ExprNumberLiteral:
+		lit := e.(*ExprNumberLiteral)
+		kind := untypedInt
+		if lit.isRune {
+			kind = untypedRune
+		}
+		num := newBigInt(lit.val)
+		if lit.tok != nil && lit.tok.isTypeInt() {
+			// the literal may exceed 64 bits
+			num = parseBigInt(lit.tok.sval)
+		}
+		return newNumConst(kind, nil, num, newBigInt(1))
+	case *ExprFloatLiteral:
+		lit := e.(*ExprFloatLiteral)
+		c := parseFloatConst(lit.val)
+		if lit.gtype != nil {
+			c.kind = ""
+			c.gtype = lit.gtype
+		}
+		return c
+	case *ExprStringLiteral:
+		return &constant{
+			kind: untypedString,
+			str:  string(e.(*ExprStringLiteral).val),
+		}
+	case *ExprConstVariable:
+		cnst := e.(*ExprConstVariable)
+		if cnst == eIota {
+			if iotaIndex < 0 {
+				return nil
+			}
+			return newIntConst(iotaIndex)
+		}
+		if cnst.val == nil {
+			return nil
+		}
+		c := evalConstIota(cnst.val, cnst.iotaIndex)
+		if c == nil || cnst.gtype == nil {
+			return c
+		}
+		if _, ok := unwrapRel(cnst.val).(*IrExprBoolVal); ok {
+			// true and false are untyped
+			return c
+		}
+		return convertConst(c, cnst.gtype)
+	case *ExprUop:
+		return evalConstUop(e.(*ExprUop), iotaIndex)
+	case *ExprBinop:
+		return evalConstBinop(e.(*ExprBinop), iotaIndex)
+	case *ExprFuncallOrConversion:
+		funcall := e.(*ExprFuncallOrConversion)
+		if len(funcall.args) != 1 {
+			return nil
+		}
+		c := evalConstIota(funcall.args[0], iotaIndex)
+		if c == nil {
+			return nil
+		}
+		if funcall.typ != nil {
+			return convertConst(c, funcall.typ)
+		}
+		if funcall.rel.gtype != nil {
+			return convertConst(c, funcall.getGtype())
+		}
+		if ref, ok := funcall.rel.expr.(*ExprFuncRef); ok && ref.funcdef == builtinLen {
+			return constLen(c)
+		}
+		return nil
+	case *IrExprConversion:
+		conv := e.(*IrExprConversion)
+		c := evalConstIota(conv.arg, iotaIndex)
+		if c == nil {
+			return nil
+		}
+		return convertConst(c, conv.toGtype)
+	case *ExprLen:
+		c := evalConstIota(e.(*ExprLen).arg, iotaIndex)
+		if c == nil {
+			return nil
+		}
+		return constLen(c)
+	}
+	return nil
+}
+
+// len(s) of a constant string s is a constant
+func constLen(c *constant) *constant {
+	if !c.isString() {
+		return nil
+	}
+	return newNumConst("", gInt, newBigInt(len(c.str)), newBigInt(1))
+}
+
+// the value of c converted to gtype, or nil if it cannot be a constant of gtype
+func convertConst(c *constant, gtype *Gtype) *constant {
+	if gtype.isInteger() {
+		if !c.isNumeric() {
+			return nil
+		}
+		return newNumConst("", gtype, c.intPart(), newBigInt(1))
+	}
+	if gtype.isFloat() {
+		if !c.isNumeric() {
+			return nil
+		}
+		return newNumConst("", gtype, c.num, c.den)
+	}
+	switch gtype.getKind() {
+	case G_BOOL:
+		if c.isBool() {
+			return &constant{
+				gtype: gtype,
+				bol:   c.bol,
+			}
+		}
+	case G_STRING:
+		if c.isString() {
+			return &constant{
+				gtype: gtype,
+				str:   c.str,
+			}
+		}
+	}
+	return nil
+}
+
+func evalConstUop(uop *ExprUop, iotaIndex int) *constant {
+	c := evalConstIota(uop.operand, iotaIndex)
+	if c == nil {
+		return nil
+	}
+	switch uop.op {
+	case "+":
+		if c.isNumeric() {
+			return c
+		}
+	case "-":
+		if c.isNumeric() {
+			return newNumConst(c.kind, c.gtype, bigNeg(c.num), c.den)
+		}
+	case "!":
+		if c.isBool() {
+			return &constant{
+				kind:  c.kind,
+				gtype: c.gtype,
+				bol:   !c.bol,
+			}
+		}
+	}
+	return nil
+}
+
+func evalConstBinop(binop *ExprBinop, iotaIndex int) *constant {
+	x := evalConstIota(binop.left, iotaIndex)
+	if x == nil {
+		return nil
+	}
+	y := evalConstIota(binop.right, iotaIndex)
+	if y == nil {
+		return nil
+	}
+	op := binop.op
+	if op == "<<" || op == ">>" {
+		if !x.isIntegral() || !y.isIntegral() || y.num.neg || bigCmp(y.num, newBigInt(bigBase)) > 0 {
+			return nil
+		}
+		kind := x.kind
+		if kind == untypedFloat {
+			// an untyped float operand is shifted as an integer
+			kind = untypedInt
+		}
+		n := y.num.toInt()
+		if op == "<<" {
+			return newNumConst(kind, x.gtype, bigMul(x.num, bigPow(2, n)), x.den)
+		}
+		return newNumConst(kind, x.gtype, bigShr(x.num, n), x.den)
+	}
+	if op == "&&" || op == "||" {
+		if !x.isBool() || !y.isBool() {
+			return nil
+		}
+		var bol bool
+		if op == "&&" {
+			bol = x.bol && y.bol
+		} else {
+			bol = x.bol || y.bol
+		}
+		return &constant{
+			kind: untypedBool,
+			bol:  bol,
+		}
+	}
+	if isComparisonOp(op) {
+		return compareConst(op, x, y)
+	}
+
+	// the result has the type of a typed operand, or the larger kind of untyped operands
+	kind := x.kind
+	gtype := x.gtype
+	if gtype == nil {
+		if y.gtype != nil {
+			kind = ""
+			gtype = y.gtype
+		} else if untypedRank(y.kind) > untypedRank(kind) {
+			kind = y.kind
+		}
+	}
+	if x.isString() && y.isString() {
+		if op != "+" {
+			return nil
+		}
+		return &constant{
+			kind:  kind,
+			gtype: gtype,
+			str:   x.str + y.str,
+		}
+	}
+	if !x.isNumeric() || !y.isNumeric() {
+		return nil
+	}
+	var isInt bool
+	if gtype != nil {
+		isInt = gtype.isInteger()
+	} else {
+		isInt = kind == untypedInt || kind == untypedRune
+	}
+	switch op {
+	case "+":
+		return newNumConst(kind, gtype, bigAdd(bigMul(x.num, y.den), bigMul(y.num, x.den)), bigMul(x.den, y.den))
+	case "-":
+		return newNumConst(kind, gtype, bigSub(bigMul(x.num, y.den), bigMul(y.num, x.den)), bigMul(x.den, y.den))
+	case "*":
+		return newNumConst(kind, gtype, bigMul(x.num, y.num), bigMul(x.den, y.den))
+	case "/":
+		if y.isZero() {
+			return nil
+		}
+		if isInt {
+			return newNumConst(kind, gtype, bigQuo(x.intPart(), y.intPart()), newBigInt(1))
+		}
+		return newNumConst(kind, gtype, bigMul(x.num, y.den), bigMul(x.den, y.num))
+	case "%":
+		if y.isZero() || !isInt {
+			return nil
+		}
+		_, r := bigQuoRem(x.intPart(), y.intPart())
+		return newNumConst(kind, gtype, r, newBigInt(1))
+	}
+	return nil
+}
+
+func compareConst(op string, x *constant, y *constant) *constant {
+	var cmp int
+	if x.isNumeric() && y.isNumeric() {
+		cmp = bigCmp(bigMul(x.num, y.den), bigMul(y.num, x.den))
+	} else if x.isString() && y.isString() {
+		cmp = compareStrings(x.str, y.str)
+	} else if x.isBool() && y.isBool() {
+		if x.bol != y.bol {
+			cmp = 1
+		}
+		if op != "==" && op != "!=" {
+			return nil
+		}
+	} else {
+		return nil
+	}
+	var bol bool
+	switch op {
+	case "==":
+		bol = cmp == 0
+	case "!=":
+		bol = cmp != 0
+	case "<":
+		bol = cmp < 0
+	case "<=":
+		bol = cmp <= 0
+	case ">":
+		bol = cmp > 0
+	case ">=":
+		bol = cmp >= 0
+	}
+	return &constant{
+		kind: untypedBool,
+		bol:  bol,
+	}
+}
+
+func compareStrings(a string, b string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	if len(a) == len(b) {
+		return 0
+	}
+	if len(a) < len(b) {
+		return -1
+	}
+	return 1
+}
+
+// parses a float literal like 1.5, .5, 1e-9 or 0x1.8p3 into an exact fraction
+func parseFloatConst(s string) *constant {
+	base := 10
+	expBase := 10
+	if len(s) > 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		base = 16
+		expBase = 2
+		s = s[2:]
+	}
+	num := newBigInt(0)
+	var fracDigits int
+	var afterDot bool
+	var inExp bool
+	var exp int
+	var expNeg bool
+	for _, c := range []byte(s) {
+		if c == '_' {
+			continue
+		}
+		if inExp {
+			if c == '-' {
+				expNeg = true
+			} else if c != '+' {
+				exp = exp*10 + int(c-'0')
+			}
+		} else if c == '.' {
+			afterDot = true
+		} else if (base == 10 && (c == 'e' || c == 'E')) || (base == 16 && (c == 'p' || c == 'P')) {
+			inExp = true
+		} else {
+			num = bigAdd(bigMul(num, newBigInt(base)), newBigInt(digitVal(c)))
+			if afterDot {
+				fracDigits++
+			}
+		}
+	}
+	den := bigPow(base, fracDigits)
+	if expNeg {
+		den = bigMul(den, bigPow(expBase, exp))
+	} else {
+		num = bigMul(num, bigPow(expBase, exp))
+	}
+	return newNumConst(untypedFloat, nil, num, den)
+}
+
+// the n most significant decimal digits of the absolute value rounded half to even,
+// and the exponent e such that the value is d.ddd * 10**e
+func (c *constant) decimalDigits(n int) (string, int) {
+	if c.isZero() {
+		return "0", 0
+	}
+	num := bigAbs(c.num)
+	den := c.den
+	numDigits := num.String()
+	denDigits := den.String()
+	e := len(numDigits) - len(denDigits)
+	// the value is either in [10**e, 10**(e+1)) or in [10**(e-1), 10**e)
+	if e >= 0 {
+		if bigCmp(num, bigMul(den, bigPow(10, e))) < 0 {
+			e--
+		}
+	} else {
+		if bigCmp(bigMul(num, bigPow(10, -e)), den) < 0 {
+			e--
+		}
+	}
+	k := n - 1 - e
+	if k >= 0 {
+		num = bigMul(num, bigPow(10, k))
+	} else {
+		den = bigMul(den, bigPow(10, -k))
+	}
+	q, r := bigQuoRem(num, den)
+	half := bigCmp(bigMul(r, newBigInt(2)), den)
+	if half > 0 || (half == 0 && q.digits[0]%2 == 1) {
+		q = bigAdd(q, newBigInt(1))
+	}
+	digits := q.String()
+	if len(digits) > n {
+		// rounded up to 10**n
+		digits = digits[:n]
+		e++
+	}
+	return digits, e
+}
+
+// the text of a float for the assembler
+func (c *constant) floatText() string {
+	if c.isZero() {
+		return "0.0"
+	}
+	digits, e := c.decimalDigits(40)
+	var sign string
+	if c.num.neg {
+		sign = "-"
+	}
+	return Sprintf("%s%s.%se%d", sign, digits[:1], digits[1:], e)
+}
+
+// the value of a float like the %.6g format
+func (c *constant) floatString() string {
+	digits, e := c.decimalDigits(6)
+	n := len(digits)
+	for n > 1 && digits[n-1] == '0' {
+		n--
+	}
+	digits = digits[:n]
+	var sign string
+	if c.num.neg {
+		sign = "-"
+	}
+	if e < -4 || e >= 6 {
+		var mantissa string = digits[:1]
+		if len(digits) > 1 {
+			mantissa = mantissa + "." + digits[1:]
+		}
+		expSign := "+"
+		if e < 0 {
+			expSign = "-"
+			e = -e
+		}
+		if e < 10 {
+			return Sprintf("%s%se%s0%d", sign, mantissa, expSign, e)
+		}
+		return Sprintf("%s%se%s%d", sign, mantissa, expSign, e)
+	}
+	if e < 0 {
+		var zeros string
+		for i := 1; i < -e; i++ {
+			zeros = zeros + "0"
+		}
+		return sign + "0." + zeros + digits
+	}
+	for len(digits) < e+1 {
+		digits = digits + "0"
+	}
+	if len(digits) == e+1 {
+		return sign + digits
+	}
+	return sign + digits[:e+1] + "." + digits[e+1:]
+}
+
+// the value in the Go syntax
+func (c *constant) String() string {
+	if c.isBool() {
+		if c.bol {
+			return "true"
+		}
+		return "false"
+	}
+	if c.isString() {
+		return quoteString([]byte(c.str))
+	}
+	if c.isInt() {
+		return c.num.String()
+	}
+	return c.floatString()
+}
+
+// the largest finite values of float32 and float64
+func maxFloat(gtype *Gtype) *bigInt {
+	if gtype.getKind() == G_FLOAT32 {
+		return bigMul(bigSub(bigPow(2, 24), newBigInt(1)), bigPow(2, 104))
+	}
+	return bigMul(bigSub(bigPow(2, 53), newBigInt(1)), bigPow(2, 971))
+}
+
+// representable returns an empty string if c can be a value of gtype,
+// or the reason why not, either "truncated" or "overflows".
+func (c *constant) representable(gtype *Gtype) string {
+	if !c.isNumeric() {
+		return ""
+	}
+	if gtype.isInteger() {
+		if !c.isIntegral() {
+			return "truncated"
+		}
+		bits := gtype.getSize() * 8
+		var min *bigInt
+		var max *bigInt
+		if gtype.isUnsigned() {
+			min = newBigInt(0)
+			max = bigSub(bigPow(2, bits), newBigInt(1))
+		} else {
+			min = bigNeg(bigPow(2, bits-1))
+			max = bigSub(bigPow(2, bits-1), newBigInt(1))
+		}
+		if bigCmp(c.num, min) < 0 || bigCmp(c.num, max) > 0 {
+			return "overflows"
+		}
+	} else if gtype.isFloat() {
+		if bigCmp(bigAbs(c.num), bigMul(maxFloat(gtype), c.den)) > 0 {
+			return "overflows"
+		}
+	}
+	return ""
+}
@@ -160,6 +160,10 @@ func (ast *ExprNumberLiteral) dump() {
 	debugf("int %d", ast.val)
 }
 
+func (ast *ExprFloatLiteral) dump() {
+	debugf("float %s", ast.val)
+}
+
 func (ast *ExprStringLiteral) dump() {
 	debugf("\"%s\"", ast.val)
 }
@@ -229,6 +233,27 @@ func (f *ExprFuncRef) dump() {
 	f.funcdef.dump()
 }
 
+func (f *ExprFuncLiteral) dump() {
+	debugf("func literal %s", f.funcdef.fname)
+}
+
+func (e *ExprMethodValue) dump() {
+	debugf("method value %s", e.funcdef.fname)
+	debugNest++
+	e.receiver.dump()
+	debugNest--
+}
+
+func (e *ExprFuncValueCall) dump() {
+	debugf("call func value")
+	debugNest++
+	e.fn.dump()
+	for _, arg := range e.args {
+		arg.dump()
+	}
+	debugNest--
+}
+
 func (e *ExprSlice) dump() {
 	debugf("ExprSlice:")
 	debugNest++
@@ -302,6 +327,20 @@ func (f *IrStmtRangeMap) dump() {
 	debugNest--
 }
 
+func (f *IrStmtRangeString) dump() {
+	debugf("for range string")
+	debugNest++
+	f.block.dump()
+	debugNest--
+}
+
+func (f *IrStmtRangeChan) dump() {
+	debugf("for range chan")
+	debugNest++
+	f.block.dump()
+	debugNest--
+}
+
 func (f *IrStmtClikeFor) dump() {
 	debugf("for clause")
 	if f.cls.init != nil {
@@ -354,6 +393,21 @@ func (e *ExprCap) dump() {
 	TBI(e.token(), "")
 }
 
+func (e *ExprChanRecv) dump() {
+	debugf("<-")
+	debugNest++
+	e.ch.dump()
+	debugNest--
+}
+
+func (s *StmtSend) dump() {
+	debugf("send")
+	debugNest++
+	s.ch.dump()
+	s.value.dump()
+	debugNest--
+}
+
 func (e *ExprSliceLiteral) dump() {
 	debugf("slice %s", e.gtype.String())
 	debugNest++
@@ -396,6 +450,21 @@ func (ast *StmtBreak) dump() {
 	debugf("break")
 }
 
+func (ast *StmtLabeled) dump() {
+	debugf("%s:", ast.name)
+	if ast.stmt != nil {
+		ast.stmt.dump()
+	}
+}
+
+func (ast *StmtFallthrough) dump() {
+	debugf("fallthrough")
+}
+
+func (ast *StmtGoto) dump() {
+	debugf("goto %s", ast.name)
+}
+
 func (ast *StmtExpr) dump() {
 	ast.expr.dump()
 }
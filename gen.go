@@ -149,24 +149,46 @@ func align(n int, m int) int {
 	}
 }
 
-func emitFuncEpilogue(labelDeferHandler string, stmtDefer *StmtDefer) {
+func emitFuncEpilogue(labelDeferHandler string, hasDefer bool) {
 	emitNewline()
 	emit("# func epilogue")
 	// every function has a defer handler
 	emit("%s: # defer handler", labelDeferHandler)
 
-	// if the function has a defer statement, jump to there
-	if stmtDefer != nil {
-		emit("jmp %s", stmtDefer.label)
+	// if the function has defer statements, run the deferred calls of this frame
+	if hasDefer {
+		for i := 0; i < len(retRegi); i++ {
+			emit("pushq %%%s", retRegi[i])
+		}
+		emit("movq %%rbp, %%rax")
+		emit("PUSH_8")
+		emit("POP_TO_ARG_0")
+		emit("FUNCALL %s", getFuncSymbol(IRuntimePath, "deferreturn"))
+		for i := len(retRegi) - 1; i >= 0; i-- {
+			emit("popq %%%s", retRegi[i])
+		}
 	}
 
 	emit("leave")
 	emit("ret")
 }
 
+// extend a value of gtype in %rax to 64 bits.
+// This drops the bits beyond its width, which makes arithmetic wrap around.
 func emit_intcast(gtype *Gtype) {
-	if gtype.getKind() == G_BYTE {
+	switch gtype.getKind() {
+	case G_BYTE:
 		emit("CAST_UINT8_TO_INT")
+	case G_INT_8:
+		emit("CAST_INT8_TO_INT")
+	case G_UINT_16:
+		emit("CAST_UINT16_TO_INT")
+	case G_INT_16:
+		emit("CAST_INT16_TO_INT")
+	case G_UINT_32:
+		emit("CAST_UINT32_TO_INT")
+	case G_INT_32:
+		emit("CAST_INT32_TO_INT")
 	}
 }
 
@@ -179,9 +201,17 @@ func makeLabel() string {
 }
 
 func (ast *StmtInc) emit() {
+	if ast.operand.getGtype().isFloat() {
+		emitFloatIncrDecl("+", ast.tok, ast.operand)
+		return
+	}
 	emitIncrDecl("ADD_NUMBER 1", ast.operand)
 }
 func (ast *StmtDec) emit() {
+	if ast.operand.getGtype().isFloat() {
+		emitFloatIncrDecl("-", ast.tok, ast.operand)
+		return
+	}
 	emitIncrDecl("SUB_NUMBER 1", ast.operand)
 }
 
@@ -199,6 +229,11 @@ func (binop *ExprBinop) emitComp() {
 	emit("# emitComp")
 	assert(binop.left != nil, binop.token(), "should not be nil")
 
+	if gtype := binop.floatGtype(); gtype != nil {
+		binop.emitFloatComp(gtype)
+		return
+	}
+
 	if binop.left.getGtype().isString() {
 		e := &IrExprStringComparison{
 			tok:   binop.token(),
@@ -212,15 +247,28 @@ func (binop *ExprBinop) emitComp() {
 
 	var instruction string
 	op := binop.op
+	unsigned := binop.operandGtype().isUnsigned()
 	switch op {
 	case "<":
 		instruction = "setl"
+		if unsigned {
+			instruction = "setb"
+		}
 	case ">":
 		instruction = "setg"
+		if unsigned {
+			instruction = "seta"
+		}
 	case "<=":
 		instruction = "setle"
+		if unsigned {
+			instruction = "setbe"
+		}
 	case ">=":
 		instruction = "setge"
+		if unsigned {
+			instruction = "setae"
+		}
 	case "!=":
 		instruction = "setne"
 	case "==":
@@ -230,19 +278,18 @@ func (binop *ExprBinop) emitComp() {
 	}
 
 	binop.left.emit()
-	if binop.left.getGtype().getKind() == G_BYTE {
-		emit_intcast(binop.left.getGtype())
-	}
 	emit("PUSH_8 # left") // left
 	binop.right.emit()
-	if binop.right.getGtype().getKind() == G_BYTE {
-		emit_intcast(binop.right.getGtype())
-	}
 	emit("PUSH_8 # right") // right
 	emit("CMP_FROM_STACK %s", instruction)
 }
 
 func (ast *ExprBinop) emit() {
+	if c := evalConst(ast); c != nil && !c.isString() {
+		// constant folding
+		emitConst(c, c.getGtype())
+		return
+	}
 	if ast.op == "+" && ast.left.getGtype().isString() {
 		var e Expr = &IrStringConcat{
 			left:  ast.left,
@@ -293,11 +340,16 @@ func (ast *ExprBinop) emit() {
 		emit("%s:", labelEnd)
 		return
 	}
+	if gtype := ast.floatGtype(); gtype != nil {
+		ast.emitFloat(gtype)
+		return
+	}
 	ast.left.emit()
 	emit("PUSH_8")
 	ast.right.emit()
 	emit("PUSH_8")
 
+	unsigned := ast.getGtype().isUnsigned()
 	op = string(ast.op)
 	switch op {
 	case "+":
@@ -307,19 +359,29 @@ func (ast *ExprBinop) emit() {
 	case "*":
 		emit("IMUL_FROM_STACK")
 	case "%":
-		emit("popq %%rcx")
-		emit("popq %%rax")
-		emit("movq $0, %%rdx # init %%rdx")
-		emit("divq %%rcx")
-		emit("movq %%rdx, %%rax")
+		if unsigned {
+			emit("UMOD_FROM_STACK")
+		} else {
+			emit("MOD_FROM_STACK")
+		}
 	case "/":
-		emit("popq %%rcx")
-		emit("popq %%rax")
-		emit("movq $0, %%rdx # init %%rdx")
-		emit("divq %%rcx")
+		if unsigned {
+			emit("UDIV_FROM_STACK")
+		} else {
+			emit("DIV_FROM_STACK")
+		}
+	case "<<":
+		emit("SHL_FROM_STACK")
+	case ">>":
+		if unsigned {
+			emit("SHR_FROM_STACK")
+		} else {
+			emit("SAR_FROM_STACK")
+		}
 	default:
 		errorft(ast.token(), "Unknown binop: %s", op)
 	}
+	emit_intcast(ast.getGtype())
 }
 
 func isUnderScore(e Expr) bool {
@@ -336,17 +398,17 @@ func emitCopyStructFromStack(size int) {
 	emit("popq %%rax") // from
 
 	var i int
-	for ; i < size; i += 8 {
+	for ; i+8 <= size; i += 8 {
 		emit("movq %d(%%rbx), %%rcx", i)
 		emit("movq %%rcx, %d(%%rax)", i)
 	}
-	for ; i < size; i += 4 {
-		emit("movl %d(%%rbx), %%rcx", i)
-		emit("movl %%rcx, %d(%%rax)", i)
+	for ; i+4 <= size; i += 4 {
+		emit("movl %d(%%rbx), %%ecx", i)
+		emit("movl %%ecx, %d(%%rax)", i)
 	}
 	for ; i < size; i++ {
-		emit("movb %d(%%rbx), %%rcx", i)
-		emit("movb %%rcx, %d(%%rax)", i)
+		emit("movb %d(%%rbx), %%cl", i)
+		emit("movb %%cl, %d(%%rax)", i)
 	}
 }
 
@@ -380,22 +442,47 @@ func emitConversionToInterface(dynamicValue Expr) {
 	}
 
 	emit("# emitConversionToInterface from %s", dynamicValue.getGtype().String())
-	dynamicValue.emit()
-	emitPush(dynamicValue.getGtype())
-	if dynamicValue.getGtype().is24WidthType() {
-		emitCallMalloc(24)
+	kind := dynamicValue.getGtype().getKind()
+	size := dynamicValue.getGtype().getSize()
+	if kind == G_ARRAY || (kind == G_STRUCT && size > 8) {
+		// box the address of a heap copy of the value
+		emitCallMallocTyped(size, dynamicValue.getGtype())
+		emit("PUSH_8 # addr of the copy")
+		if kind == G_STRUCT {
+			emitAddress(dynamicValue)
+		} else {
+			dynamicValue.emit()
+		}
+		emit("PUSH_8 # addr of the value")
+		emitCopyStructFromStack(size)
 		emit("PUSH_8")
-		emit("STORE_24_INDIRECT_FROM_STACK")
-	} else {
-		emitCallMalloc(8)
+		ptrType := &Gtype{
+			kind:     G_POINTER,
+			origType: dynamicValue.getGtype(),
+		}
+		emitCallMallocTyped(8, ptrType)
 		emit("PUSH_8")
 		emit("STORE_8_INDIRECT_FROM_STACK")
+	} else {
+		dynamicValue.emit()
+		emitPush(dynamicValue.getGtype())
+		if dynamicValue.getGtype().is24WidthType() {
+			emitCallMallocTyped(24, dynamicValue.getGtype())
+			emit("PUSH_8")
+			emit("STORE_24_INDIRECT_FROM_STACK")
+		} else {
+			emitCallMallocTyped(8, dynamicValue.getGtype())
+			emit("PUSH_8")
+			emit("STORE_8_INDIRECT_FROM_STACK")
+		}
 	}
 
 	emit("PUSH_8 # addr of dynamicValue") // address
 
 	if receiverType.kind == G_POINTER {
 		receiverType = receiverType.origType.relation.gtype
+	} else if receiverType.kind == G_NAMED && receiverType.receiverTypeId == 0 && receiverType.relation.gtype != nil {
+		receiverType = receiverType.relation.gtype
 	}
 	//assert(receiverType.receiverTypeId > 0,  dynamicValue.token(), "no receiverTypeId")
 	emit("LOAD_NUMBER %d # receiverTypeId", receiverType.receiverTypeId)
@@ -429,6 +516,9 @@ func (decl *DeclVar) emitLocal() {
 	gtype := decl.variable.gtype
 	variable := decl.variable
 	rhs := decl.initval
+	if variable.isBoxed {
+		variable.emitNewCell()
+	}
 	switch gtype.getKind() {
 	case G_ARRAY:
 		assignToArray(variable, rhs)
@@ -454,6 +544,7 @@ func (decl *DeclConst) emit() {
 func (ast *StmtSatementList) emit() {
 	for _, stmt := range ast.stmts {
 		setPos(ast.token())
+		emitPcLine(stmt.token())
 		emit("# Statement")
 		gasIndentLevel++
 		stmt.emit()
@@ -463,7 +554,16 @@ func (ast *StmtSatementList) emit() {
 
 func (e *ExprIndex) emit() {
 	emit("# emit *ExprIndex")
-	e.emitOffsetLoad(0)
+	gtype := e.getGtype()
+	if gtype.getKind() == G_ARRAY && e.collection.getGtype().getKind() != G_MAP {
+		// an array is represented by its address
+		e.emitAddress()
+	} else if gtype.is24WidthType() {
+		e.emitOffsetLoad(24, 0)
+	} else {
+		e.emitOffsetLoad(loadSize(gtype), 0)
+		emit_intcast(gtype)
+	}
 }
 
 func (e *ExprNilLiteral) emit() {
@@ -481,7 +581,7 @@ func (s *StmtShortVarDecl) emit() {
 }
 
 func (f *ExprFuncRef) emit() {
-	f.funcdef.emitLoadFuncRef()
+	f.funcdef.emitLoadStaticClosure()
 }
 
 func (f *DeclFunc) emitLoadFuncRef() {
@@ -539,7 +639,52 @@ func (e *ExprVaArg) emit() {
 
 func (e *IrExprConversion) emit() {
 	emit("# IrExprConversion.emit()")
+	if c := evalConst(e); c != nil && !c.isString() {
+		emitConst(c, e.toGtype)
+		return
+	}
+	if e.toGtype.isFloat() || e.arg.getGtype().isFloat() {
+		emitFloatConversion(e.arg, e.toGtype)
+		return
+	}
+	if e.isRunesConversion() {
+		if e.toGtype.isString() {
+			emitRuntimeConversion("slicerunetostring", e.arg)
+		} else {
+			emitRuntimeConversion("stringtoslicerune", e.arg)
+		}
+		return
+	}
+	if e.toGtype.isString() && !e.arg.getGtype().is24WidthType() {
+		// string(integer) is the UTF-8 encoding of the code point
+		e.arg.emit()
+		emit("PUSH_8")
+		emit("POP_TO_ARG_0")
+		emit("FUNCALL %s", getFuncSymbol(IRuntimePath, "intstring"))
+		return
+	}
 	e.arg.emit()
+	emit_intcast(e.toGtype)
+}
+
+// []rune(string) or string([]rune), which decodes or encodes UTF-8
+func (e *IrExprConversion) isRunesConversion() bool {
+	from := e.arg.getGtype()
+	to := e.toGtype
+	return (to.isRunesSlice() && from.isString()) || (to.isString() && from.isRunesSlice())
+}
+
+// a conversion done by a runtime function of one argument
+func emitRuntimeConversion(fname string, arg Expr) {
+	call := &IrCall{
+		tok:    arg.token(),
+		symbol: getFuncSymbol(IRuntimePath, fname),
+		args:   []Expr{arg},
+		callee: &DeclFunc{
+			params: []*ExprVariable{&ExprVariable{}},
+		},
+	}
+	call.emit()
 }
 
 func (e *ExprStructLiteral) emit() {
@@ -563,58 +708,24 @@ func (f *DeclFunc) emit() {
 		return
 	}
 
+	beginFuncInfo(f)
 	f.prologue.emit()
 	f.body.emit()
+	// deferred calls run at the closing brace
+	emitPcLine(f.body.rbrace)
 	emit("LOAD_EMPTY_8")
-	emitFuncEpilogue(f.labelDeferHandler, f.stmtDefer)
+	emitFuncEpilogue(f.labelDeferHandler, f.hasDefer)
+	endFuncInfo()
 }
 
+// the value of a constant expression as a machine integer
 func evalIntExpr(e Expr) int {
-	e = unwrapRel(e)
-
-	switch e.(type) {
-	case nil:
-		errorf("e is nil")
-	case *IrExprBoolVal:
-		ir := e.(*IrExprBoolVal)
-		if ir.bol {
-			return 1
-		} else {
-			return 0
-		}
-	case *ExprNumberLiteral:
-		return e.(*ExprNumberLiteral).val
-	case *ExprVariable:
-		errorft(e.token(), "variable cannot be inteppreted at compile time")
-	case *ExprBinop:
-		binop := e.(*ExprBinop)
-		switch binop.op {
-		case "+":
-			return evalIntExpr(binop.left) + evalIntExpr(binop.right)
-		case "-":
-			return evalIntExpr(binop.left) - evalIntExpr(binop.right)
-		case "*":
-			return evalIntExpr(binop.left) * evalIntExpr(binop.right)
-
+	c := evalConst(e)
+	if c == nil {
+		if _, ok := unwrapRel(e).(*ExprVariable); ok {
+			errorft(e.token(), "variable cannot be inteppreted at compile time")
 		}
-	case *ExprConstVariable:
-		cnst := e.(*ExprConstVariable)
-		if cnst.hasIotaValue() {
-			return cnst.iotaIndex
-		}
-		return evalIntExpr(cnst.val)
-	default:
-		errorft(e.token(), "unkown type %T", e)
-	}
-	return 0
-}
-
-func (cnst *ExprConstVariable) hasIotaValue() bool {
-	rel, ok := cnst.val.(*Relation)
-	if !ok {
-		return false
+		errorft(e.token(), "%s is not constant", exprString(e))
 	}
-
-	val := rel.expr.(*ExprConstVariable)
-	return val == eIota
+	return c.toInt()
 }
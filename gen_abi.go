@@ -32,6 +32,8 @@ var retRegi [14]string = [14]string{
 	"r15",
 }
 
+// Arguments and return values are passed in general purpose registers.
+// Floats are no exception: they are passed as their bit patterns (see gen_float.go).
 var RegsForArguments [12]string = [12]string{
 	"rdi",
 	"rsi",
@@ -103,12 +105,22 @@ func (f *DeclFunc) prepare() Emitter {
 		//debugf(S("set offset %d to lvar %s, type=%s"), lvar.offset, lvar.varname, lvar.gtype)
 	}
 
+	// captured variables hold the addresses of their cells
+	for _, c := range f.captures {
+		localarea -= ptrSize
+		offset -= ptrSize
+		c.inner.offset = offset
+	}
+
 	return &funcPrologueEmitter{
 		token:        f.token(),
 		symbol:       f.getSymbol(),
 		argRegisters: argRegisters,
+		params:       params,
 		localvars:    f.localvars,
+		captures:     f.captures,
 		localarea:    localarea,
+		yieldCheck:   f.pkgPath != IRuntimePath,
 	}
 }
 
@@ -116,8 +128,11 @@ type funcPrologueEmitter struct {
 	token        *Token
 	symbol       string
 	argRegisters []int
+	params       []*ExprVariable
 	localvars    []*ExprVariable
+	captures     []*Capture
 	localarea    int
+	yieldCheck   bool // the runtime must not be preempted
 }
 
 func (fe *funcPrologueEmitter) emit() {
@@ -139,10 +154,31 @@ func (fe *funcPrologueEmitter) emit() {
 			lvar := fe.localvars[i]
 			emit("# offset %d variable \"%s\" %s", lvar.offset, lvar.varname, lvar.gtype.String())
 		}
+	}
+	if fe.localarea != 0 {
 		var localarea int = -fe.localarea
 		emit("subq $%d, %%rsp # total stack size", localarea)
 	}
 
+	// %rax holds the closure object when called as a func value
+	for i, c := range fe.captures {
+		emit("movq %d(%%rax), %%rcx # cell of \"%s\"", ptrSize*(i+1), c.inner.varname)
+		emit("movq %%rcx, %d(%%rbp)", c.inner.offset)
+	}
+	for _, param := range fe.params {
+		if param.isBoxed {
+			param.emitMoveToCell()
+		}
+	}
+	for _, lvar := range fe.localvars {
+		if lvar.isBoxed {
+			lvar.emitNewCell()
+		}
+	}
+	if fe.yieldCheck {
+		emit("YIELD_CHECK")
+	}
+
 	emitNewline()
 }
 
@@ -162,7 +198,28 @@ func (call *IrCall) emit() {
 		emitCallInner(numRegs, call.args, params)
 
 		emit("POP_8 # funcref")
-		emit("call *%%rax")
+		if call.isGoroutine {
+			emit("call %s", getFuncSymbol(IRuntimePath, "gostmtcode"))
+		} else if call.isDeferred {
+			emit("call %s", getFuncSymbol(IRuntimePath, "deferstmtcode"))
+		} else {
+			emit("call *%%rax")
+		}
+	} else if call.funcval != nil {
+		emit("# emit call of a func value")
+		params = call.icallee.params
+		call.funcval.emit()
+		emit("PUSH_8 # closure")
+		emitCallInner(numRegs, call.args, params)
+
+		emit("POP_8 # closure")
+		if call.isGoroutine {
+			emit("call %s", getFuncSymbol(IRuntimePath, "gostmt"))
+		} else if call.isDeferred {
+			emit("call %s", getFuncSymbol(IRuntimePath, "deferstmt"))
+		} else {
+			emit("call *(%%rax)")
+		}
 	} else {
 		emit("# emit static call:  %s", call.symbol)
 		params = call.callee.params
@@ -179,7 +236,15 @@ func (call *IrCall) emit() {
 		}
 		emitCallInner(numRegs, call.args, params)
 
-		emit("FUNCALL %s", call.symbol)
+		if call.isGoroutine {
+			emit("leaq %s(%%rip), %%rax", call.symbol)
+			emit("call %s", getFuncSymbol(IRuntimePath, "gostmtcode"))
+		} else if call.isDeferred {
+			emit("leaq %s(%%rip), %%rax", call.symbol)
+			emit("call %s", getFuncSymbol(IRuntimePath, "deferstmtcode"))
+		} else {
+			emit("FUNCALL %s", call.symbol)
+		}
 	}
 	emitNewline()
 }
@@ -203,6 +268,7 @@ func emitCallInner(numRegs int, args []Expr, params []*ExprVariable) {
 			continue
 		}
 		var doConvertToInterface bool
+		var toGtype *Gtype
 
 		var fromGtype string
 		if arg.getGtype() != nil {
@@ -220,7 +286,6 @@ func emitCallInner(numRegs int, args []Expr, params []*ExprVariable) {
 				emit("# fromGtype:%s", fromGtype.String())
 			}
 
-			var toGtype *Gtype
 			if param.getGtype() != nil {
 				toGtype = param.getGtype()
 				emit("# toGtype:%s", toGtype.String())
@@ -238,7 +303,7 @@ func emitCallInner(numRegs int, args []Expr, params []*ExprVariable) {
 			emit("# doConvertToInterface !!!")
 			emitConversionToInterface(arg)
 		} else {
-			arg.emit()
+			emitAs(arg, toGtype)
 		}
 
 		var width int
@@ -312,7 +377,7 @@ func (stmt *StmtReturn) emit() {
 	}
 
 	var retRegiIndex int
-	if len(stmt.exprs) == 1 {
+	if len(stmt.exprs) == 1 && stmt.bufs == nil {
 		expr := stmt.exprs[0]
 		rettype := stmt.rettypes[0]
 		if rettype.getKind() == G_INTERFACE && expr.getGtype().getKind() != G_INTERFACE {
@@ -322,7 +387,7 @@ func (stmt *StmtReturn) emit() {
 				emitConversionToInterface(expr)
 			}
 		} else {
-			expr.emit()
+			emitAs(expr, rettype)
 			if expr.getGtype() == nil && stmt.rettypes[0].getKind() == G_SLICE {
 				emit("LOAD_EMPTY_SLICE")
 			}
@@ -332,7 +397,19 @@ func (stmt *StmtReturn) emit() {
 	}
 	for i, rettype := range stmt.rettypes {
 		expr := stmt.exprs[i]
-		expr.emit()
+		if stmt.bufs != nil && stmt.bufs[i] != nil {
+			// a struct or an array is returned word by word
+			buf := stmt.bufs[i]
+			emitAssignOne(buf, expr)
+			buf.emitAddress(0)
+			num64bit := retRegiCount(rettype)
+			for j := 0; j < num64bit; j++ {
+				emit("pushq %d(%%rax)", j*8)
+			}
+			retRegiIndex += num64bit
+			continue
+		}
+		emitAs(expr, rettype)
 		//		rettype := stmt.rettypes[i]
 		if expr.getGtype() == nil && rettype.getKind() == G_SLICE {
 			emit("LOAD_EMPTY_SLICE")
@@ -348,6 +425,9 @@ func (stmt *StmtReturn) emit() {
 			retRegiIndex++
 		}
 	}
+	if retRegiIndex > len(retRegi) {
+		TBI(stmt.token(), "too many words of return values")
+	}
 	for i := 0; i < retRegiIndex; i++ {
 		reg := retRegi[retRegiIndex-1-i]
 		emit("popq %%%s", reg)
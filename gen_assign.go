@@ -13,7 +13,7 @@ func emitAssignMultiToMulti(ast *StmtAssignment) {
 		right := ast.rights[i]
 		left := ast.lefts[i]
 		switch right.(type) {
-		case *ExprFuncallOrConversion, *ExprMethodcall:
+		case *ExprFuncallOrConversion, *ExprMethodcall, *ExprFuncValueCall:
 			rettypes := getRettypes(right)
 			assert(len(rettypes) == 1, ast.token(), "return values should be one")
 		}
@@ -50,12 +50,16 @@ func emitAssignOneRightToMultiLeft(ast *StmtAssignment) {
 
 	var leftsMayBeTwo bool // a(,b) := expr // map index or type assertion
 	switch right.(type) {
-	case *ExprFuncallOrConversion, *ExprMethodcall:
+	case *ExprFuncallOrConversion, *ExprMethodcall, *ExprFuncValueCall:
 		rettypes := getRettypes(right)
 		numRight += len(rettypes)
 	case *ExprTypeAssertion:
 		leftsMayBeTwo = true
 		numRight++
+	case *ExprChanRecv:
+		emit("# v, ok = <-ch")
+		leftsMayBeTwo = true
+		numRight++
 	case *ExprIndex:
 		indexExpr := right.(*ExprIndex)
 		if indexExpr.collection.getGtype().getKind() == G_MAP {
@@ -80,7 +84,7 @@ func emitAssignOneRightToMultiLeft(ast *StmtAssignment) {
 
 	left := ast.lefts[0]
 	switch right.(type) {
-	case *ExprFuncallOrConversion, *ExprMethodcall:
+	case *ExprFuncallOrConversion, *ExprMethodcall, *ExprFuncValueCall:
 		rettypes := getRettypes(right)
 		if len(rettypes) > 1 {
 			// a,b,c = f()
@@ -88,26 +92,23 @@ func emitAssignOneRightToMultiLeft(ast *StmtAssignment) {
 			right.emit()
 			var retRegiLen int
 			for _, rettype := range rettypes {
-				retSize := rettype.getSize()
-				if retSize < 8 {
-					retSize = 8
-				}
-				retRegiLen += retSize / 8
+				retRegiLen += retRegiCount(rettype)
 			}
 			emit("# retRegiLen=%d\n", retRegiLen)
 			var i int
 			for i = retRegiLen - 1; i >= 0; i-- {
 				emit("pushq %%%s # %d", retRegi[i], i)
 			}
-			for _, left := range ast.lefts {
-				if isUnderScore(left) {
+			for i, left := range ast.lefts {
+				if isUnderScore(left) || left == nil {
+					emit("addq $%d, %%rsp # discard", retRegiCount(rettypes[i])*8)
 					continue
 				}
-				if left == nil {
-					// what is this case ???
+				assert(left.getGtype() != nil, left.token(), "should not be nil")
+				if isAggregate(rettypes[i]) {
+					emitSaveResultFromStack(left, rettypes[i])
 					continue
 				}
-				assert(left.getGtype() != nil, left.token(), "should not be nil")
 				emitPop(left.getGtype())
 				emitOffsetSave(left, 0)
 			}
@@ -119,22 +120,71 @@ func emitAssignOneRightToMultiLeft(ast *StmtAssignment) {
 	if leftsMayBeTwo && len(ast.lefts) == 2 {
 		okVariable := ast.lefts[1]
 		//emit("# lefts[0] type = %s", ast.lefts[0].getGtype().String())
-		okRegister := mapOkRegister(ast.lefts[0].getGtype().is24WidthType())
+		leftType := ast.lefts[0].getGtype()
+		okRegister := mapOkRegister(leftType.is24WidthType() || isWideElement(leftType))
 		emit("movq %%%s, %%rax # emit okValue", okRegister)
 		emitSavePrimitive(okVariable)
 	}
 }
 
+// evaluate e and drop its value
+func emitDiscard(e Expr) {
+	if e == nil {
+		return
+	}
+	switch unwrapRel(e).(type) {
+	case *ExprStructLiteral, *ExprArrayLiteral:
+		// composite literals are evaluated only into a variable
+		return
+	}
+	e.emit()
+}
+
+// the number of registers which hold a return value of gtype
+func retRegiCount(gtype *Gtype) int {
+	size := gtype.getSize()
+	if size < 8 {
+		size = 8
+	}
+	return (size + 7) / 8
+}
+
+// A struct or an array is returned word by word in the return registers,
+// and is copied from them into its destination.
+func isAggregate(gtype *Gtype) bool {
+	kind := gtype.getKind()
+	return kind == G_STRUCT || kind == G_ARRAY
+}
+
+// push the words of a struct or array result
+func emitPushResult(gtype *Gtype) {
+	for i := retRegiCount(gtype) - 1; i >= 0; i-- {
+		emit("pushq %%%s # %d", retRegi[i], i)
+	}
+}
+
+// copy the words of a struct or array result from the stack to lhs, and drop them
+func emitSaveResultFromStack(lhs Expr, gtype *Gtype) {
+	emitAddress(lhs)
+	emit("PUSH_8 # to")
+	emit("leaq 8(%%rsp), %%rax")
+	emit("PUSH_8 # from")
+	emitCopyStructFromStack(gtype.getSize())
+	emit("addq $%d, %%rsp # drop the result", retRegiCount(gtype)*8)
+}
+
 func emitAssignOne(lhs Expr, rhs Expr) {
-	if lhs == nil {
-		// what is this case ???
+	if lhs == nil || isUnderScore(lhs) {
+		// _ = rhs
+		// rhs is evaluated for its side effects and run-time checks
+		emitDiscard(rhs)
 		return
 	}
 	gtype := lhs.getGtype()
 	switch {
 	case gtype == nil:
 		// suppose lhs is "_"
-		rhs.emit()
+		emitDiscard(rhs)
 	case gtype.getKind() == G_ARRAY:
 		assignToArray(lhs, rhs)
 	case gtype.getKind() == G_SLICE || gtype.getKind() == G_STRING:
@@ -172,8 +222,8 @@ func emitAssignPrimitive(lhs Expr, rhs Expr) {
 
 	assert(lhs.getGtype().getSize() <= 8, lhs.token(), "invalid type for lhs")
 	assert(rhs != nil || rhs.getGtype().getSize() <= 8, rhs.token(), "invalid type for rhs")
-	rhs.emit()             //   expr => %rax
-	emitSavePrimitive(lhs) //   %rax => memory
+	emitAs(rhs, lhs.getGtype()) //   expr => %rax
+	emitSavePrimitive(lhs)      //   %rax => memory
 }
 
 func assignToStruct(lhs Expr, rhs Expr) {
@@ -181,6 +231,29 @@ func assignToStruct(lhs Expr, rhs Expr) {
 	lhs = unwrapRel(lhs)
 	assert(rhs == nil || (rhs.getGtype().getKind() == G_STRUCT),
 		lhs.token(), "rhs should be struct type")
+	strcttyp := lhs.getGtype().Underlying()
+	if rhs != nil {
+		strcttyp = rhs.getGtype().Underlying()
+	}
+	rhs = unwrapRel(rhs)
+	switch rhs.(type) {
+	case *ExprVariable, *ExprIndex, *ExprStructField, *ExprUop, *ExprChanRecv:
+		// copy struct
+		emitAddress(lhs)
+		emit("PUSH_8")
+		emitAddress(rhs)
+		emit("PUSH_8")
+		emitCopyStructFromStack(lhs.getGtype().getSize())
+		emit("# assignToStruct end")
+		return
+	case *ExprFuncallOrConversion, *ExprMethodcall, *ExprFuncValueCall:
+		rhs.emit()
+		emitPushResult(rhs.getGtype())
+		emitSaveResultFromStack(lhs, rhs.getGtype())
+		emit("# assignToStruct end")
+		return
+	}
+
 	// initializes with zero values
 	emit("# initialize struct with zero values: start")
 	for _, fieldtype := range lhs.getGtype().relation.gtype.fields {
@@ -228,27 +301,7 @@ func assignToStruct(lhs Expr, rhs Expr) {
 	}
 	variable := lhs
 
-	strcttyp := rhs.getGtype().Underlying()
-	rhs = unwrapRel(rhs)
 	switch rhs.(type) {
-	case *ExprVariable:
-		emitAddress(lhs)
-		emit("PUSH_8")
-		emitAddress(rhs)
-		emit("PUSH_8")
-		emitCopyStructFromStack(lhs.getGtype().getSize())
-	case *ExprUop:
-		re := rhs.(*ExprUop)
-		if re.op == "*" {
-			// copy struct
-			emitAddress(lhs)
-			emit("PUSH_8")
-			re.operand.emit()
-			emit("PUSH_8")
-			emitCopyStructFromStack(lhs.getGtype().getSize())
-		} else {
-			TBI(rhs.token(), "assign to struct")
-		}
 	case *ExprStructLiteral:
 		structliteral, ok := rhs.(*ExprStructLiteral)
 		assert(ok || rhs == nil, rhs.token(), "invalid rhs")
@@ -274,7 +327,7 @@ func assignToStruct(lhs Expr, rhs Expr) {
 					assignToArray(left, field.value)
 				default:
 					for i, val := range initvalues.values {
-						val.emit()
+						emitAs(val, elementType)
 						emitOffsetSavePrimitive(variable, elmSize, arrayType.offset+i*elmSize)
 					}
 				}
@@ -303,7 +356,7 @@ func assignToStruct(lhs Expr, rhs Expr) {
 				if field.value == nil {
 					field.value = &ExprNumberLiteral{}
 				}
-				field.value.emit()
+				emitAs(field.value, fieldtype)
 
 				regSize := fieldtype.getSize()
 				assert(0 < regSize && regSize <= 8, variable.token(), "%s", fieldtype.String())
@@ -355,11 +408,14 @@ func assignToSlice(lhs Expr, rhs Expr) {
 		// see also https://blog.golang.org/strings
 		conversion := rhs.(*IrExprConversion)
 		fromExpr := unwrapRel(conversion.arg)
-		if fromExpr.getGtype().getKind() == G_SLICE || fromExpr.getGtype().getKind() == G_STRING {
+		if conversion.isRunesConversion() {
+			conversion.emit()
+		} else if fromExpr.getGtype().getKind() == G_SLICE || fromExpr.getGtype().getKind() == G_STRING {
 			// emit as it is
 			fromExpr.emit()
 		} else {
-			assertNotReached(lhs.token())
+			// string(integer)
+			conversion.emit()
 		}
 	default:
 		//emit("# emit rhs of type %T %s", rhs, rhs.getGtype().String())
@@ -378,21 +434,37 @@ func assignToArray(lhs Expr, rhs Expr) {
 	elementType := arrayType.elementType
 	elmSize := elementType.getSize()
 	assert(rhs == nil || rhs.getGtype().getKind() == G_ARRAY, nil, "rhs should be array")
+	switch rhs.(type) {
+	case *ExprVariable, *ExprIndex, *ExprStructField, *ExprUop, *ExprChanRecv:
+		// copy the whole array
+		emitAddress(lhs)
+		emit("PUSH_8")
+		emitAddress(rhs)
+		emit("PUSH_8")
+		emitCopyStructFromStack(arrayType.getSize())
+		return
+	case *ExprFuncallOrConversion, *ExprMethodcall, *ExprFuncValueCall:
+		rhs.emit()
+		emitPushResult(arrayType)
+		emitSaveResultFromStack(lhs, arrayType)
+		return
+	}
 	switch elementType.getKind() {
-	case G_STRUCT:
-		//TBI
+	case G_STRUCT, G_ARRAY:
 		for i := 0; i < arrayType.length; i++ {
 			left := &ExprIndex{
 				collection: lhs,
 				index:      &ExprNumberLiteral{val: i},
 			}
-			if rhs == nil {
-				assignToStruct(left, nil)
-				continue
+			var value Expr
+			if rhs != nil {
+				arrayLiteral, ok := rhs.(*ExprArrayLiteral)
+				assert(ok, nil, "ok")
+				if i < len(arrayLiteral.values) {
+					value = arrayLiteral.values[i]
+				}
 			}
-			arrayLiteral, ok := rhs.(*ExprArrayLiteral)
-			assert(ok, nil, "ok")
-			assignToStruct(left, arrayLiteral.values[i])
+			emitAssignOne(left, value)
 		}
 		return
 	default: // prrimitive type or interface
@@ -420,7 +492,6 @@ func assignToArray(lhs Expr, rhs Expr) {
 						// conversion of dynamic type => interface type
 						dynamicValue := arrayLiteral.values[i]
 						emitConversionToInterface(dynamicValue)
-						emit("LOAD_EMPTY_INTERFACE")
 						emitSave24(lhs, offsetByIndex)
 						continue
 					} else {
@@ -437,12 +508,6 @@ func assignToArray(lhs Expr, rhs Expr) {
 					val := arrayLiteral.values[i]
 					val.emit()
 				}
-			case *ExprVariable:
-				arrayVariable := rhs.(*ExprVariable)
-				arrayVariable.emitOffsetLoad(elmSize, offsetByIndex)
-			case *ExprStructField:
-				strctField := rhs.(*ExprStructField)
-				strctField.emitOffsetLoad(elmSize, offsetByIndex)
 			default:
 				TBI(rhs.token(), "no supporetd %T", rhs)
 			}
@@ -458,6 +523,8 @@ func getRettypes(call Expr) []*Gtype {
 		return call.(*ExprFuncallOrConversion).getRettypes()
 	case *ExprMethodcall:
 		return call.(*ExprMethodcall).getRettypes()
+	case *ExprFuncValueCall:
+		return call.(*ExprFuncValueCall).getRettypes()
 	}
 	assertNotReached(call.token())
 	return nil
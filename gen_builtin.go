@@ -26,15 +26,16 @@ func (e *ExprLen) emit() {
 			var length int = len(_arg.values)
 			emit("LOAD_NUMBER %d", length)
 		case *ExprSlice:
-			sliceExpr := arg.(*ExprSlice)
-			uop := &ExprBinop{
-				op:    "-",
-				left:  sliceExpr.high,
-				right: sliceExpr.low,
-			}
-			uop.emit()
+			// the bounds are checked
+			arg.emit()
+			emit("movq %%rbx, %%rax # len")
 		case *IrExprConversion:
 			conv := arg.(*IrExprConversion)
+			if conv.isRunesConversion() {
+				conv.emit()
+				emit("movq %%rbx, %%rax # len")
+				return
+			}
 			e.arg = conv.arg
 			e.emit()
 		default:
@@ -53,12 +54,15 @@ func (e *ExprLen) emit() {
 		emit("cmpq $0, %%rax # map && map (check if map is nil)")
 		emit("je %s # jump if map is nil", labelNil)
 		// not nil case
-		emit("movq 8(%%rax), %%rax # load map len")
+		emit("movq %d(%%rax), %%rax # load map len", hmapCountOffset)
 		emit("jmp %s", labelEnd)
 		// nil case
 		emit("%s:", labelNil)
 		emit("LOAD_NUMBER 0")
 		emit("%s:", labelEnd)
+	case G_CHAN:
+		emit("# emit len(chan)")
+		emitChanHeader(arg, hchanQcountOffset)
 	default:
 		TBI(arg.token(), "unable to handle %s", gtype)
 	}
@@ -95,23 +99,16 @@ func (e *ExprCap) emit() {
 			var length int = len(_arg.values)
 			emit("LOAD_NUMBER %d", length)
 		case *ExprSlice:
-			sliceExpr := arg.(*ExprSlice)
-			if sliceExpr.collection.getGtype().getKind() == G_ARRAY {
-				cp := &ExprBinop{
-					tok: e.tok,
-					op:  "-",
-					left: &ExprLen{
-						tok: e.tok,
-						arg: sliceExpr.collection,
-					},
-					right: sliceExpr.low,
-				}
-				cp.emit()
-			} else {
-				TBI(arg.token(), "unable to handle %T", arg)
-			}
+			// the bounds are checked
+			arg.emit()
+			emit("movq %%rcx, %%rax # cap")
 		case *IrExprConversion:
 			conv := arg.(*IrExprConversion)
+			if conv.isRunesConversion() {
+				conv.emit()
+				emit("movq %%rcx, %%rax # cap")
+				return
+			}
 			e.arg = conv.arg
 			e.emit()
 		default:
@@ -119,6 +116,9 @@ func (e *ExprCap) emit() {
 		}
 	case G_MAP:
 		errorft(arg.token(), "invalid argument for cap")
+	case G_CHAN:
+		emit("# emit cap(chan)")
+		emitChanHeader(arg, hchanDataqsizOffset)
 	default:
 		TBI(e.token(), "unable to handle %s", gtype.String())
 	}
@@ -7,12 +7,26 @@ type Emitter interface {
 func (funcall *ExprFuncallOrConversion) getRettypes() []*Gtype {
 	if funcall.rel.gtype != nil {
 		// Conversion
-		return []*Gtype{funcall.rel.gtype}
+		return []*Gtype{funcall.getGtype()}
+	}
+	if _, ok := funcall.rel.expr.(*ExprFuncRef); !ok {
+		// call of a func value
+		return getSignature(funcall.rel.expr).rettypes
+	}
+	if funcall.getFuncDef() == builtinMake && funcall.typarg != nil {
+		return []*Gtype{funcall.typarg}
+	}
+	if funcall.getFuncDef() == builtinAppend && len(funcall.args) > 0 {
+		return []*Gtype{funcall.args[0].getGtype()}
 	}
 
 	return funcall.getFuncDef().rettypes
 }
 
+func (call *ExprFuncValueCall) getRettypes() []*Gtype {
+	return getSignature(call.fn).rettypes
+}
+
 func (ast *ExprMethodcall) getUniqueName() string {
 	gtype := ast.receiver.getGtype()
 	return getMethodUniqueName(gtype, ast.fname)
@@ -23,6 +37,10 @@ func (methodCall *ExprMethodcall) getOrigType() *Gtype {
 	assertNotNil(methodCall.receiver != nil, methodCall.token())
 	assertNotNil(gtype != nil, methodCall.tok)
 	assert(gtype.kind == G_NAMED || gtype.kind == G_POINTER || gtype.kind == G_INTERFACE, methodCall.tok, "method must be an interface or belong to a named type")
+	if gtype.kind == G_INTERFACE {
+		// an interface type literal
+		return gtype
+	}
 	var typeToBeloing *Gtype
 	if gtype.kind == G_POINTER {
 		typeToBeloing = gtype.origType
@@ -36,7 +54,27 @@ func (methodCall *ExprMethodcall) getOrigType() *Gtype {
 	return origType
 }
 
+// returns the field if it is a call of a func value in a struct field like s.f()
+func (methodCall *ExprMethodcall) getFuncField() *Gtype {
+	gtype := methodCall.receiver.getGtype()
+	if gtype.getKind() == G_POINTER {
+		gtype = gtype.Underlying().origType
+	}
+	if gtype.getKind() != G_STRUCT {
+		return nil
+	}
+	for _, field := range gtype.Underlying().fields {
+		if string(field.fieldname) == string(methodCall.fname) && field.getKind() == G_FUNC {
+			return field
+		}
+	}
+	return nil
+}
+
 func (methodCall *ExprMethodcall) getRettypes() []*Gtype {
+	if field := methodCall.getFuncField(); field != nil {
+		return field.Underlying().sig.rettypes
+	}
 	origType := methodCall.getOrigType()
 	if origType == nil {
 		errorft(methodCall.token(), "origType should not be nil")
@@ -46,19 +84,30 @@ func (methodCall *ExprMethodcall) getRettypes() []*Gtype {
 		imethod, _ := imethodGet(origType.imethods, methodCall.fname)
 		return imethod.rettypes
 	} else {
-		funcref, ok := methodGet(origType.methods, methodCall.fname)
-		if !ok {
-			errorft(methodCall.token(), "method %s is not found in type %s", methodCall.fname, methodCall.receiver.getGtype().String())
+		return methodCall.getMethod().funcdef.rettypes
+	}
+}
+
+// returns the method called on a non-interface type
+func (methodCall *ExprMethodcall) getMethod() *ExprFuncRef {
+	origType := methodCall.getOrigType()
+	funcref, ok := methodGet(origType.methods, methodCall.fname)
+	if !ok {
+		if _, ambiguous := origType.lookupPromoted(methodCall.fname); ambiguous {
+			errorft(methodCall.token(), "ambiguous selector %s", methodCall.fname)
 		}
-		return funcref.funcdef.rettypes
+		errorft(methodCall.token(), "method %s is not found in type %s", methodCall.fname, methodCall.receiver.getGtype().String())
 	}
+	return funcref
 }
 
 type IrInterfaceMethodCall struct {
-	receiver   Expr
-	methodName identifier
-	args       []Expr
-	callee     *signature
+	receiver    Expr
+	methodName  identifier
+	args        []Expr
+	callee      *signature
+	isGoroutine bool
+	isDeferred  bool
 }
 
 func (methodCall *ExprMethodcall) interfaceMethodCall() Emitter {
@@ -77,11 +126,7 @@ func (methodCall *ExprMethodcall) interfaceMethodCall() Emitter {
 }
 
 func (methodCall *ExprMethodcall) dynamicTypeMethodCall() Emitter {
-	origType := methodCall.getOrigType()
-	funcref, ok := methodGet(origType.methods, methodCall.fname)
-	if !ok {
-		errorft(methodCall.token(), "method %s is not found in type %s", methodCall.fname, methodCall.receiver.getGtype().String())
-	}
+	funcref := methodCall.getMethod()
 
 	name := methodCall.getUniqueName()
 	var staticCall Expr = &IrCall{
@@ -118,20 +163,60 @@ func (funcall *ExprFuncallOrConversion) getFuncDef() *DeclFunc {
 	return funcref.funcdef
 }
 
-func (stmt *StmtGo) emit() {
-	call, ok := stmt.expr.(*ExprFuncallOrConversion)
-	if !ok {
-		errorft(stmt.expr.token(), "Unexpected expr for go routine")
+// the call of a go or defer statement
+func stmtCallOf(expr Expr) (*IrCall, *IrInterfaceMethodCall) {
+	var call *IrCall
+	switch expr.(type) {
+	case *ExprFuncallOrConversion:
+		call, _ = funcall2emitter(expr.(*ExprFuncallOrConversion)).(*IrCall)
+	case *ExprFuncValueCall:
+		call = expr.(*ExprFuncValueCall).irCall()
+	case *ExprMethodcall:
+		methodCall := expr.(*ExprMethodcall)
+		if methodCall.getOrigType().getKind() == G_INTERFACE {
+			icall := methodCall.interfaceMethodCall().(*IrInterfaceMethodCall)
+			return nil, icall
+		}
+		call = methodCall.dynamicTypeMethodCall().(*IrCall)
+	case *IrCall:
+		call = expr.(*IrCall)
 	}
-	declFunc := call.getFuncDef()
-	declFunc.emitLoadFuncRef() // load funcref
-	emit("movq %%rax, %%rsi # set task")
+	return call, nil
+}
 
-	emit("leaq iruntime.mstart+0(%%rip), %%rax # load mstart")
-	emit("movq %%rax, %%rdi")
-	emit("call iruntime.startm")
+// The callee and its arguments are evaluated in the current goroutine,
+// and then they are passed to a new goroutine.
+func (stmt *StmtGo) emit() {
+	call, icall := stmtCallOf(stmt.expr)
+	if icall != nil {
+		icall.isGoroutine = true
+		icall.emit()
+		return
+	}
+	if call == nil {
+		errorft(stmt.token(), "expression in go must be function call")
+	}
+	call.isGoroutine = true
+	call.emit()
 }
 
+// The callee and its arguments are evaluated at the defer statement,
+// and then they are registered to the current goroutine.
+// The deferred calls are run in LIFO order when the function returns.
+func (stmt *StmtDefer) emit() {
+	emit("# defer")
+	call, icall := stmtCallOf(stmt.expr)
+	if icall != nil {
+		icall.isDeferred = true
+		icall.emit()
+		return
+	}
+	if call == nil {
+		errorft(stmt.token(), "expression in defer must be function call")
+	}
+	call.isDeferred = true
+	call.emit()
+}
 
 func funcall2emitter(funcall *ExprFuncallOrConversion) Emitter {
 
@@ -183,9 +268,12 @@ type IrCall struct {
 	// A symbol is one or more characters chosen from the set of all letters (both upper and lower case), digits and the three characters ‘_.$’.
 	tok          *Token
 	isInterfaceMethodCall bool
+	isGoroutine  bool // for a go statement
+	isDeferred   bool // for a defer statement
 	symbol       string
 	icallee      *signature
 	callee       *DeclFunc
+	funcval      Expr // for a call of a func value
 	receiver     Expr
 	args         []Expr
 	origExpr     Expr
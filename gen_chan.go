@@ -0,0 +1,157 @@
+// gen_chan handles channel operations
+package main
+
+// A channel is a pointer to iruntime.hchan.
+// Values are passed to the runtime through a buffer on the stack.
+
+// offsets of fields in iruntime.hchan
+const hchanQcountOffset = 0
+const hchanDataqsizOffset = 8
+
+func (e *ExprChanRecv) getGtype() *Gtype {
+	gtype := e.ch.getGtype()
+	if gtype.getKind() != G_CHAN {
+		errorft(e.token(), "invalid operation: receive from non-chan type %s", gtype.String())
+	}
+	return gtype.Underlying().elementType
+}
+
+// type of fist,second := <-ch
+func (e *ExprChanRecv) getSecondGtype() *Gtype {
+	return gBool
+}
+
+// A wide element is a struct or an array.
+// It is sent from and received into an invisible variable,
+// and a received value is loaded as the address of the variable.
+func isWideElement(elementType *Gtype) bool {
+	kind := elementType.getKind()
+	return kind == G_STRUCT || kind == G_ARRAY
+}
+
+// The received value is loaded like a map value:
+// the value into %rax (or %rax,%rbx,%rcx) and the ok flag into mapOkRegister().
+func (e *ExprChanRecv) emit() {
+	if e.selectBuf != nil {
+		e.emitSelectedValue()
+		return
+	}
+	emit("# receive from channel")
+	elementType := e.getGtype()
+	if e.buf != nil {
+		e.emitWide()
+		return
+	}
+	is24Width := elementType.is24WidthType()
+	if is24Width {
+		emit("pushq $0 # buffer")
+		emit("pushq $0")
+		emit("pushq $0")
+	} else {
+		emit("pushq $0 # buffer")
+	}
+	e.ch.emit()
+	emit("movq %%rax, %%rdi # channel")
+	emit("movq %%rsp, %%rsi # buffer")
+	emit("FUNCALL %s", getFuncSymbol(IRuntimePath, "chanrecv"))
+	if is24Width {
+		emit("movq %%rax, %%%s # ok", mapOkRegister(is24Width))
+		emit("popq %%rax")
+		emit("popq %%rbx")
+		emit("popq %%rcx")
+	} else {
+		emit("movq %%rax, %%%s # ok", mapOkRegister(is24Width))
+		emit("popq %%rax")
+	}
+}
+
+// receive a wide element into the buffer variable
+func (e *ExprChanRecv) emitWide() {
+	emitAssignOne(e.buf, nil) // the zero value for a closed channel
+	e.ch.emit()
+	emit("PUSH_8 # channel")
+	e.buf.emitAddress(0)
+	emit("movq %%rax, %%rsi # buffer")
+	emit("popq %%rdi # channel")
+	emit("FUNCALL %s", getFuncSymbol(IRuntimePath, "chanrecv"))
+	emit("movq %%rax, %%%s # ok", mapOkRegister(true))
+	e.buf.emitAddress(0)
+}
+
+func (s *StmtSend) elementType() *Gtype {
+	gtype := s.ch.getGtype()
+	if gtype.getKind() != G_CHAN {
+		errorft(s.token(), "invalid operation: send to non-chan type %s", gtype.String())
+	}
+	return gtype.Underlying().elementType
+}
+
+// load a value to send
+func emitChanValue(value Expr, elementType *Gtype) {
+	if _, ok := value.(*ExprNilLiteral); ok && elementType.is24WidthType() {
+		emit("LOAD_EMPTY_24")
+	} else {
+		value.emit()
+	}
+}
+
+func (s *StmtSend) emit() {
+	emit("# send to channel")
+	if s.buf != nil {
+		// a wide element
+		emitAssignOne(s.buf, s.value)
+		s.ch.emit()
+		emit("PUSH_8 # channel")
+		s.buf.emitAddress(0)
+		emit("movq %%rax, %%rsi # buffer")
+		emit("popq %%rdi # channel")
+		emit("FUNCALL %s", getFuncSymbol(IRuntimePath, "chansend"))
+		return
+	}
+	elementType := s.elementType()
+	emitChanValue(s.value, elementType)
+	var bufSize int
+	if elementType.is24WidthType() {
+		emit("pushq %%rcx # buffer")
+		emit("pushq %%rbx")
+		emit("pushq %%rax")
+		bufSize = 24
+	} else {
+		emit("pushq %%rax # buffer")
+		bufSize = 8
+	}
+	s.ch.emit()
+	emit("movq %%rax, %%rdi # channel")
+	emit("movq %%rsp, %%rsi # buffer")
+	emit("FUNCALL %s", getFuncSymbol(IRuntimePath, "chansend"))
+	emit("addq $%d, %%rsp # destroy the buffer", bufSize)
+}
+
+// for v := range ch { ... }
+func (f *IrStmtRangeChan) emit() {
+	emit("# for range channel")
+	emit("%s: # begin loop ", f.labels.labelBegin)
+	f.recv.emit()
+	f.okvar.emit()
+	emit("cmpq $0, %%rax")
+	emit("je %s  # exit if the channel is closed", f.labels.labelEndLoop)
+	f.block.emit()
+	emit("%s: # end block", f.labels.labelEndBlock)
+	emitRenewCells(f.loopvars)
+	emit("jmp %s", f.labels.labelBegin)
+	emit("%s: # end loop", f.labels.labelEndLoop)
+}
+
+// load len or cap of a channel. nil channel has 0.
+func emitChanHeader(ch Expr, offset int) {
+	ch.emit()
+	labelNil := makeLabel()
+	labelEnd := makeLabel()
+	emit("cmpq $0, %%rax # check if channel is nil")
+	emit("je %s # jump if channel is nil", labelNil)
+	emit("movq %d(%%rax), %%rax", offset)
+	emit("jmp %s", labelEnd)
+	emit("%s:", labelNil)
+	emit("LOAD_NUMBER 0")
+	emit("%s:", labelEnd)
+}
@@ -0,0 +1,133 @@
+// gen_closure handles function literals and func values
+package main
+
+// A func value is the address of a closure object:
+//   [ func addr, cell addr of captured var 1, cell addr of captured var 2, ... ]
+// A function is called through a func value with the closure object in %rax,
+// from which the prologue of the callee loads the cells of captured variables.
+
+func (f *DeclFunc) getSignature() *signature {
+	return &signature{
+		fname:    f.fname,
+		params:   f.params,
+		rettypes: f.rettypes,
+	}
+}
+
+func getSignature(fn Expr) *signature {
+	gtype := fn.getGtype()
+	if gtype.getKind() != G_FUNC || gtype.Underlying().sig == nil {
+		errorft(fn.token(), "cannot call non-function (type %s)", gtype.String())
+	}
+	return gtype.Underlying().sig
+}
+
+// load a closure object which has no captured variables
+func (f *DeclFunc) emitLoadStaticClosure() {
+	label := makeLabel()
+	emit(".data 1")
+	emitWithoutIndent("%s:", label)
+	emit(".quad %s # func addr", f.getSymbol())
+	emit(".text")
+	emit("leaq %s(%%rip), %%rax # closure", label)
+}
+
+func (e *ExprFuncLiteral) emit() {
+	f := e.funcdef
+	if len(f.captures) == 0 {
+		f.emitLoadStaticClosure()
+		return
+	}
+	emit("# make a closure of %s", f.getSymbol())
+	emitCallMalloc(ptrSize * (1 + len(f.captures)))
+	emit("PUSH_8 # closure")
+	for i, c := range f.captures {
+		c.outer.emitAddress(0)
+		emit("popq %%rcx # closure")
+		emit("movq %%rax, %d(%%rcx) # cell of \"%s\"", ptrSize*(i+1), c.outer.varname)
+		emit("pushq %%rcx # closure")
+	}
+	f.emitLoadFuncRef()
+	emit("popq %%rcx # closure")
+	emit("movq %%rax, 0(%%rcx)")
+	emit("movq %%rcx, %%rax")
+}
+
+// The receiver is evaluated and copied into a new cell every time
+// the method value is evaluated.
+func (e *ExprMethodValue) emit() {
+	emit("# method value %s", e.funcdef.fname)
+	e.recvvar.emitNewCell()
+	emitAssignOne(e.recvvar, e.receiver)
+	funcLiteral := &ExprFuncLiteral{
+		tok:     e.tok,
+		funcdef: e.funcdef,
+	}
+	funcLiteral.emit()
+}
+
+func (e *ExprFuncValueCall) irCall() *IrCall {
+	return &IrCall{
+		tok:      e.token(),
+		funcval:  e.fn,
+		icallee:  getSignature(e.fn),
+		args:     e.args,
+		origExpr: e,
+	}
+}
+
+func (e *ExprFuncValueCall) emit() {
+	e.irCall().emit()
+}
+
+func (variable *ExprVariable) cellSize() int {
+	return align(variable.getGtype().getSize(), 8)
+}
+
+// allocate a new cell for the variable
+func (variable *ExprVariable) emitNewCell() {
+	emitCallMallocTyped(variable.cellSize(), variable.getGtype())
+	emit("STORE_8_TO_LOCAL %d # new cell of \"%s\"", variable.offset, variable.varname)
+}
+
+// move the value in the stack slot into a new cell
+func (variable *ExprVariable) emitMoveToCell() {
+	emitCallMallocTyped(variable.cellSize(), variable.getGtype())
+	for i := 0; i < variable.cellSize(); i += 8 {
+		emit("movq %d(%%rbp), %%rcx", variable.offset+i)
+		emit("movq %%rcx, %d(%%rax)", i)
+	}
+	emit("STORE_8_TO_LOCAL %d # new cell of \"%s\"", variable.offset, variable.varname)
+}
+
+// copy the current cell into a new cell
+// so that closures created in an iteration do not share variables with the next one.
+func (variable *ExprVariable) emitRenewCell() {
+	emitCallMallocTyped(variable.cellSize(), variable.getGtype())
+	emit("movq %d(%%rbp), %%rdx # old cell of \"%s\"", variable.offset, variable.varname)
+	for i := 0; i < variable.cellSize(); i += 8 {
+		emit("movq %d(%%rdx), %%rcx", i)
+		emit("movq %%rcx, %d(%%rax)", i)
+	}
+	emit("STORE_8_TO_LOCAL %d # new cell of \"%s\"", variable.offset, variable.varname)
+}
+
+func emitRenewCells(loopvars []*ExprVariable) {
+	for _, variable := range loopvars {
+		if variable.isBoxed {
+			variable.emitRenewCell()
+		}
+	}
+}
+
+func (s *IrStmtNewCells) emit() {
+	for _, variable := range s.variables {
+		variable.emitNewCell()
+	}
+}
+
+func (s *IrStmtNewCells) dump() {
+	for _, variable := range s.variables {
+		debugf("new cell %s", variable.varname)
+	}
+}
@@ -75,6 +75,8 @@ func (stmt *StmtSwitch) emit() {
 
 	emit("# switch statement")
 	labelEnd := makeLabel()
+	lbls := stmt.labels
+	lbls.labelEndLoop = labelEnd
 	var labels []string
 	// switch (expr) {
 	var cond Expr
@@ -161,6 +163,15 @@ func (stmt *StmtSwitch) emit() {
 	for i, caseClause := range stmt.cases {
 		emit("# case clause")
 		emit("%s:", labels[i])
+		if caseClause.fallthroughStmt != nil {
+			// go to the next clause
+			ft := caseClause.fallthroughStmt
+			if i+1 < len(labels) {
+				ft.label = labels[i+1]
+			} else {
+				ft.label = defaultLabel
+			}
+		}
 		caseClause.compound.emit()
 		emit("jmp %s", labelEnd)
 	}
@@ -191,6 +202,7 @@ func (f *IrStmtForRangeList) emit() {
 
 	f.block.emit()
 	emit("%s: # end block", f.labels.labelEndBlock)
+	emitRenewCells(f.loopvars)
 
 	f.cond2.emit()
 	emit("cmpq $0, %%rax")
@@ -215,6 +227,7 @@ func (f *IrStmtClikeFor) emit() {
 	}
 	f.block.emit()
 	emit("%s: # end block", f.labels.labelEndBlock)
+	emitRenewCells(f.loopvars)
 	if f.cls.post != nil {
 		f.cls.post.emit()
 	}
@@ -231,6 +244,10 @@ func (f *StmtFor) convert() Stmt {
 	if f.rng != nil {
 		if f.rng.rangeexpr.getGtype().getKind() == G_MAP {
 			f.kind = FOR_KIND_RANGE_MAP
+		} else if f.rng.rangeexpr.getGtype().getKind() == G_CHAN {
+			f.kind = FOR_KIND_RANGE_CHAN
+		} else if f.rng.rangeexpr.getGtype().getKind() == G_STRING {
+			f.kind = FOR_KIND_RANGE_STRING
 		} else {
 			f.kind = FOR_KIND_RANGE_LIST
 		}
@@ -254,13 +271,48 @@ func (f *StmtFor) convert() Stmt {
 	case FOR_KIND_RANGE_MAP:
 		assertNotNil(f.rng.indexvar != nil, f.rng.tok)
 		em = &IrStmtRangeMap{
-			tok:        f.token(),
-			block:      f.block,
-			labels:     f.labels,
-			rangeexpr:  f.rng.rangeexpr,
-			indexvar:   f.rng.indexvar,
-			valuevar:   f.rng.valuevar,
-			mapCounter: f.rng.invisibleMapCounter,
+			tok:       f.token(),
+			block:     f.block,
+			labels:    f.labels,
+			rangeexpr: f.rng.rangeexpr,
+			indexvar:  f.rng.indexvar,
+			valuevar:  f.rng.valuevar,
+			mapIter:   f.rng.invisibleMapCounter,
+			loopvars:  f.loopvars,
+		}
+	case FOR_KIND_RANGE_CHAN:
+		assertNotNil(f.rng.indexvar != nil, f.rng.tok)
+		if f.rng.valuevar != nil {
+			errorft(f.rng.tok, "range over %s permits only one iteration variable", f.rng.rangeexpr.getGtype().String())
+		}
+		// the invisible counter is used as the ok flag
+		okvar := f.rng.invisibleMapCounter
+		em = &IrStmtRangeChan{
+			tok:    f.token(),
+			labels: f.labels,
+			recv: &StmtAssignment{
+				tok:   f.token(),
+				lefts: []Expr{f.rng.indexvar, okvar},
+				rights: []Expr{&ExprChanRecv{
+					tok: f.rng.tok,
+					ch:  f.rng.rangeexpr,
+				}},
+			},
+			okvar:    okvar,
+			block:    f.block,
+			loopvars: f.loopvars,
+		}
+	case FOR_KIND_RANGE_STRING:
+		assertNotNil(f.rng.indexvar != nil, f.rng.tok)
+		em = &IrStmtRangeString{
+			tok:       f.token(),
+			labels:    f.labels,
+			rangeexpr: f.rng.rangeexpr,
+			indexvar:  f.rng.indexvar,
+			valuevar:  f.rng.valuevar,
+			next:      f.rng.invisibleMapCounter,
+			block:     f.block,
+			loopvars:  f.loopvars,
 		}
 	case FOR_KIND_RANGE_LIST:
 		assertNotNil(f.rng.indexvar != nil, f.rng.tok)
@@ -333,13 +385,15 @@ func (f *StmtFor) convert() Stmt {
 			incr:      incr,
 			block:     f.block,
 			labels:    f.labels,
+			loopvars:  f.loopvars,
 		}
 	case FOR_KIND_CLIKE:
 		em = &IrStmtClikeFor{
-			tok:    f.token(),
-			labels: f.labels,
-			cls:    f.cls,
-			block:  f.block,
+			tok:      f.token(),
+			labels:   f.labels,
+			cls:      f.cls,
+			block:    f.block,
+			loopvars: f.loopvars,
 		}
 	default:
 		assertNotReached(f.token())
@@ -355,45 +409,6 @@ func (stmt *StmtReturn) emitDeferAndReturn() {
 	}
 }
 
-func (ast *StmtDefer) emit() {
-	emit("# defer")
-	/*
-		// arguments should be evaluated immediately
-		var args []Expr
-		switch ast.expr.(type) {
-		case *ExprMethodcall:
-			call := ast.expr.(*ExprMethodcall)
-			args = call.args
-		case *ExprFuncallOrConversion:
-			call := ast.expr.(*ExprFuncallOrConversion)
-			args = call.args
-		default:
-			errorft(ast.token(), "defer should be a funcall")
-		}
-	*/
-	labelStart := string(makeLabel()) + "_defer"
-	labelEnd := string(makeLabel()) + "_defer"
-	ast.label = labelStart
-
-	emit("jmp %s", labelEnd)
-	emit("%s: # defer start", labelStart)
-
-	for i := 0; i < len(retRegi); i++ {
-		emit("pushq %%%s", retRegi[i])
-	}
-
-	ast.expr.emit()
-
-	for i := len(retRegi) - 1; i >= 0; i-- {
-		emit("popq %%%s", retRegi[i])
-	}
-
-	emit("leave")
-	emit("ret")
-	emit("%s: # defer end", labelEnd)
-
-}
-
 func (ast *StmtContinue) emit() {
 	assert(len(ast.labels.labelEndBlock) > 0, ast.token(), "labelEndBlock should not be empty")
 	emit("jmp %s # continue", ast.labels.labelEndBlock)
@@ -403,3 +418,19 @@ func (ast *StmtBreak) emit() {
 	assert(len(ast.labels.labelEndLoop) > 0, ast.token(), "labelEndLoop should not be empty")
 	emit("jmp %s # break", ast.labels.labelEndLoop)
 }
+
+func (ast *StmtLabeled) emit() {
+	emit("%s: # label %s", ast.asmLabel, ast.name)
+	if ast.stmt != nil {
+		ast.stmt.emit()
+	}
+}
+
+func (ast *StmtFallthrough) emit() {
+	assert(len(ast.label) > 0, ast.token(), "label should not be empty")
+	emit("jmp %s # fallthrough", ast.label)
+}
+
+func (ast *StmtGoto) emit() {
+	emit("jmp %s # goto %s", ast.target.asmLabel, ast.name)
+}
@@ -0,0 +1,199 @@
+// gen_float handles floating-point values.
+// A float64 value is kept in %rax as its bit pattern, and a float32 value in %eax,
+// so that they are loaded, saved and passed around like integers.
+// SSE registers are used only within arithmetic, comparisons and conversions.
+package main
+
+func (e *ExprFloatLiteral) emit() {
+	if e.getGtype().getKind() == G_FLOAT32 {
+		emit("LOAD_FLOAT32 %s", e.val)
+	} else {
+		emit("LOAD_FLOAT64 %s", e.val)
+	}
+}
+
+// whether e is an integer constant which has no type by itself, like 1 or 'a'
+func isUntypedIntConst(e Expr) bool {
+	c := evalConst(e)
+	return c != nil && (c.kind == untypedInt || c.kind == untypedRune)
+}
+
+// whether e is a floating-point constant which has no type by itself, like 1.5 or pi
+func isUntypedFloatConst(e Expr) bool {
+	c := evalConst(e)
+	return c != nil && c.kind == untypedFloat
+}
+
+func isUntypedConst(e Expr) bool {
+	return isUntypedIntConst(e) || isUntypedFloatConst(e)
+}
+
+// emit e as a value of gtype.
+// An untyped numeric constant is converted to gtype.
+func emitAs(e Expr, gtype *Gtype) {
+	c := evalConst(e)
+	if c != nil && c.gtype == nil && c.isNumeric() && gtype.isNumeric() {
+		emitConst(c, gtype.Underlying())
+		return
+	}
+	e.emit()
+}
+
+// emitConst loads a constant evaluated at compile time
+func emitConst(c *constant, gtype *Gtype) {
+	switch gtype.getKind() {
+	case G_FLOAT64:
+		emit("LOAD_FLOAT64 %s", c.floatText())
+	case G_FLOAT32:
+		emit("LOAD_FLOAT32 %s", c.floatText())
+	default:
+		emit("LOAD_NUMBER %s", c.intText())
+	}
+}
+
+// suffix of SSE instructions
+func floatSuffix(gtype *Gtype) string {
+	if gtype.getKind() == G_FLOAT32 {
+		return "ss"
+	}
+	return "sd"
+}
+
+func (binop *ExprBinop) emitFloat(gtype *Gtype) {
+	emitAs(binop.left, gtype)
+	emit("PUSH_8 # left")
+	emitAs(binop.right, gtype)
+	emit("PUSH_8 # right")
+	emit("POP_FLOAT_OPERANDS")
+	suffix := floatSuffix(gtype)
+	var inst string
+	switch binop.op {
+	case "+":
+		inst = "add"
+	case "-":
+		inst = "sub"
+	case "*":
+		inst = "mul"
+	case "/":
+		inst = "div"
+	default:
+		errorft(binop.token(), "invalid operation: operator %s not defined on %s", binop.op, gtype.String())
+	}
+	emit("%s%s %%xmm1, %%xmm0", inst, suffix)
+	if suffix == "ss" {
+		emit("movd %%xmm0, %%eax")
+	} else {
+		emit("movq %%xmm0, %%rax")
+	}
+}
+
+// x++ and x-- on a float are x += 1 and x -= 1
+func emitFloatIncrDecl(op string, tok *Token, operand Expr) {
+	binop := &ExprBinop{
+		tok:   tok,
+		op:    op,
+		left:  operand,
+		right: &ExprNumberLiteral{tok: tok, val: 1},
+	}
+	binop.emitFloat(operand.getGtype())
+	emitSavePrimitive(operand)
+}
+
+// Comparisons with NaN are false except for !=.
+// ucomis sets ZF, PF and CF when either operand is NaN.
+func (binop *ExprBinop) emitFloatComp(gtype *Gtype) {
+	emitAs(binop.left, gtype)
+	emit("PUSH_8 # left")
+	emitAs(binop.right, gtype)
+	emit("PUSH_8 # right")
+	emit("POP_FLOAT_OPERANDS")
+	suffix := floatSuffix(gtype)
+	switch binop.op {
+	case "<":
+		emit("ucomi%s %%xmm0, %%xmm1", suffix)
+		emit("seta %%al")
+	case "<=":
+		emit("ucomi%s %%xmm0, %%xmm1", suffix)
+		emit("setae %%al")
+	case ">":
+		emit("ucomi%s %%xmm1, %%xmm0", suffix)
+		emit("seta %%al")
+	case ">=":
+		emit("ucomi%s %%xmm1, %%xmm0", suffix)
+		emit("setae %%al")
+	case "==":
+		emit("ucomi%s %%xmm1, %%xmm0", suffix)
+		emit("sete %%al")
+		emit("setnp %%cl")
+		emit("andb %%cl, %%al")
+	case "!=":
+		emit("ucomi%s %%xmm1, %%xmm0", suffix)
+		emit("setne %%al")
+		emit("setp %%cl")
+		emit("orb %%cl, %%al")
+	default:
+		assertNotReached(binop.token())
+	}
+	emit("movzbq %%al, %%rax")
+}
+
+// conversion between numeric types where either side is a float
+func emitFloatConversion(arg Expr, toGtype *Gtype) {
+	fromGtype := arg.getGtype()
+	from := fromGtype.getKind()
+	to := toGtype.getKind()
+	if toGtype.isFloat() && isUntypedConst(arg) {
+		emitAs(arg, toGtype)
+		return
+	}
+	arg.emit()
+	switch {
+	case from == to:
+	case from == G_FLOAT64 && to == G_FLOAT32:
+		emit("CONVERT_FLOAT64_TO_FLOAT32")
+	case from == G_FLOAT32 && to == G_FLOAT64:
+		emit("CONVERT_FLOAT32_TO_FLOAT64")
+	case from == G_FLOAT64 && isUint64(toGtype):
+		emit("CONVERT_FLOAT64_TO_UINT")
+	case from == G_FLOAT32 && isUint64(toGtype):
+		emit("CONVERT_FLOAT32_TO_UINT")
+	case from == G_FLOAT64:
+		emit("CONVERT_FLOAT64_TO_INT")
+		emit_intcast(toGtype)
+	case from == G_FLOAT32:
+		emit("CONVERT_FLOAT32_TO_INT")
+		emit_intcast(toGtype)
+	case isUint64(fromGtype):
+		if to == G_FLOAT32 {
+			emit("CONVERT_UINT_TO_FLOAT32")
+		} else {
+			emit("CONVERT_UINT_TO_FLOAT64")
+		}
+	default:
+		emit_intcast(fromGtype)
+		if to == G_FLOAT32 {
+			emit("CONVERT_INT_TO_FLOAT32")
+		} else {
+			emit("CONVERT_INT_TO_FLOAT64")
+		}
+	}
+}
+
+// whether values of gtype can be 2^63 or above,
+// which the signed conversion instructions can not handle
+func isUint64(gtype *Gtype) bool {
+	switch gtype.getKind() {
+	case G_UINT, G_UINT_64, G_UINT_PTR:
+		return true
+	}
+	return false
+}
+
+// source text of a constant float expression for the assembler
+func floatConstText(e Expr) string {
+	c := evalConst(e)
+	if c == nil || !c.isNumeric() {
+		errorft(e.token(), "a float constant is expected")
+	}
+	return c.floatText()
+}
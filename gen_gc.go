@@ -0,0 +1,121 @@
+// gen_gc emits pointer maps for the garbage collector
+package main
+
+// A pointer map tells the garbage collector which words of an object may hold pointers.
+//   [ reserved for the allocator, number of words, a flag byte for each word ]
+// Objects without pointers are allocated as noscan,
+// and objects of unknown layout are scanned conservatively. (see internal/runtime/mgc.go)
+
+const mallocTypConservative int = 0
+const mallocTypNoscan int = 1
+
+// label of pointer map for each pattern of flags
+var ptrmapLabels map[string]string
+
+// flag the words of gtype which may hold pointers
+func (gtype *Gtype) markPointers(flags []int, offset int) {
+	switch gtype.getKind() {
+	case G_POINTER, G_MAP, G_CHAN, G_FUNC, G_SLICE, G_STRING, G_INTERFACE:
+		// the first word is a pointer
+		flags[offset/8] = 1
+	case G_UINT_PTR:
+		// the runtime holds addresses in uintptr
+		flags[offset/8] = 1
+	case G_STRUCT:
+		strct := gtype.Underlying()
+		strct.getSize()
+		for _, field := range strct.fields {
+			field.markPointers(flags, offset+field.offset)
+		}
+	case G_ARRAY:
+		arrayType := gtype.Underlying()
+		elementType := arrayType.elementType
+		for i := 0; i < arrayType.length; i++ {
+			elementType.markPointers(flags, offset+i*elementType.getSize())
+		}
+	case G_INT, G_BOOL, G_BYTE, G_UINT_16, G_INT_8, G_INT_16, G_INT_32, G_INT_64,
+		G_UINT, G_UINT_32, G_UINT_64, G_FLOAT64, G_FLOAT32:
+		// no pointers
+	default:
+		// unknown type may hold a pointer
+		flags[offset/8] = 1
+	}
+}
+
+func (gtype *Gtype) hasPointers() bool {
+	size := gtype.getSize()
+	flags := make([]int, (size+7)/8, (size+7)/8)
+	gtype.markPointers(flags, 0)
+	for _, flag := range flags {
+		if flag == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// load the typ argument of iruntime.mallocgc for a value of gtype
+func emitLoadPtrmap(size int, gtype *Gtype) {
+	nwords := (size + 7) / 8
+	flags := make([]int, nwords, nwords)
+	gtype.markPointers(flags, 0)
+	var key string
+	var hasPointers bool
+	for _, flag := range flags {
+		if flag == 1 {
+			key = key + "1"
+			hasPointers = true
+		} else {
+			key = key + "0"
+		}
+	}
+	if !hasPointers {
+		emit("LOAD_NUMBER %d # noscan", mallocTypNoscan)
+		return
+	}
+
+	if ptrmapLabels == nil {
+		ptrmapLabels = make(map[string]string)
+	}
+	label, ok := ptrmapLabels[key]
+	if !ok {
+		label = makeLabel()
+		ptrmapLabels[key] = label
+		emit(".data 0")
+		emit(".p2align 3")
+		emitWithoutIndent("%s: # pointer map", label)
+		emit(".quad 0 # spans")
+		emit(".quad %d # number of words", nwords)
+		for _, flag := range flags {
+			emit(".byte %d", flag)
+		}
+		emit(".text")
+	}
+	emit("leaq %s(%%rip), %%rax # pointer map", label)
+}
+
+// allocate size bytes for a value of gtype
+func emitCallMallocTyped(size int, gtype *Gtype) {
+	emit("LOAD_NUMBER %d", size)
+	emit("PUSH_8")
+	emitLoadPtrmap(size, gtype)
+	emit("PUSH_8")
+	emit("POP_TO_ARG_1")
+	emit("POP_TO_ARG_0")
+	emit("FUNCALL %s", getFuncSymbol(IRuntimePath, "mallocgc"))
+}
+
+// allocate size bytes for an array of elementType
+func emitCallMallocElements(size int, elementType *Gtype) {
+	emit("LOAD_NUMBER %d", size)
+	emit("PUSH_8")
+	if elementType.hasPointers() {
+		emit("LOAD_NUMBER %d # conservative", mallocTypConservative)
+	} else {
+		emit("LOAD_NUMBER %d # noscan", mallocTypNoscan)
+	}
+	emit("PUSH_8")
+	emit("POP_TO_ARG_1")
+	emit("POP_TO_ARG_0")
+	emit("FUNCALL %s", getFuncSymbol(IRuntimePath, "mallocgc"))
+}
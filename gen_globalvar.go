@@ -11,8 +11,10 @@ func (vr *ExprVariable) globalSymbol() string {
 // https://en.wikipedia.org/wiki/.bss
 func (decl *DeclVar) emitBss() {
 	emit(".data")
-	// https://sourceware.org/binutils/docs-2.30/as/Lcomm.html#Lcomm
-	emit(".lcomm %s, %d", decl.variable.globalSymbol(), decl.variable.getGtype().getSize())
+	// https://sourceware.org/binutils/docs-2.30/as/Comm.html#Comm
+	// align to 8 bytes so that the garbage collector can find pointers
+	emit(".local %s", decl.variable.globalSymbol())
+	emit(".comm %s, %d, 8", decl.variable.globalSymbol(), decl.variable.getGtype().getSize())
 }
 
 func (decl *DeclVar) emitData() {
@@ -20,9 +22,15 @@ func (decl *DeclVar) emitData() {
 	gtype := decl.variable.gtype
 	right := decl.initval
 
+	emitWithoutIndent(".data 0")
+	emit(".p2align 3")
 	emitWithoutIndent("%s: # gtype=%s", decl.variable.globalSymbol(), gtype.String())
 	emitWithoutIndent("# right.gtype = %s", right.getGtype().String())
-	emitWithoutIndent(".data 0")
+	if isUntypedIntConst(right) {
+		// the constant is converted to the type of the variable
+		doEmitData(ptok, gtype, right, "", 0)
+		return
+	}
 	doEmitData(ptok, right.getGtype(), right, "", 0)
 }
 
@@ -58,8 +66,10 @@ func doEmitData(ptok *Token /* left type */, gtype *Gtype, value /* nullable */
 				value := arrayliteral.values[i]
 				assertNotNil(value != nil, nil)
 				size := elmType.getSize()
-				if size == 8 {
-					switch value.(type) {
+				if elmType.isFloat() {
+					doEmitData(ptok, elmType, value, selector, depth)
+				} else if size == 8 {
+					switch unwrapRel(value).(type) {
 					case *ExprUop:
 						uop := value.(*ExprUop)
 						operand := unwrapRel(uop.operand)
@@ -68,6 +78,8 @@ func doEmitData(ptok *Token /* left type */, gtype *Gtype, value /* nullable */
 						emit(".quad %s # %s %s", vr.globalSymbol(), value.getGtype().String(), selector)
 					case *ExprVariable:
 						assert(false, value.token(), "variable here is not allowed")
+					case *ExprFuncRef, *ExprFuncLiteral:
+						doEmitData(ptok, elmType, value, selector, depth)
 					default:
 						emit(".quad %d # %s %s", evalIntExpr(value), value.getGtype().String(), selector)
 					}
@@ -104,7 +116,7 @@ func doEmitData(ptok *Token /* left type */, gtype *Gtype, value /* nullable */
 		case *ExprStringLiteral:
 			stringLiteral := value.(*ExprStringLiteral)
 			emit(".quad .%s", stringLiteral.slabel)
-			var length int = len(stringLiteral.val)
+			var length int = countStrlen(stringLiteral.val)
 			emit(".quad %d", length)
 			emit(".quad %d", length)
 		case *ExprFuncallOrConversion:
@@ -113,7 +125,7 @@ func doEmitData(ptok *Token /* left type */, gtype *Gtype, value /* nullable */
 			stringLiteral, ok := call.args[0].(*ExprStringLiteral)
 			assert(ok, call.token(), "arg0 should be stringliteral")
 			emit(".quad .%s", stringLiteral.slabel)
-			var length int = len(stringLiteral.val)
+			var length int = countStrlen(stringLiteral.val)
 			emit(".quad %d", length)
 			emit(".quad %d", length)
 		default:
@@ -131,21 +143,27 @@ func doEmitData(ptok *Token /* left type */, gtype *Gtype, value /* nullable */
 		}
 		var val int = evalIntExpr(value)
 		emit(".quad %d # %s %s", val, gtype.String(), containerName)
+	} else if primType == G_FLOAT64 || primType == G_FLOAT32 {
+		var directive string = ".double"
+		if primType == G_FLOAT32 {
+			directive = ".float"
+		}
+		if value == nil {
+			emit("%s 0 # %s %s zero value", directive, gtype.String(), containerName)
+			return
+		}
+		emit("%s %s # %s %s", directive, floatConstText(value), gtype.String(), containerName)
 	} else if primType == G_STRUCT {
 		s := string(containerName) + "." + string(gtype.relation.name)
 		containerName = s
+		var end int
 		for _, field := range gtype.relation.gtype.fields {
 			emit("# padding=%d", field.padding)
-			switch field.padding {
-			case 1:
-				emit(".byte 0 # padding")
-			case 4:
-				emit(".double 0 # padding")
-			case 8:
-				emit(".quad 0 # padding")
-			default:
+			if field.padding > 0 {
+				emit(".zero %d # padding", field.padding)
 			}
 			emit("# field:offesr=%d, fieldname=%s", field.offset, field.fieldname)
+			end = field.offset + field.getSize()
 			if value == nil {
 				s2 := string(containerName) + "." + string(field.fieldname)
 				doEmitData(ptok, field, nil, s2, depth)
@@ -162,28 +180,37 @@ func doEmitData(ptok *Token /* left type */, gtype *Gtype, value /* nullable */
 			s3 := string(containerName) + "." + string(field.fieldname)
 			doEmitData(ptok, gtype, value, s3, depth)
 		}
+		if gtype.getSize() > end {
+			emit(".zero %d # padding", gtype.getSize()-end)
+		}
 	} else {
 		var val int
 		var gtypeString string = gtype.String()
+		directive := intDataDirective(gtype.getSize())
 		switch value.(type) {
 		case nil:
-			emit(".quad %d # %s %s zero value", val, gtypeString, containerName)
+			emit("%s %d # %s %s zero value", directive, val, gtypeString, containerName)
 		case *ExprNumberLiteral:
 			val = value.(*ExprNumberLiteral).val
-			emit(".quad %d # %s %s", val, gtypeString, containerName)
+			emit("%s %d # %s %s", directive, val, gtypeString, containerName)
 		case *ExprConstVariable:
 			cnst := value.(*ExprConstVariable)
 			val = evalIntExpr(cnst)
-			emit(".quad %d # %s ", val, gtypeString)
+			emit("%s %d # %s ", directive, val, gtypeString)
 		case *ExprVariable:
 			vr := value.(*ExprVariable)
 			val = evalIntExpr(vr)
-			emit(".quad %d # %s ", val, gtypeString)
+			emit("%s %d # %s ", directive, val, gtypeString)
 		case *ExprBinop:
 			val = evalIntExpr(value)
-			emit(".quad %d # %s ", val, gtypeString)
+			emit("%s %d # %s ", directive, val, gtypeString)
 		case *ExprUop:
 			uop := value.(*ExprUop)
+			if uop.op == "-" {
+				val = evalIntExpr(uop)
+				emit("%s %d # %s ", directive, val, gtypeString)
+				return
+			}
 			assert(uop.op == "&", ptok, "only uop & is allowed")
 			operand := unwrapRel(uop.operand)
 			vr, ok := operand.(*ExprVariable)
@@ -194,12 +221,45 @@ func doEmitData(ptok *Token /* left type */, gtype *Gtype, value /* nullable */
 				// var gv = &Struct{_}
 				emitDataAddr(operand, depth)
 			}
+		case *ExprFuncRef:
+			funcref := value.(*ExprFuncRef)
+			emitStaticClosure(funcref.funcdef, depth)
+		case *ExprFuncLiteral:
+			funcLiteral := value.(*ExprFuncLiteral)
+			emitStaticClosure(funcLiteral.funcdef, depth)
+		case *ExprStructField:
+			// the receiver of a method value is evaluated at run time
+			TBI(ptok, "method value %s in a package-level variable", value.(*ExprStructField).fieldname)
 		default:
 			TBI(ptok, "unable to handle %d", primType)
 		}
 	}
 }
 
+// directive of an integer of size bytes
+func intDataDirective(size int) string {
+	switch size {
+	case 1:
+		return ".byte"
+	case 2:
+		return ".short"
+	case 4:
+		return ".long"
+	default:
+		return ".quad"
+	}
+}
+
+// a closure object which has no captured variables
+func emitStaticClosure(f *DeclFunc, depth int) {
+	emit(".data %d", depth+1)
+	label := makeLabel()
+	emit("%s:", label)
+	emit(".quad %s # func addr", f.getSymbol())
+	emit(".data %d", depth)
+	emit(".quad %s", label)
+}
+
 // this logic is stolen from 8cc.
 func emitDataAddr(operand Expr, depth int) {
 	emit(".data %d", depth+1)
@@ -12,23 +12,44 @@ func loadStructField(strct Expr, field *Gtype, offset int) {
 	case *ExprVariable:
 		variable := strct.(*ExprVariable)
 		if field.getKind() == G_ARRAY {
-			variable.emitAddress(field.offset)
+			variable.emitAddress(field.offset + offset)
+		} else if field.is24WidthType() {
+			variable.emitAddress(field.offset + offset)
+			emit("LOAD_24_BY_DEREF")
 		} else {
-			if variable.isGlobal {
-				emit("LOAD_8_FROM_GLOBAL %s, %d+%d", variable.globalSymbol(), field.offset, offset)
-			} else {
-				emit("LOAD_8_FROM_LOCAL %d+%d+%d", variable.offset, field.offset, offset)
-			}
+			variable.emitOffsetLoad(loadSize(field), field.offset+offset)
+			emit_intcast(field)
 		}
 	case *ExprStructField: // strct.field.field
 		a := strct.(*ExprStructField)
+		if a.strct.getGtype().getKind() == G_POINTER {
+			// ptr.field.field
+			a.emitAddress()
+			emit("ADD_NUMBER %d", field.offset+offset)
+			if field.getKind() == G_ARRAY {
+				return
+			}
+			if field.is24WidthType() {
+				emit("LOAD_24_BY_DEREF")
+			} else {
+				emit("LOAD_%d_BY_DEREF", loadSize(field))
+				emit_intcast(field)
+			}
+			return
+		}
 		strcttype := a.strct.getGtype().Underlying()
 		assert(strcttype.size > 0, a.token(), "struct size should be > 0")
+		// the field keeps its type, and is offset by the outer field
 		field2 := strcttype.getField(a.fieldname)
-		loadStructField(a.strct, field2, offset+field.offset)
+		loadStructField(a.strct, field, offset+field2.offset)
 	case *ExprIndex: // array[1].field
 		indexExpr := strct.(*ExprIndex)
-		indexExpr.emitOffsetLoad(offset + field.offset)
+		if field.is24WidthType() {
+			indexExpr.emitOffsetLoad(24, offset+field.offset)
+		} else {
+			indexExpr.emitOffsetLoad(loadSize(field), offset+field.offset)
+			emit_intcast(field)
+		}
 	default:
 		// funcall().field
 		// methodcall().field
@@ -41,6 +62,14 @@ func loadStructField(strct Expr, field *Gtype, offset int) {
 }
 
 func (a *ExprStructField) emitAddress() {
+	if a.strct.getGtype().getKind() != G_POINTER {
+		// strct.field where strct is a struct value
+		strcttype := a.strct.getGtype().relation.gtype
+		field := strcttype.getField(a.fieldname)
+		emitAddress(a.strct)
+		emit("ADD_NUMBER %d", field.offset)
+		return
+	}
 	strcttype := a.strct.getGtype().origType.relation.gtype
 	field := strcttype.getField(a.fieldname)
 	a.strct.emit()
@@ -59,14 +88,8 @@ func (a *ExprStructField) emit() {
 		case true:
 			emit("LOAD_24_BY_DEREF")
 		default:
-			switch field.getKind() {
-			case G_BYTE:
-				emit("LOAD_1_BY_DEREF")
-			case G_UINT_16:
-				emit("LOAD_2_BY_DEREF")
-			default:
-				emit("LOAD_8_BY_DEREF")
-			}
+			emit("LOAD_%d_BY_DEREF", loadSize(field))
+			emit_intcast(field)
 		}
 
 	case G_STRUCT:
@@ -97,24 +120,35 @@ func (ast *ExprVariable) emit() {
 			ast.emitAddress(0)
 		} else if ast.getGtype().is24WidthType() {
 			emit("LOAD_24_FROM_GLOBAL %s", symbol)
-		} else if ast.getGtype().getSize() == 1 {
-			emit("LOAD_1_FROM_GLOBAL_CAST %s", symbol)
 		} else {
-			emit("LOAD_8_FROM_GLOBAL %s", symbol)
+			emit("LOAD_%d_FROM_GLOBAL %s", loadSize(ast.getGtype()), symbol)
+			emit_intcast(ast.getGtype())
 		}
 
 	} else {
 		if ast.offset == 0 {
 			errorft(ast.token(), "offset should not be zero for localvar %s", ast.varname)
 		}
+		if ast.isBoxed {
+			ast.emitAddress(0)
+			if ast.gtype.getKind() == G_ARRAY {
+				return
+			}
+			if ast.gtype.is24WidthType() {
+				emit("LOAD_24_BY_DEREF")
+			} else {
+				emit("LOAD_%d_BY_DEREF", loadSize(ast.getGtype()))
+				emit_intcast(ast.getGtype())
+			}
+			return
+		}
 		if ast.gtype.getKind() == G_ARRAY {
 			ast.emitAddress(0)
 		} else if ast.gtype.is24WidthType() {
 			emit("LOAD_24_FROM_LOCAL %d", ast.offset)
-		} else if ast.getGtype().getSize() == 1 {
-			emit("LOAD_1_FROM_LOCAL_CAST %d", ast.offset)
 		} else {
-			emit("LOAD_8_FROM_LOCAL %d", ast.offset)
+			emit("LOAD_%d_FROM_LOCAL %d", loadSize(ast.getGtype()), ast.offset)
+			emit_intcast(ast.getGtype())
 		}
 	}
 }
@@ -126,6 +160,11 @@ func (variable *ExprVariable) emitAddress(offset int) {
 		if variable.offset == 0 {
 			errorft(variable.token(), "offset should not be zero for localvar %s", variable.varname)
 		}
+		if variable.isBoxed {
+			emit("LOAD_8_FROM_LOCAL %d # cell of \"%s\"", variable.offset, variable.varname)
+			emit("ADD_NUMBER %d", offset)
+			return
+		}
 		emit("LOAD_LOCAL_ADDR %d+%d", variable.offset, offset)
 	}
 }
@@ -137,21 +176,19 @@ func (rel *Relation) emit() {
 
 func (ast *ExprConstVariable) emit() {
 	emit("# *ExprConstVariable.emit() name=%s iotaindex=%d", ast.name, ast.iotaIndex)
-	assert(ast.iotaIndex < 10000, ast.token(), "iotaindex is too large")
-	assert(ast.val != nil, ast.token(), "const.val for should not be nil:%s", ast.name)
-	if ast.hasIotaValue() {
-		emit("# const is iota")
-		val := &ExprNumberLiteral{
-			val: ast.iotaIndex,
-		}
-		val.emit()
-	} else {
-		emit("# const is not iota")
-		ast.val.emit()
+	if c := evalConst(ast); c != nil && !c.isString() {
+		emitConst(c, c.getGtype())
+		return
 	}
+	assert(ast.val != nil, ast.token(), "const.val for should not be nil:%s", ast.name)
+	ast.val.emit()
 }
 
 func (ast *ExprUop) emit() {
+	if c := evalConst(ast); c != nil && !c.isString() {
+		emitConst(c, c.getGtype())
+		return
+	}
 	operand := unwrapRel(ast.operand)
 	ast.operand = operand
 	emit("# emitting ExprUop")
@@ -169,7 +206,7 @@ func (ast *ExprUop) emit() {
 			ivv := e.invisiblevar
 			assignToStruct(ivv, e)
 
-			emitCallMalloc(e.getGtype().getSize())
+			emitCallMallocTyped(e.getGtype().getSize(), e.getGtype())
 			emit("PUSH_8") // to:ptr addr
 			e.invisiblevar.emitAddress(0)
 			emit("PUSH_8") // from:address of invisible var
@@ -186,7 +223,8 @@ func (ast *ExprUop) emit() {
 		}
 	case "*":
 		ast.operand.emit()
-		emit("LOAD_8_BY_DEREF")
+		emit("LOAD_%d_BY_DEREF", loadSize(ast.getGtype()))
+		emit_intcast(ast.getGtype())
 	case "!":
 		ast.operand.emit()
 		emit("CMP_EQ_ZERO")
@@ -207,10 +245,29 @@ func (ast *ExprUop) emit() {
 
 }
 
+// the width of a load or store of a scalar value of gtype
+func scalarSize(gtype *Gtype) int {
+	size := gtype.getSize()
+	assert(0 <= size && size <= 8 && !isAggregate(gtype), nil, "invalid size")
+	return size
+}
+
+// the width of a load of a value of gtype.
+// A struct is loaded as its first word.
+func loadSize(gtype *Gtype) int {
+	if isAggregate(gtype) {
+		return 8
+	}
+	return scalarSize(gtype)
+}
+
 func (variable *ExprVariable) emitOffsetLoad(size int, offset int) {
 	assert(0 <= size && size <= 8, variable.token(), "invalid size")
 	if variable.isGlobal {
 		emit("LOAD_%d_FROM_GLOBAL %s %d", size, variable.globalSymbol(), offset)
+	} else if variable.isBoxed {
+		variable.emitAddress(offset)
+		emit("LOAD_%d_BY_DEREF", size)
 	} else {
 		emit("LOAD_%d_FROM_LOCAL %d+%d", size, variable.offset, offset)
 	}
@@ -223,13 +280,13 @@ func (e *ExprSliceLiteral) emit() {
 	emit("# (*ExprSliceLiteral).emit()")
 	var length int = len(e.values)
 	//debugf(S("slice literal %s: underlyingarray size = %d (should be %d)"), e.getGtype(), e.gtype.getSize(),  e.gtype.elementType.getSize() * length)
-	emitCallMalloc(e.gtype.getSize() * length)
+	emitCallMallocElements(e.gtype.getSize()*length, e.gtype.elementType)
 	emit("PUSH_8 # ptr")
 	for i, value := range e.values {
 		if e.gtype.elementType.getKind() == G_INTERFACE && value.getGtype().getKind() != G_INTERFACE {
 			emitConversionToInterface(value)
 		} else {
-			value.emit()
+			emitAs(value, e.gtype.elementType)
 		}
 
 		emit("popq %%r10 # ptr")
@@ -240,8 +297,18 @@ func (e *ExprSliceLiteral) emit() {
 			emit("movq %%rbx, %d+%d(%%r10)", baseOffset, offset8)
 			emit("movq %%rcx, %d+%d(%%r10)", baseOffset, offset16)
 		} else if e.gtype.elementType.getSize() <= 8 {
-			var offset int = IntSize * i
-			emit("movq %%rax, %d(%%r10)", offset)
+			elmSize := e.gtype.elementType.getSize()
+			var offset int = elmSize * i
+			switch elmSize {
+			case 1:
+				emit("movb %%al, %d(%%r10)", offset)
+			case 2:
+				emit("movw %%ax, %d(%%r10)", offset)
+			case 4:
+				emit("movl %%eax, %d(%%r10)", offset)
+			default:
+				emit("movq %%rax, %d(%%r10)", offset)
+			}
 		} else {
 			TBI(e.token(), "ExprSliceLiteral emit")
 		}
@@ -260,6 +327,17 @@ func emitAddress(e Expr) {
 		e.(*ExprVariable).emitAddress(0)
 	case *ExprIndex:
 		e.(*ExprIndex).emitAddress()
+	case *ExprStructField:
+		e.(*ExprStructField).emitAddress()
+	case *ExprUop:
+		uop := e.(*ExprUop)
+		if uop.op != "*" {
+			TBI(e.token(), "")
+		}
+		uop.operand.emit()
+	case *ExprChanRecv:
+		// a wide element is received into a variable
+		e.emit()
 	default:
 		TBI(e.token(), "")
 	}
@@ -280,15 +358,14 @@ func emitOffsetLoad(lhs Expr, size int, offset int) {
 			var sum int = fieldType.offset + offset
 			emit("# offset %d + %d = %d", fieldType.offset, offset, sum)
 			emit("ADD_NUMBER %d+%d", fieldType.offset, offset)
-			//reg := getReg(size)
-			emit("LOAD_8_BY_DEREF")
+			emit("LOAD_%d_BY_DEREF", size)
 		} else {
 			emitOffsetLoad(structfield.strct, size, fieldType.offset+offset)
 		}
 	case *ExprIndex:
 		//  e.g. arrayLiteral.values[i].getGtype().getKind()
 		indexExpr := lhs.(*ExprIndex)
-		indexExpr.emitOffsetLoad(offset)
+		indexExpr.emitOffsetLoad(size, offset)
 	case *ExprMethodcall:
 		// @TODO this logic is temporarly. Need to be verified.
 		mcall := lhs.(*ExprMethodcall)
@@ -299,11 +376,33 @@ func emitOffsetLoad(lhs Expr, size int, offset int) {
 		mcall.emit()
 		emit("ADD_NUMBER %d", offset)
 		emit("LOAD_8_BY_DEREF")
+	case *ExprUop:
+		uop := lhs.(*ExprUop)
+		assert(uop.op == "*", uop.tok, "uop op should be *")
+		uop.operand.emit()
+		emit("ADD_NUMBER %d", offset)
+		emit("LOAD_%d_BY_DEREF", size)
 	default:
 		errorft(lhs.token(), "unkonwn type %T", lhs)
 	}
 }
 
+// emit the head address of a collection onto the stack, and the index into %rax.
+// It panics if the index is out of range.
+func emitHeadAndCheckedIndex(collection Expr, index Expr) {
+	collection.emit()
+	emit("PUSH_8 # head")
+	collectionType := collection.getGtype().Underlying()
+	if collectionType.getKind() == G_ARRAY {
+		emit("pushq $%d # len", collectionType.length)
+	} else {
+		emit("pushq %%rbx # len")
+	}
+
+	index.emit()
+	emit("CHECK_INDEX")
+}
+
 func (e *ExprIndex) emitAddressOfArrayOrSliceIndex() {
 	collection := e.collection
 	index := e.index
@@ -312,31 +411,20 @@ func (e *ExprIndex) emitAddressOfArrayOrSliceIndex() {
 	elmSize := elmType.getSize()
 	assert(elmSize > 0, nil, "elmSize > 0")
 
-	collection.emit()
-	emit("PUSH_8 # head")
-
-	index.emit()
+	emitHeadAndCheckedIndex(collection, index)
 	emit("IMUL_NUMBER %d", elmSize)
 	emit("PUSH_8 # index * elmSize")
 
 	emit("SUM_FROM_STACK # (index * elmSize) + head")
 }
 
-func (e *ExprIndex) loadArrayOrSliceIndex(offset int) {
-	elmType := e.collection.getGtype().Underlying().elementType
-	elmSize := elmType.getSize()
-
+// size is 24 for a slice, string or interface
+func (e *ExprIndex) loadArrayOrSliceIndex(size int, offset int) {
 	e.emitAddressOfArrayOrSliceIndex()
 	emit("ADD_NUMBER %d", offset)
 
 	// dereference the content of an emelment
-	if elmType.is24WidthType() {
-		emit("LOAD_24_BY_DEREF")
-	} else if elmSize == 1 {
-		emit("LOAD_1_BY_DEREF")
-	} else {
-		emit("LOAD_8_BY_DEREF")
-	}
+	emit("LOAD_%d_BY_DEREF", size)
 }
 
 func (e *ExprIndex) emitAddress() {
@@ -348,14 +436,21 @@ func (e *ExprIndex) emitAddress() {
 	}
 }
 
-func (e *ExprIndex) emitOffsetLoad(offset int) {
+func (e *ExprIndex) emitOffsetLoad(size int, offset int) {
 	emit("# ExprIndex.emitOffsetLoad")
 	switch e.collection.getGtype().getKind() {
 	case G_ARRAY, G_SLICE, G_STRING:
-		e.loadArrayOrSliceIndex(offset)
+		e.loadArrayOrSliceIndex(size, offset)
 		return
 	case G_MAP:
 		loadMapIndexExpr(e)
+		// pick a word of the value
+		switch offset {
+		case 8:
+			emit("movq %%rbx, %%rax")
+		case 16:
+			emit("movq %%rcx, %%rax")
+		}
 	default:
 		TBI(e.collection.token(), "unable to handle kind %d", e.collection.getGtype().String())
 	}
@@ -363,62 +458,66 @@ func (e *ExprIndex) emitOffsetLoad(offset int) {
 
 // Slice expressions construct a substring or slice from a string, array, pointer to array, or slice.
 func (e *ExprSlice) emit() {
-	elmType := e.collection.getGtype().Underlying().elementType
+	collectionType := e.collection.getGtype().Underlying()
+	elmType := collectionType.elementType
 	assert(elmType != nil, e.token(), "type should not be nil:T %s", e.collection.getGtype().String())
 	size := elmType.getSize()
 	assert(size > 0, nil, "size > 0")
 
-	emit("# assign to a slice")
-	emit("#   emit address of the array")
+	emit("# emit a slice expression")
 	e.collection.emit()
 	emit("PUSH_8 # head of the array")
-	e.low.emit()
-	emit("PUSH_8 # low index")
-	emit("LOAD_NUMBER %d", size)
-	emit("PUSH_8")
-	emit("IMUL_FROM_STACK")
-	emit("PUSH_8")
-	emit("SUM_FROM_STACK")
-	emit("PUSH_8")
-
-	emit("#   calc and set len")
-
-	if e.high == nil {
-		e.high = &ExprLen{
-			tok: e.token(),
-			arg: e.collection,
-		}
+	switch collectionType.getKind() {
+	case G_ARRAY:
+		emit("pushq $%d # len", collectionType.length)
+		emit("pushq $%d # cap", collectionType.length)
+	case G_STRING:
+		emit("pushq %%rbx # len")
+		emit("pushq %%rbx # cap")
+	default:
+		emit("pushq %%rbx # len")
+		emit("pushq %%rcx # cap")
 	}
-	calcLen := &ExprBinop{
-		op:    "-",
-		left:  e.high,
-		right: e.low,
+	e.low.emit()
+	emit("PUSH_8 # low")
+	if e.high != nil {
+		e.high.emit()
+	} else {
+		emit("movq 16(%%rsp), %%rax # len")
 	}
-	calcLen.emit()
-	emit("PUSH_8")
-
-	if e.collection.getGtype().isString() {
-		emit("PUSH_8")
+	emit("PUSH_8 # high")
+	three := 0
+	if e.max != nil {
+		three = 1
+		e.max.emit()
+		emit("PUSH_8 # max")
 	} else {
-		emit("#   calc and set cap")
-		var max Expr
-		if e.max != nil {
-			max = e.max
-		} else {
-			max = &ExprCap{
-				tok: e.token(),
-				arg: e.collection,
-			}
-		}
-		calcCap := &ExprBinop{
-			op:    "-",
-			left:  max,
-			right: e.low,
-		}
+		emit("PUSH_8 # high as max")
+	}
 
-		calcCap.emit()
-		emit("PUSH_8")
+	emit("popq %%rdx # max")
+	emit("popq %%rsi # high")
+	emit("popq %%rdi # low")
+	emit("popq %%rcx # cap")
+	emit("popq %%rbx # len")
+	hasCap := 0
+	if collectionType.getKind() == G_SLICE {
+		hasCap = 1
 	}
+	emit("CHECK_SLICE %d, %d", three, hasCap)
 
-	emit("POP_SLICE")
+	emit("popq %%rax # head")
+	if e.max == nil {
+		emit("movq %%rcx, %%rdx # max is cap")
+	}
+	emit("movq %%rsi, %%rbx")
+	emit("subq %%rdi, %%rbx # len = high - low")
+	if collectionType.getKind() == G_STRING {
+		emit("movq %%rbx, %%rcx # cap = len")
+	} else {
+		emit("movq %%rdx, %%rcx")
+		emit("subq %%rdi, %%rcx # cap = max - low")
+	}
+	emit("imulq $%d, %%rdi", size)
+	emit("addq %%rdi, %%rax # head + low * size")
 }
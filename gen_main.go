@@ -29,6 +29,13 @@ func (program *Program) emitDynamicTypes() {
 	emit("# Dynamic Types")
 	for dynamicTypeId, gs := range symbolTable.uniquedDTypes {
 		label := makeDynamicTypeLabel(dynamicTypeId)
+		// the underlying type is stored before the type
+		underlyingId := util.Index(symbolTable.underlyingDTypes[dynamicTypeId], symbolTable.uniquedDTypes)
+		if underlyingId >= 0 {
+			emit(".quad .%s # underlying type", makeDynamicTypeLabel(underlyingId))
+		} else {
+			emit(".quad 0 # underlying type")
+		}
 		emitWithoutIndent(".%s:", label)
 		emit(".string \"%s\"", gs)
 	}
@@ -38,8 +45,6 @@ func (program *Program) emitMethodTable() {
 	emitWithoutIndent("#--------------------------------------------------------")
 	emit("# Method table")
 	emit(".data 0")
-	emitWithoutIndent("%s:", "receiverTypes")
-	emit(".quad 0 # receiverTypeId:0")
 	var maxId int
 	var i int
 	var id int
@@ -48,6 +53,9 @@ func (program *Program) emitMethodTable() {
 			maxId = id
 		}
 	}
+	emit(".quad %d # the number of receiver types", maxId)
+	emitWithoutIndent("%s:", "receiverTypes")
+	emit(".quad 0 # receiverTypeId:0")
 	for i = 1; i <= maxId; i++ {
 		_, ok := program.methodTable[i]
 		if ok {
@@ -59,6 +67,8 @@ func (program *Program) emitMethodTable() {
 
 	var shortMethodNames []string
 
+	// a method table is an array of pairs of a method name and a function address.
+	// (see findMethod in internal/runtime/map.go)
 	for i, v := range program.methodTable {
 		emitWithoutIndent("receiverType%d:", i)
 		methods := v
@@ -68,34 +78,22 @@ func (program *Program) emitMethodTable() {
 			}
 			splitted := strings.Split(methodNameFull, "$")
 			var shortMethodName string = splitted[1]
-			emit(".quad .S.S.%s # key", shortMethodName)
-			label := makeLabel()
-			gasIndentLevel++
-			emit(".data 1")
-			emit("%s:", label)
+			emit(".quad .S.%s # name", shortMethodName)
 			emit(".quad %s # func addr", methodNameFull)
-			gasIndentLevel--
-			emit(".data 0")
-			emit(".quad %s # func addr addr", label)
 
 			if !util.InArray(shortMethodName, shortMethodNames) {
 				shortMethodNames = append(shortMethodNames, shortMethodName)
 			}
 		}
+		emit(".quad 0 # end of methods")
 	}
 
 	emitWithoutIndent("#--------------------------------------------------------")
 	emitWithoutIndent("# Short method names")
 	for _, shortMethodName := range shortMethodNames {
-		emit(".data 0")
-		emit(".S.S.%s:", shortMethodName)
-		gasIndentLevel++
-		emit(".data 1")
+		// the address identifies a method name. The runtime looks up methods by name. (see lookupMethod)
 		emit(".S.%s:", shortMethodName)
-		emit(".quad 0") // Any value is ok. This is not referred to.
-		gasIndentLevel--
-		emit(".data 0")
-		emit(".quad .S.%s", shortMethodName)
+		emit(".string \"%s\"", shortMethodName)
 	}
 
 }
@@ -149,6 +147,8 @@ func (program *Program) emit() {
 
 	}
 
+	emitFuncTable()
+
 }
 
 func emitMainFunc(packages []*AstPackage) {
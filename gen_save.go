@@ -25,9 +25,9 @@ func emitSavePrimitive(lhs Expr) {
 	case nil:
 		return
 	case *ExprVariable:
-		emitOffsetSavePrimitive(lhs, lhs.getGtype().getSize(), 0)
+		emitOffsetSavePrimitive(lhs, scalarSize(lhs.getGtype()), 0)
 	case *ExprIndex:
-		emitOffsetSavePrimitive(lhs, lhs.getGtype().getSize(), 0)
+		emitOffsetSavePrimitive(lhs, scalarSize(lhs.getGtype()), 0)
 	case *ExprStructField:
 		lhs.(*ExprStructField).emitSavePrimitive()
 	case *ExprUop:
@@ -46,13 +46,27 @@ func emitOffsetSavePrimitive(lhs Expr, size int, offset int) {
 		variable.emitOffsetSavePrimitive(size, offset, false)
 	case *ExprIndex:
 		indexExpr := lhs.(*ExprIndex)
-		indexExpr.emitOffsetSavePrimitive(offset)
+		indexExpr.emitOffsetSavePrimitive(size, offset)
 	case *ExprStructField:
 		structfield := lhs.(*ExprStructField)
 		fieldType := structfield.getGtype()
-		emitOffsetSavePrimitive(structfield.strct, size, fieldType.offset+offset)
+		if structfield.strct.getGtype().getKind() == G_POINTER {
+			emit("PUSH_8 # rhs")
+			structfield.strct.emit()
+			emit("ADD_NUMBER %d", fieldType.offset+offset)
+			emit("PUSH_8")
+			emit("STORE_%d_INDIRECT_FROM_STACK", size)
+		} else {
+			emitOffsetSavePrimitive(structfield.strct, size, fieldType.offset+offset)
+		}
 	case *ExprUop:
-		errorft(lhs.token(), "unkonwn type %T", lhs)
+		uop := lhs.(*ExprUop)
+		assert(uop.op == "*", uop.tok, "uop op should be *")
+		emit("PUSH_8 # what")
+		uop.operand.emit()
+		emit("ADD_NUMBER %d", offset)
+		emit("PUSH_8 # where")
+		emit("STORE_%d_INDIRECT_FROM_STACK", size)
 	default:
 		errorft(lhs.token(), "unkonwn type %T", lhs)
 	}
@@ -66,11 +80,7 @@ func (uop *ExprUop) emitSavePrimitive() {
 	uop.operand.emit()
 	emit("PUSH_8 # where")
 
-	if uop.getGtype().getSize() == 1 {
-		emit("STORE_1_INDIRECT_FROM_STACK")
-	} else {
-		emit("STORE_8_INDIRECT_FROM_STACK")
-	}
+	emit("STORE_%d_INDIRECT_FROM_STACK", scalarSize(uop.getGtype()))
 }
 
 // x = 1
@@ -82,18 +92,23 @@ func (variable *ExprVariable) emitOffsetSavePrimitive(size int, offset int, forc
 		variable.emit()
 		emit("ADD_NUMBER %d", offset)
 		emit("PUSH_8 # where")
-		emit("STORE_8_INDIRECT_FROM_STACK # %s", variable.varname)
+		emit("STORE_%d_INDIRECT_FROM_STACK # %s", size, variable.varname)
 		return
 	}
 	if variable.isGlobal {
 		emit("STORE_%d_TO_GLOBAL %s %d # %s ", size, variable.globalSymbol(), offset, variable.varname)
+	} else if variable.isBoxed {
+		emit("PUSH_8 # what")
+		variable.emitAddress(offset)
+		emit("PUSH_8 # where")
+		emit("STORE_%d_INDIRECT_FROM_STACK # %s", size, variable.varname)
 	} else {
 		emit("STORE_%d_TO_LOCAL %d+%d # %s", size, variable.offset, offset, variable.varname)
 	}
 }
 
 // save data from stack
-func (e *ExprIndex) emitSave24() {
+func (e *ExprIndex) emitSave24(offset int) {
 	// load head address of the array
 	// load index
 	// multi index * size
@@ -107,9 +122,7 @@ func (e *ExprIndex) emitSave24() {
 	}
 
 	assert(collectionType.getKind() == G_ARRAY || collectionType.getKind() == G_SLICE, e.token(), "unexpected kind")
-	e.collection.emit()
-	emit("PUSH_8 # head address of collection")
-	e.index.emit()
+	emitHeadAndCheckedIndex(e.collection, e.index)
 	emit("PUSH_8 # index")
 	elmType := collectionType.elementType
 	size := elmType.getSize()
@@ -119,15 +132,16 @@ func (e *ExprIndex) emitSave24() {
 	emit("IMUL_FROM_STACK # index * elementSize")
 	emit("PUSH_8 # index * elementSize")
 	emit("SUM_FROM_STACK # (index * size) + address")
+	emit("ADD_NUMBER %d # offset", offset)
 	emit("PUSH_8")
 	emit("STORE_24_INDIRECT_FROM_STACK")
 }
 
-func (e *ExprIndex) emitOffsetSavePrimitive(offset int) {
+func (e *ExprIndex) emitOffsetSavePrimitive(size int, offset int) {
 	collectionType := e.collection.getGtype()
 	switch {
 	case collectionType.getKind() == G_ARRAY, collectionType.getKind() == G_SLICE:
-		e.emitArrayOrSliceSavePrimitive(offset)
+		e.emitArrayOrSliceSavePrimitive(size, offset)
 	case collectionType.getKind() == G_MAP:
 		emit("PUSH_8") // push RHS value
 		e.emitMapSetFromStack8()
@@ -146,9 +160,9 @@ func (e *ExprStructField) emitSavePrimitive() {
 		emit("ADD_NUMBER %d", fieldType.offset)
 		emit("PUSH_8")
 
-		emit("STORE_8_INDIRECT_FROM_STACK")
+		emit("STORE_%d_INDIRECT_FROM_STACK", scalarSize(fieldType))
 	} else {
-		emitOffsetSavePrimitive(e.strct, 8, fieldType.offset)
+		emitOffsetSavePrimitive(e.strct, scalarSize(fieldType), fieldType.offset)
 	}
 }
 
@@ -170,7 +184,15 @@ func emitSave24(lhs Expr, offset int) {
 		emitSave24(structfield.strct, fieldOffset+offset)
 	case *ExprIndex:
 		indexExpr := lhs.(*ExprIndex)
-		indexExpr.emitSave24()
+		indexExpr.emitSave24(offset)
+	case *ExprUop:
+		uop := lhs.(*ExprUop)
+		assert(uop.op == "*", uop.tok, "uop op should be *")
+		emit("PUSH_24")
+		uop.operand.emit()
+		emit("ADD_NUMBER %d", offset)
+		emit("PUSH_8")
+		emit("STORE_24_INDIRECT_FROM_STACK")
 	default:
 		errorft(lhs.token(), "unkonwn type %T", lhs)
 	}
@@ -187,7 +209,7 @@ func (variable *ExprVariable) emitSave24(offset int) {
 	variable.emitOffsetSavePrimitive(8, offset+0, true)
 }
 
-func (e *ExprIndex) emitArrayOrSliceSavePrimitive(offset int) {
+func (e *ExprIndex) emitArrayOrSliceSavePrimitive(size int, offset int) {
 	collection := e.collection
 	index := e.index
 	collectionType := collection.getGtype()
@@ -199,10 +221,7 @@ func (e *ExprIndex) emitArrayOrSliceSavePrimitive(offset int) {
 
 	emit("PUSH_8 # rhs")
 
-	collection.emit()
-	emit("PUSH_8 # addr")
-
-	index.emit()
+	emitHeadAndCheckedIndex(collection, index)
 	emit("IMUL_NUMBER %d # index * elmSize", elmSize)
 	emit("PUSH_8")
 
@@ -210,10 +229,6 @@ func (e *ExprIndex) emitArrayOrSliceSavePrimitive(offset int) {
 	emit("ADD_NUMBER %d # offset", offset)
 	emit("PUSH_8")
 
-	if elmSize == 1 {
-		emit("STORE_1_INDIRECT_FROM_STACK")
-	} else {
-		emit("STORE_8_INDIRECT_FROM_STACK")
-	}
+	emit("STORE_%d_INDIRECT_FROM_STACK", size)
 	emitNewline()
 }
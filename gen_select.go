@@ -0,0 +1,193 @@
+// gen_select handles select statements
+package main
+
+// A select statement is lowered to a call of iruntime.selectgo.
+// Each case is passed to the runtime as iruntime.scase:
+//   [ channel, direction, address of the element buffer ]
+// The runtime executes the chosen case and returns its index.
+// A received value is then loaded from the buffer of the case.
+
+const selectCaseRecv = 1
+const selectCaseSend = 2
+const sizeOfScase = 24
+
+func (clause *CommClause) walk(s *StmtSelect) {
+	var ch Expr
+	switch clause.comm.(type) {
+	case *StmtSend:
+		clause.send = clause.comm.(*StmtSend)
+	case *StmtExpr:
+		ch = clause.comm.(*StmtExpr).expr
+	case *StmtAssignment:
+		assignment := clause.comm.(*StmtAssignment)
+		if len(assignment.rights) == 1 {
+			ch = assignment.rights[0]
+		}
+	case *StmtShortVarDecl:
+		decl := clause.comm.(*StmtShortVarDecl)
+		if len(decl.rights) == 1 {
+			ch = decl.rights[0]
+		}
+	}
+	if clause.send == nil {
+		recv, ok := ch.(*ExprChanRecv)
+		if !ok {
+			errorft(clause.token(), "select case must be receive, send or assign recv")
+		}
+		if isWideElement(recv.getGtype()) {
+			clause.buf = newTempVariable(clause.token(), recv.getGtype())
+		}
+		recv.selectBuf = clause.buf
+		recv.selectOk = s.okvar
+		clause.recv = recv
+	}
+	clause.comm = walkStmt(clause.comm)
+	clause.compound = walkStmtList(clause.compound)
+}
+
+func (clause *CommClause) token() *Token {
+	return clause.tok
+}
+
+// set the i-th case to the runtime case array
+func (s *StmtSelect) emitScase(i int, ch Expr, dir int, buf *ExprVariable) {
+	offset := i * sizeOfScase
+	ch.emit()
+	emit("PUSH_8 # channel")
+	buf.emitAddress(0)
+	emit("PUSH_8 # elem")
+	s.scases.emitAddress(0)
+	emit("popq %%rcx # elem")
+	emit("movq %%rcx, %d(%%rax)", offset+16)
+	emit("popq %%rcx # channel")
+	emit("movq %%rcx, %d(%%rax)", offset)
+	emit("movq $%d, %d(%%rax) # dir", dir, offset+8)
+}
+
+func (s *StmtSelect) emit() {
+	emit("# select")
+	// Evaluate all the channels and values to send in source order
+	for i, clause := range s.cases {
+		emit("# select case %d", i)
+		if clause.send != nil {
+			elementType := clause.send.elementType()
+			if clause.send.buf != nil {
+				// a wide element
+				emitAssignOne(clause.send.buf, clause.send.value)
+				s.emitScase(i, clause.send.ch, selectCaseSend, clause.send.buf)
+				continue
+			}
+			emitChanValue(clause.send.value, elementType)
+			if elementType.is24WidthType() {
+				emit("PUSH_24")
+				clause.buf.emitAddress(0)
+				emit("popq %%rcx")
+				emit("movq %%rcx, 16(%%rax)")
+				emit("popq %%rcx")
+				emit("movq %%rcx, 8(%%rax)")
+				emit("popq %%rcx")
+				emit("movq %%rcx, 0(%%rax)")
+			} else {
+				emit("PUSH_8")
+				clause.buf.emitAddress(0)
+				emit("popq %%rcx")
+				emit("movq %%rcx, 0(%%rax)")
+			}
+			s.emitScase(i, clause.send.ch, selectCaseSend, clause.buf)
+		} else {
+			if isWideElement(clause.recv.getGtype()) {
+				emitAssignOne(clause.buf, nil) // clear the buffer
+			} else {
+				clause.buf.emitAddress(0)
+				emit("movq $0, 0(%%rax) # clear the buffer")
+				emit("movq $0, 8(%%rax)")
+				emit("movq $0, 16(%%rax)")
+			}
+			s.emitScase(i, clause.recv.ch, selectCaseRecv, clause.buf)
+		}
+	}
+
+	if len(s.cases) == 0 {
+		emit("LOAD_NUMBER 0")
+	} else {
+		s.scases.emitAddress(0)
+	}
+	emit("PUSH_8 # cases")
+	emit("LOAD_NUMBER %d", len(s.cases))
+	emit("PUSH_8 # ncases")
+	if s.dflt == nil {
+		emit("LOAD_NUMBER 1")
+	} else {
+		emit("LOAD_NUMBER 0")
+	}
+	emit("PUSH_8 # blocking")
+	emit("POP_TO_ARG_2")
+	emit("POP_TO_ARG_1")
+	emit("POP_TO_ARG_0")
+	emit("FUNCALL %s", getFuncSymbol(IRuntimePath, "selectgo"))
+
+	emit("PUSH_8 # chosen index")
+	emit("movq %%rbx, %%rax # ok")
+	emitSavePrimitive(s.okvar)
+	emit("POP_8 # chosen index")
+
+	labelEnd := makeLabel()
+	lbls := s.labels
+	lbls.labelEndLoop = labelEnd
+	var labels []string
+	for i := range s.cases {
+		label := makeLabel()
+		labels = append(labels, label)
+		emit("cmpq $%d, %%rax", i)
+		emit("je %s", label)
+	}
+	if s.dflt != nil {
+		emit("# default case clause")
+		s.dflt.emit()
+	}
+	emit("jmp %s", labelEnd)
+
+	for i, clause := range s.cases {
+		emit("%s: # select case %d", labels[i], i)
+		if clause.recv != nil {
+			// load the received value
+			clause.comm.emit()
+		}
+		clause.compound.emit()
+		emit("jmp %s", labelEnd)
+	}
+	emit("%s: # end of select", labelEnd)
+}
+
+// load the value received by select
+func (e *ExprChanRecv) emitSelectedValue() {
+	emit("# value received by select")
+	is24Width := e.getGtype().is24WidthType()
+	wide := isWideElement(e.getGtype())
+	e.selectOk.emit()
+	emit("movq %%rax, %%%s # ok", mapOkRegister(is24Width || wide))
+	e.selectBuf.emitAddress(0)
+	if wide {
+		// the address of the buffer
+		return
+	}
+	if is24Width {
+		emit("LOAD_24_BY_DEREF")
+	} else {
+		emit("LOAD_8_BY_DEREF")
+	}
+}
+
+func (s *StmtSelect) dump() {
+	debugf("select")
+	debugNest++
+	for _, clause := range s.cases {
+		clause.comm.dump()
+		clause.compound.dump()
+	}
+	if s.dflt != nil {
+		debugf("default")
+		s.dflt.dump()
+	}
+	debugNest--
+}
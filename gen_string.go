@@ -4,21 +4,20 @@ func emitEmptyString() {
 	emit("LOAD_EMPTY_SLICE")
 }
 
+// the number of bytes of a string literal escaped by escapeString
 func countStrlen(chars []byte) int {
 	var length int
-	var isInBackSlash bool
-
-	for _, c := range chars {
-		if !isInBackSlash && c == '\\' {
-			isInBackSlash = true
-			continue
-		} else if isInBackSlash && c == '\\' {
-			isInBackSlash = false
-			length++
-		} else {
-			isInBackSlash = false
-			length++
+	var i int
+	for i = 0; i < len(chars); i++ {
+		if chars[i] == '\\' {
+			if '0' <= chars[i+1] && chars[i+1] <= '7' {
+				// octal escape like \033
+				i = i + 3
+			} else {
+				i++
+			}
 		}
+		length++
 	}
 	return length
 }
@@ -118,3 +117,54 @@ func emitGoStringsEqualFromStack() {
 	}
 	call.emit()
 }
+
+// for i, r := range s { ... }
+// r is the code point decoded from the UTF-8 encoding at s[i].
+func (em *IrStmtRangeString) emit() {
+	emit("# for range string")
+	emit("LOAD_NUMBER 0")
+	emitSavePrimitive(em.next)
+
+	emit("%s: # begin loop ", em.labels.labelBegin)
+	// next < len(s)
+	cond := &ExprBinop{
+		tok:  em.tok,
+		op:   "<",
+		left: em.next,
+		right: &ExprLen{
+			tok: em.tok,
+			arg: em.rangeexpr,
+		},
+	}
+	cond.emit()
+	emit("cmpq $0, %%rax")
+	emit("je %s  # exit if no more bytes", em.labels.labelEndLoop)
+
+	em.next.emit()
+	emitSavePrimitive(em.indexvar)
+
+	var dummyVariable = &ExprVariable{
+		isVariadic: false,
+	}
+	// func decoderune(s string, k int) (int32, int)
+	call := &IrCall{
+		tok:    em.tok,
+		symbol: getFuncSymbol(IRuntimePath, "decoderune"),
+		args:   []Expr{em.rangeexpr, em.next},
+		callee: &DeclFunc{
+			params: []*ExprVariable{dummyVariable, dummyVariable},
+		},
+	}
+	call.emit()
+	emit("pushq %%rbx # the next index")
+	emitSavePrimitive(em.valuevar)
+	emit("popq %%rax # the next index")
+	emitSavePrimitive(em.next)
+
+	em.block.emit()
+	emit("%s: # end block", em.labels.labelEndBlock)
+	emitRenewCells(em.loopvars)
+
+	emit("jmp %s", em.labels.labelBegin)
+	emit("%s: # end loop", em.labels.labelEndLoop)
+}
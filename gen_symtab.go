@@ -0,0 +1,143 @@
+package main
+
+import (
+	"github.com/DQNEO/minigo/stdlib/strings"
+)
+
+// The function table maps a pc to a function and a source line.
+// The runtime uses it to print tracebacks. (see internal/runtime/symtab.go)
+
+type funcInfo struct {
+	symbol   string
+	endLabel string
+	name     string // qualified name like "main.(*T).f"
+	file     string
+	hasArgs  bool
+	lines    []*pcLine
+}
+
+// a line begins at the pc
+type pcLine struct {
+	label string
+	line  int
+}
+
+var funcInfos []*funcInfo
+var currentFuncInfo *funcInfo
+
+// "/stdlib/fmt" => "fmt"
+// "$GOPATH/src/github.com/foo/bar" => "github.com/foo/bar"
+func qualifiedPkgPath(pkgPath normalizedPackagePath) string {
+	s := string(pkgPath)
+	if strings.HasPrefix(s, "/stdlib/") {
+		return s[len("/stdlib/"):]
+	}
+	gopathSrc := getGOPATH() + "/src/"
+	if strings.HasPrefix(s, gopathSrc) {
+		return s[len(gopathSrc):]
+	}
+	return s
+}
+
+// the function name as the official toolchain shows
+func (f *DeclFunc) qualifiedName() string {
+	pkg := qualifiedPkgPath(f.pkgPath)
+	if f.receiver != nil {
+		gtype := f.receiver.gtype
+		if gtype.kind == G_POINTER {
+			return Sprintf("%s.(*%s).%s", pkg, gtype.origType.relation.name, f.fname)
+		}
+		return Sprintf("%s.%s.%s", pkg, gtype.relation.name, f.fname)
+	}
+	return Sprintf("%s.%s", pkg, f.fname)
+}
+
+func beginFuncInfo(f *DeclFunc) {
+	assert(currentFuncInfo == nil, f.token(), "functions should not be nested")
+	fi := &funcInfo{
+		symbol:   f.getSymbol(),
+		endLabel: makeLabel(),
+		name:     f.qualifiedName(),
+		file:     f.token().filename,
+		hasArgs:  f.receiver != nil || len(f.params) > 0,
+	}
+	fi.lines = append(fi.lines, &pcLine{
+		label: fi.symbol,
+		line:  f.token().line,
+	})
+	funcInfos = append(funcInfos, fi)
+	currentFuncInfo = fi
+}
+
+func endFuncInfo() {
+	emitWithoutIndent("%s: # end of %s", currentFuncInfo.endLabel, currentFuncInfo.symbol)
+	currentFuncInfo = nil
+}
+
+// mark the pc where the code of a line begins
+func emitPcLine(tok *Token) {
+	fi := currentFuncInfo
+	if fi == nil || tok == nil || tok.filename != fi.file {
+		return
+	}
+	last := fi.lines[len(fi.lines)-1]
+	if last.line == tok.line {
+		return
+	}
+	label := makeLabel()
+	emitWithoutIndent("%s: # line %d", label, tok.line)
+	fi.lines = append(fi.lines, &pcLine{
+		label: label,
+		line:  tok.line,
+	})
+}
+
+// functab is an array of
+// [entry, end, name, file, hasArgs, lines, nlines]
+// and lines is an array of [pc, line].
+func emitFuncTable() {
+	emitWithoutIndent("#--------------------------------------------------------")
+	emit("# Function table")
+	emit(".data 0")
+	emit(".quad %d # the number of functions", len(funcInfos))
+	emitWithoutIndent("%s:", "functab")
+	var files []string
+	for i, fi := range funcInfos {
+		fileId := -1
+		for j, file := range files {
+			if file == fi.file {
+				fileId = j
+			}
+		}
+		if fileId < 0 {
+			fileId = len(files)
+			files = append(files, fi.file)
+		}
+		var hasArgs int
+		if fi.hasArgs {
+			hasArgs = 1
+		}
+		emit(".quad %s # entry", fi.symbol)
+		emit(".quad %s # end", fi.endLabel)
+		emit(".quad .F.name.%d", i)
+		emit(".quad .F.file.%d", fileId)
+		emit(".quad %d # hasArgs", hasArgs)
+		emit(".quad .F.lines.%d", i)
+		emit(".quad %d # nlines", len(fi.lines))
+	}
+
+	for i, fi := range funcInfos {
+		emitWithoutIndent(".F.name.%d:", i)
+		emit(".string \"%s\"", fi.name)
+		emitWithoutIndent(".p2align 3")
+		emitWithoutIndent(".F.lines.%d:", i)
+		for _, l := range fi.lines {
+			emit(".quad %s", l.label)
+			emit(".quad %d", l.line)
+		}
+	}
+	for i, file := range files {
+		emitWithoutIndent(".F.file.%d:", i)
+		emit(".string \"%s\"", file)
+	}
+}
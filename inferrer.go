@@ -48,6 +48,10 @@ func (decl *DeclVar) infer() {
 	decl.variable.gtype = gtype
 }
 
+func (c *Capture) infer() {
+	c.inner.gtype = c.outer.gtype
+}
+
 func (clause *ForRangeClause) infer() {
 	//debugf(S("infering ForRangeClause"))
 	collectionType := clause.rangeexpr.getGtype()
@@ -61,8 +65,13 @@ func (clause *ForRangeClause) infer() {
 	switch collectionType.getKind() {
 	case G_ARRAY, G_SLICE:
 		indexType = gInt
+	case G_STRING:
+		indexType = gInt
 	case G_MAP:
 		indexType = collectionType.Underlying().mapKey
+	case G_CHAN:
+		// for v := range ch
+		indexType = collectionType.Underlying().elementType
 	default:
 		// @TODO consider map etc.
 		TBI(clause.tok, "unable to handle %d ", collectionType.getKind())
@@ -82,6 +91,9 @@ func (clause *ForRangeClause) infer() {
 			elementType = collectionType.Underlying().elementType
 		} else if collectionType.getKind() == G_MAP {
 			elementType = collectionType.Underlying().mapValue
+		} else if collectionType.getKind() == G_STRING {
+			// the code point
+			elementType = gInt32
 		} else {
 			errorft(clause.token(), "internal error")
 		}
@@ -97,9 +109,15 @@ func (ast *StmtShortVarDecl) infer() {
 		switch rightExpr.(type) {
 		case *ExprFuncallOrConversion:
 			fcallOrConversion := rightExpr.(*ExprFuncallOrConversion)
-			if fcallOrConversion.rel.gtype != nil {
+			if fcallOrConversion.typ != nil || fcallOrConversion.rel.gtype != nil {
 				// Conversion
-				rightTypes = append(rightTypes, fcallOrConversion.rel.gtype)
+				rightTypes = append(rightTypes, fcallOrConversion.getGtype())
+			} else if _, ok := fcallOrConversion.rel.expr.(*ExprFuncRef); !ok {
+				// call of a func value
+				rettypes := fcallOrConversion.getRettypes()
+				for _, gtype := range rettypes {
+					rightTypes = append(rightTypes, gtype)
+				}
 			} else {
 				fcall := fcallOrConversion
 				funcdef := fcall.getFuncDef()
@@ -109,7 +127,8 @@ func (ast *StmtShortVarDecl) infer() {
 				if funcdef == builtinLen {
 					rightTypes = append(rightTypes, gInt)
 				} else {
-					for _, gtype := range fcall.getFuncDef().rettypes {
+					rettypes := fcall.getRettypes()
+					for _, gtype := range rettypes {
 						rightTypes = append(rightTypes, gtype)
 					}
 				}
@@ -120,6 +139,16 @@ func (ast *StmtShortVarDecl) infer() {
 			for _, gtype := range rettypes {
 				rightTypes = append(rightTypes, gtype)
 			}
+		case *ExprFuncValueCall:
+			fcall := rightExpr.(*ExprFuncValueCall)
+			rettypes := fcall.getRettypes()
+			for _, gtype := range rettypes {
+				rightTypes = append(rightTypes, gtype)
+			}
+		case *ExprChanRecv:
+			e := rightExpr.(*ExprChanRecv)
+			rightTypes = append(rightTypes, e.getGtype())
+			rightTypes = append(rightTypes, e.getSecondGtype())
 		case *ExprTypeAssertion:
 			assertion := rightExpr.(*ExprTypeAssertion)
 			rightTypes = append(rightTypes, assertion.gtype)
@@ -138,20 +167,19 @@ func (ast *StmtShortVarDecl) infer() {
 			if rightExpr == nil {
 				errorft(ast.token(), "rightExpr is nil")
 			}
+			// the type is nil if the expression has an error,
+			// which is reported by the type checker
 			gtype := rightExpr.getGtype()
-			if gtype == nil {
-				errorft(ast.token(), "rightExpr %T gtype is nil", rightExpr)
-			}
 			//debugf(S("infered type %s"), gtype)
 			rightTypes = append(rightTypes, gtype)
 		}
 	}
 
-	if len(ast.lefts) > len(rightTypes) {
-		// @TODO this check is too loose.
-		errorft(ast.tok, "number of lhs and rhs does not match (%d <=> %d)", len(ast.lefts), len(rightTypes))
-	}
 	for i, e := range ast.lefts {
+		if i >= len(rightTypes) {
+			// reported by the type checker
+			break
+		}
 		rel := e.(*Relation) // a brand new rel
 		variable := rel.expr.(*ExprVariable)
 		rightType := rightTypes[i]
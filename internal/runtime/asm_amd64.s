@@ -1,8 +1,14 @@
 // start up routines
+// the data section begins here, which is a root of the garbage collector
+.data 0
+.p2align 3
+iruntime.dataStart:
+
 .text
   .global _start
 _start:
   movq %rsp,    %rbp # initial stack top addr
+  movq %rsp, iruntime.mainStackHi+0(%rip)
   movq 0(%rsp), %rdx # argc
   leaq 8(%rsp), %rsi # argv
   # get envp
@@ -18,6 +24,10 @@ _start:
 
 iruntime.rt0_go:
   callq iruntime.args
+  # set up the thread local storage of the main thread
+  leaq iruntime.m0(%rip), %rdi
+  movq %rdi, 24(%rdi) # m0.self
+  callq iruntime.settls
   jmp _init_packages
 
 iruntime.args:
@@ -0,0 +1,184 @@
+package runtime
+
+// Channels.
+// All channels are guarded by a single lock "chanlock".
+// Goroutines waiting on channels are parked in "chanwaitq",
+// and all of them are readied whenever the state of any channel changes.
+// An unbuffered channel is a buffer of one element
+// whose sender waits until the element is received.
+
+// The compiler knows the offsets of qcount and dataqsiz (see gen_chan.go)
+type hchan struct {
+	qcount      int // number of elements in the buffer
+	dataqsiz    int // capacity of the buffer
+	buf         uintptr
+	elemsize    int
+	sendx       int
+	recvx       int
+	closed      bool
+	sent        int // number of elements sent so far
+	recvd       int // number of elements received so far
+	recvwaiting int // number of receivers waiting for an element
+}
+
+var chanlock int
+var chanwaitq *g
+
+func makechan(elemsize int, size int) *hchan {
+	if size < 0 {
+		panic("makechan: size out of range")
+	}
+	c := &hchan{}
+	c.elemsize = elemsize
+	c.dataqsiz = size
+	c.buf = malloc(uintptr(elemsize * c.bufsize()))
+	return c
+}
+
+// wait until the state of some channel changes.
+// chanlock must be held.
+func chanwait() {
+	gp := getg()
+	gp.schedlink = chanwaitq
+	chanwaitq = gp
+	park(&chanlock)
+	lock(&chanlock)
+}
+
+// chanlock must be held.
+func chanbroadcast() {
+	for chanwaitq != nil {
+		gp := chanwaitq
+		chanwaitq = gp.schedlink
+		ready(gp)
+	}
+}
+
+func (c *hchan) bufsize() int {
+	if c.dataqsiz == 0 {
+		return 1
+	}
+	return c.dataqsiz
+}
+
+func (c *hchan) slot(i int) uintptr {
+	return c.buf + uintptr(i*c.elemsize)
+}
+
+// A sender waiting for receivers must be woken up
+// when the first receiver starts waiting.
+func (c *hchan) startRecvWaiting() {
+	c.recvwaiting++
+	if c.recvwaiting == 1 {
+		chanbroadcast()
+	}
+}
+
+func (c *hchan) endRecvWaiting() {
+	c.recvwaiting--
+}
+
+// put an element into the buffer.
+// The buffer must have room.
+// chanlock must be held.
+func (c *hchan) send(elem uintptr) {
+	if c.closed {
+		unlock(&chanlock)
+		panic("send on closed channel")
+	}
+	memmove(c.slot(c.sendx), elem, c.elemsize)
+	c.sendx++
+	if c.sendx == c.bufsize() {
+		c.sendx = 0
+	}
+	c.qcount++
+	c.sent++
+	ticket := c.sent
+	chanbroadcast()
+	if c.dataqsiz == 0 {
+		// wait for a receiver
+		for c.recvd < ticket {
+			chanwait()
+		}
+	}
+}
+
+// take an element from the buffer.
+// It returns false if the channel is closed and empty.
+// chanlock must be held.
+func (c *hchan) recv(dst uintptr) bool {
+	if c.qcount == 0 {
+		// closed
+		return false
+	}
+	memmove(dst, c.slot(c.recvx), c.elemsize)
+	c.recvx++
+	if c.recvx == c.bufsize() {
+		c.recvx = 0
+	}
+	c.qcount--
+	c.recvd++
+	chanbroadcast()
+	return true
+}
+
+func (c *hchan) canSend() bool {
+	return c.closed || c.qcount < c.bufsize()
+}
+
+func (c *hchan) canRecv() bool {
+	return c.closed || c.qcount > 0
+}
+
+// block forever
+func block() {
+	for {
+		park(nil)
+	}
+}
+
+// elem is the address of the value to send
+func chansend(c *hchan, elem uintptr) {
+	if c == nil {
+		block()
+	}
+	lock(&chanlock)
+	for !c.canSend() {
+		chanwait()
+	}
+	c.send(elem)
+	unlock(&chanlock)
+}
+
+// The received value is written to dst.
+// It returns false if the channel is closed and empty.
+func chanrecv(c *hchan, dst uintptr) bool {
+	if c == nil {
+		block()
+	}
+	lock(&chanlock)
+	if !c.canRecv() {
+		c.startRecvWaiting()
+		for !c.canRecv() {
+			chanwait()
+		}
+		c.endRecvWaiting()
+	}
+	ok := c.recv(dst)
+	unlock(&chanlock)
+	return ok
+}
+
+func closechan(c *hchan) {
+	if c == nil {
+		panic("close of nil channel")
+	}
+	lock(&chanlock)
+	if c.closed {
+		unlock(&chanlock)
+		panic("close of closed channel")
+	}
+	c.closed = true
+	chanbroadcast()
+	unlock(&chanlock)
+}
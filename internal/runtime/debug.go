@@ -9,7 +9,7 @@ func reportMemoryUsage() {
 }
 
 func getMemoryUsage() uintptr {
-	return heapCurrent - heapHead
+	return heapLive
 }
 
 // This is a copy from stconv
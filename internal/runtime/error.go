@@ -0,0 +1,161 @@
+package runtime
+
+// run-time panics
+
+// runtimeError is a run-time panic which is not about bounds
+type runtimeError struct {
+	msg string
+}
+
+func (e *runtimeError) Error() string {
+	return "runtime error: " + e.msg
+}
+
+func (e *runtimeError) RuntimeError() {
+}
+
+// plainError is a run-time panic whose message has no "runtime error: " prefix
+type plainError struct {
+	msg string
+}
+
+func (e *plainError) Error() string {
+	return e.msg
+}
+
+func (e *plainError) RuntimeError() {
+}
+
+// A boundsError represents an indexing or slicing operation gone wrong.
+type boundsError struct {
+	x    int
+	y    int
+	code int
+}
+
+const (
+	boundsIndex      = 0 // s[x], 0 <= x < len(s) failed
+	boundsSliceAlen  = 1 // s[?:x], 0 <= x <= len(s) failed
+	boundsSliceAcap  = 2 // s[?:x], 0 <= x <= cap(s) failed
+	boundsSliceB     = 3 // s[x:y], 0 <= x <= y failed
+	boundsSlice3Alen = 4 // s[?:?:x], 0 <= x <= len(s) failed
+	boundsSlice3Acap = 5 // s[?:?:x], 0 <= x <= cap(s) failed
+	boundsSlice3B    = 6 // s[?:x:y], 0 <= x <= y failed
+	boundsSlice3C    = 7 // s[x:y:?], 0 <= x <= y failed
+)
+
+var boundsErrorFmt []string = []string{
+	"index out of range [%x] with length %y",
+	"slice bounds out of range [:%x] with length %y",
+	"slice bounds out of range [:%x] with capacity %y",
+	"slice bounds out of range [%x:%y]",
+	"slice bounds out of range [::%x] with length %y",
+	"slice bounds out of range [::%x] with capacity %y",
+	"slice bounds out of range [:%x:%y]",
+	"slice bounds out of range [%x:%y:]",
+}
+
+// boundsNegErrorFmt are overriding formats if x is negative. In this case there's no need to report y.
+var boundsNegErrorFmt []string = []string{
+	"index out of range [%x]",
+	"slice bounds out of range [:%x]",
+	"slice bounds out of range [:%x]",
+	"slice bounds out of range [%x:]",
+	"slice bounds out of range [::%x]",
+	"slice bounds out of range [::%x]",
+	"slice bounds out of range [:%x:]",
+	"slice bounds out of range [%x::]",
+}
+
+func (e *boundsError) Error() string {
+	format := boundsErrorFmt[e.code]
+	if e.x < 0 {
+		format = boundsNegErrorFmt[e.code]
+	}
+	var buf []byte
+	buf = appendString(buf, "runtime error: ")
+	for i := 0; i < len(format); i++ {
+		if format[i] == '%' && i+1 < len(format) {
+			if format[i+1] == 'x' {
+				buf = appendInt(buf, e.x)
+				i++
+				continue
+			}
+			if format[i+1] == 'y' {
+				buf = appendInt(buf, e.y)
+				i++
+				continue
+			}
+		}
+		buf = append(buf, format[i])
+	}
+	return string(buf)
+}
+
+func (e *boundsError) RuntimeError() {
+}
+
+func appendInt(buf []byte, v int) []byte {
+	if v < 0 {
+		buf = append(buf, '-')
+		v = -v
+	}
+	var digits []byte = make([]byte, 20, 20)
+	i := len(digits)
+	for {
+		i--
+		digits[i] = byte('0') + byte(v%10)
+		v = v / 10
+		if v == 0 {
+			break
+		}
+	}
+	for ; i < len(digits); i++ {
+		buf = append(buf, digits[i])
+	}
+	return buf
+}
+
+// called by the code generated for x[i] (see CHECK_INDEX in macro.s)
+func panicIndex(x int, y int) {
+	panic(&boundsError{x: x, y: y, code: boundsIndex})
+}
+
+// called by the code generated for x[low:high:max] (see CHECK_SLICE in macro.s)
+// The capacity of a string or an array is its length.
+// A check fails when its upper side is below its lower side as unsigned integers.
+func panicSlice(low int, high int, max int, cap int, three bool, hasCap bool) {
+	if uint(max) > uint(cap) {
+		code := boundsSliceAlen
+		if three {
+			code = boundsSlice3Alen
+		}
+		if hasCap {
+			code++
+		}
+		panic(&boundsError{x: max, y: cap, code: code})
+	}
+	if three {
+		if uint(high) > uint(max) {
+			panic(&boundsError{x: high, y: max, code: boundsSlice3B})
+		}
+		panic(&boundsError{x: low, y: high, code: boundsSlice3C})
+	}
+	panic(&boundsError{x: low, y: high, code: boundsSliceB})
+}
+
+func panicdivide() {
+	panic(&runtimeError{msg: "integer divide by zero"})
+}
+
+func panicoverflow() {
+	panic(&runtimeError{msg: "integer overflow"})
+}
+
+func panicnilmap() {
+	panic(&plainError{msg: "assignment to entry in nil map"})
+}
+
+func panicmem() {
+	panic(&runtimeError{msg: "invalid memory address or nil pointer dereference"})
+}
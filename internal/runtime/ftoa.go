@@ -0,0 +1,441 @@
+package runtime
+
+import "unsafe"
+
+// Conversion of floats to strings.
+// A float is converted to its exact decimal representation, which is rounded afterwards.
+// The shortest representation is found in the same way as the standard library does.
+
+// a multi-precision decimal number
+type decimal struct {
+	d  []byte // digits
+	nd int    // number of digits used
+	dp int    // decimal point
+}
+
+// a shift is split into steps of maxShift bits so that intermediate values fit in an int
+const maxShift = 59
+
+func pow2(k int) int {
+	n := 1
+	for i := 0; i < k; i++ {
+		n = n * 2
+	}
+	return n
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func (a *decimal) assign(v int) {
+	var buf []byte
+	for v > 0 {
+		v1 := v / 10
+		buf = append(buf, byte(v-10*v1)+'0')
+		v = v1
+	}
+	var digits []byte
+	for i := len(buf) - 1; i >= 0; i-- {
+		digits = append(digits, buf[i])
+	}
+	a.d = digits
+	a.nd = len(digits)
+	a.dp = a.nd
+	a.trim()
+}
+
+// cut off trailing zeros
+func (a *decimal) trim() {
+	for a.nd > 0 && a.d[a.nd-1] == '0' {
+		a.nd--
+	}
+	if a.nd == 0 {
+		a.dp = 0
+	}
+}
+
+// multiply by 2^k
+func (a *decimal) leftShift(k int) {
+	m := pow2(k)
+	var rev []byte // digits in reverse order
+	n := 0
+	for r := a.nd - 1; r >= 0; r-- {
+		n = n + int(a.d[r]-'0')*m
+		quo := n / 10
+		rev = append(rev, byte(n-10*quo)+'0')
+		n = quo
+	}
+	for n > 0 {
+		quo := n / 10
+		rev = append(rev, byte(n-10*quo)+'0')
+		n = quo
+	}
+	var digits []byte
+	for i := len(rev) - 1; i >= 0; i-- {
+		digits = append(digits, rev[i])
+	}
+	a.dp = a.dp + len(digits) - a.nd
+	a.d = digits
+	a.nd = len(digits)
+	a.trim()
+}
+
+// divide by 2^k
+func (a *decimal) rightShift(k int) {
+	m := pow2(k)
+	r := 0
+	n := 0
+	// pick up enough leading digits
+	for n < m {
+		if r >= a.nd {
+			if n == 0 {
+				a.nd = 0
+				return
+			}
+			for n < m {
+				n = n * 10
+				r++
+			}
+			break
+		}
+		n = n*10 + int(a.d[r]-'0')
+		r++
+	}
+	a.dp = a.dp - (r - 1)
+
+	var digits []byte
+	for r < a.nd {
+		dig := n / m
+		n = n - dig*m
+		digits = append(digits, byte(dig)+'0')
+		n = n*10 + int(a.d[r]-'0')
+		r++
+	}
+	for n > 0 {
+		dig := n / m
+		n = n - dig*m
+		digits = append(digits, byte(dig)+'0')
+		n = n * 10
+	}
+	a.d = digits
+	a.nd = len(digits)
+	a.trim()
+}
+
+// multiply by 2^k, where k may be negative
+func (a *decimal) shift(k int) {
+	if a.nd == 0 {
+		return
+	}
+	for k > maxShift {
+		a.leftShift(maxShift)
+		k = k - maxShift
+	}
+	if k > 0 {
+		a.leftShift(k)
+	}
+	for k < -maxShift {
+		a.rightShift(maxShift)
+		k = k + maxShift
+	}
+	if k < 0 {
+		a.rightShift(-k)
+	}
+}
+
+func (a *decimal) shouldRoundUp(nd int) bool {
+	if a.d[nd] == '5' && nd+1 == a.nd {
+		// exactly halfway: round to even
+		return nd > 0 && int(a.d[nd-1]-'0')%2 == 1
+	}
+	return a.d[nd] >= '5'
+}
+
+// round to nd digits
+func (a *decimal) round(nd int) {
+	if nd < 0 || nd >= a.nd {
+		return
+	}
+	if a.shouldRoundUp(nd) {
+		a.roundUp(nd)
+	} else {
+		a.roundDown(nd)
+	}
+}
+
+func (a *decimal) roundDown(nd int) {
+	if nd < 0 || nd >= a.nd {
+		return
+	}
+	a.nd = nd
+	a.trim()
+}
+
+func (a *decimal) roundUp(nd int) {
+	if nd < 0 || nd >= a.nd {
+		return
+	}
+	for i := nd - 1; i >= 0; i-- {
+		c := a.d[i]
+		if c < '9' {
+			a.d[i] = c + 1
+			a.nd = i + 1
+			return
+		}
+	}
+	// all 9s
+	a.d[0] = '1'
+	a.nd = 1
+	a.dp++
+}
+
+func (a *decimal) digitAt(i int) byte {
+	if i >= 0 && i < a.nd {
+		return a.d[i]
+	}
+	return '0'
+}
+
+// round d (= mant * 2^(exp-mantbits)) to the shortest number of digits
+// that will let the original float be reconstructed exactly.
+func (d *decimal) roundShortest(mant int, exp int, mantbits int, minexp int) {
+	if mant == 0 {
+		d.nd = 0
+		return
+	}
+	// If mantissa scale is larger than decimal scale, d is already the shortest.
+	// log2(10) > 3.32
+	if exp > minexp && 332*(d.dp-d.nd) >= 100*(exp-mantbits) {
+		return
+	}
+
+	// the upper and lower bounds of the values which round to the float
+	upper := &decimal{}
+	upper.assign(mant*2 + 1)
+	upper.shift(exp - mantbits - 1)
+
+	var mantlo int
+	var explo int
+	if mant > pow2(mantbits) || exp == minexp {
+		mantlo = mant - 1
+		explo = exp
+	} else {
+		mantlo = mant*2 - 1
+		explo = exp - 1
+	}
+	lower := &decimal{}
+	lower.assign(mantlo*2 + 1)
+	lower.shift(explo - mantbits - 1)
+
+	// the bounds are inclusive when the mantissa is even
+	inclusive := mant%2 == 0
+
+	// 0: upper and d share the digits so far
+	// 1: upper is greater than d by one at the last digit and only 0s follow in upper
+	// 2: upper is sufficiently greater than d
+	var upperdelta int
+	for ui := 0; ui-upper.dp+d.dp < d.nd; ui++ {
+		mi := ui - upper.dp + d.dp
+		li := ui - upper.dp + lower.dp
+		l := lower.digitAt(li)
+		m := d.digitAt(mi)
+		u := upper.digitAt(ui)
+
+		// d can be rounded down if lower differs from d or is inclusive and ends here
+		okdown := l != m || (inclusive && li+1 == lower.nd)
+
+		if upperdelta == 0 && m+1 < u {
+			upperdelta = 2
+		} else if upperdelta == 0 && m != u {
+			upperdelta = 1
+		} else if upperdelta == 1 && (m != '9' || u != '0') {
+			upperdelta = 2
+		}
+		// d can be rounded up if upper is sufficiently greater
+		okup := upperdelta > 0 && (inclusive || upperdelta > 1 || ui+1 < upper.nd)
+
+		if okdown && okup {
+			d.round(mi + 1)
+			return
+		} else if okdown {
+			d.roundDown(mi + 1)
+			return
+		} else if okup {
+			d.roundUp(mi + 1)
+			return
+		}
+	}
+}
+
+// ftoa implements strconv.FormatFloat.
+// It is in the runtime to print floats in panic messages.
+func ftoa(f float64, format byte, prec int, bitSize int) string {
+	var p *uintptr = (*uintptr)(unsafe.Pointer(&f))
+	bits := *p
+	signexp := bits / 4503599627370496 // 2^52
+	neg := signexp >= 2048
+	exp := int(signexp % 2048)
+	mant := int(bits % 4503599627370496)
+
+	if exp == 2047 {
+		if mant != 0 {
+			return "NaN"
+		}
+		if neg {
+			return "-Inf"
+		}
+		return "+Inf"
+	}
+	if exp == 0 {
+		// denormal
+		exp = 1
+	} else {
+		mant = mant + 4503599627370496
+	}
+	exp = exp - 1023
+	mantbits := 52
+	minexp := -1022
+	if bitSize == 32 {
+		// f is exactly a float32 value
+		mantbits = 23
+		minexp = -126
+		shift := 29
+		if exp < minexp {
+			shift = shift + minexp - exp
+			exp = minexp
+		}
+		if shift > 60 {
+			// zero
+			mant = 0
+		} else {
+			mant = mant / pow2(shift)
+		}
+	}
+
+	d := &decimal{}
+	d.assign(mant)
+	d.shift(exp - mantbits)
+
+	shortest := prec < 0
+	if shortest {
+		d.roundShortest(mant, exp, mantbits, minexp)
+		switch format {
+		case 'e':
+			prec = maxInt(d.nd-1, 0)
+		case 'f':
+			prec = maxInt(d.nd-d.dp, 0)
+		case 'g':
+			prec = d.nd
+		}
+	} else {
+		switch format {
+		case 'e':
+			d.round(prec + 1)
+		case 'f':
+			d.round(d.dp + prec)
+		case 'g':
+			if prec == 0 {
+				prec = 1
+			}
+			d.round(prec)
+		}
+	}
+	return string(formatDigits(neg, d, shortest, prec, format))
+}
+
+func formatDigits(neg bool, d *decimal, shortest bool, prec int, format byte) []byte {
+	switch format {
+	case 'e':
+		return fmtE(neg, d, prec)
+	case 'f':
+		return fmtF(neg, d, prec)
+	case 'g':
+		eprec := prec
+		if eprec > d.nd && d.nd >= d.dp {
+			eprec = d.nd
+		}
+		// %e is used if the exponent from the conversion
+		// is less than -4 or greater than or equal to the precision.
+		// if precision was the shortest possible, use precision 6 for this decision.
+		if shortest {
+			eprec = 6
+		}
+		exp := d.dp - 1
+		if exp < -4 || exp >= eprec {
+			if prec > d.nd {
+				prec = d.nd
+			}
+			return fmtE(neg, d, prec-1)
+		}
+		if prec > d.dp {
+			prec = d.nd
+		}
+		return fmtF(neg, d, maxInt(prec-d.dp, 0))
+	}
+	// unknown format
+	return []byte{'%', format}
+}
+
+// -d.ddddde±dd
+func fmtE(neg bool, d *decimal, prec int) []byte {
+	var dst []byte
+	if neg {
+		dst = append(dst, '-')
+	}
+	dst = append(dst, d.digitAt(0))
+	if prec > 0 {
+		dst = append(dst, '.')
+		for i := 1; i <= prec; i++ {
+			dst = append(dst, d.digitAt(i))
+		}
+	}
+	dst = append(dst, 'e')
+	exp := d.dp - 1
+	if d.nd == 0 {
+		exp = 0
+	}
+	if exp < 0 {
+		dst = append(dst, '-')
+		exp = -exp
+	} else {
+		dst = append(dst, '+')
+	}
+	if exp < 10 {
+		dst = append(dst, '0')
+		dst = append(dst, byte(exp)+'0')
+	} else if exp < 100 {
+		dst = append(dst, byte(exp/10)+'0')
+		dst = append(dst, byte(exp%10)+'0')
+	} else {
+		dst = append(dst, byte(exp/100)+'0')
+		dst = append(dst, byte((exp/10)%10)+'0')
+		dst = append(dst, byte(exp%10)+'0')
+	}
+	return dst
+}
+
+// -ddd.ddddd
+func fmtF(neg bool, d *decimal, prec int) []byte {
+	var dst []byte
+	if neg {
+		dst = append(dst, '-')
+	}
+	if d.dp > 0 {
+		for i := 0; i < d.dp; i++ {
+			dst = append(dst, d.digitAt(i))
+		}
+	} else {
+		dst = append(dst, '0')
+	}
+	if prec > 0 {
+		dst = append(dst, '.')
+		for i := 1; i <= prec; i++ {
+			dst = append(dst, d.digitAt(d.dp+i-1))
+		}
+	}
+	return dst
+}
@@ -1,4 +1,4 @@
 // Declarations only. Minigo won't parse this file.
 package runtime
 
-func Syscall(num uintptr, u uintptr, u2 uintptr, u3 uintptr) uintptr
+func clone(flags int, stk uintptr, mstart uintptr, mp uintptr) int
@@ -0,0 +1,44 @@
+package runtime
+
+import "unsafe"
+
+const __x64_sys_sched_yield = 24
+const __x64_sys_futex = 202
+
+const _FUTEX_WAIT = 0
+const _FUTEX_WAKE = 1
+
+const maxInt32 = 0x7fffffff
+
+// implemented in runtime.s
+func cas(addr *int, old int, new int) bool
+func xadd(addr *uintptr, delta uintptr) uintptr
+func memmove(to uintptr, from uintptr, n int)
+func rawSyscall(num uintptr, a1 uintptr, a2 uintptr, a3 uintptr) uintptr
+
+func osyield() {
+	rawSyscall(__x64_sys_sched_yield, 0, 0, 0)
+}
+
+// spin lock
+func lock(l *int) {
+	for !cas(l, 0, 1) {
+		osyield()
+	}
+	getm().locks++
+}
+
+func unlock(l *int) {
+	getm().locks--
+	*l = 0
+}
+
+// sleep while *addr == val
+func futexsleep(addr *int, val int) {
+	rawSyscall(__x64_sys_futex, uintptr(unsafe.Pointer(addr)), _FUTEX_WAIT, uintptr(val))
+}
+
+// wake up at most n threads sleeping on addr
+func futexwakeup(addr *int, n int) {
+	rawSyscall(__x64_sys_futex, uintptr(unsafe.Pointer(addr)), _FUTEX_WAKE, uintptr(n))
+}
@@ -1,25 +1,396 @@
 package runtime
 
-// Stupid memory allocator.
+import "unsafe"
+
+// Memory allocator.
+//
+// The heap is an arena of pages in the brk region.
+// A span is a run of pages, which holds either objects of one size class
+// or a single large object.
+// Free slots of a span are linked through their first words.
+// Each slot has a state byte, which the garbage collector uses as the mark bit (see mgc.go).
+// Metadata of the heap lives in the persistent area, which is neither freed nor scanned.
 
 const heapSize uintptr = 640485760
+const persistentSize uintptr = 67108864
+
+const pageSize uintptr = 8192
+const maxSmallSize uintptr = 32768
+const numSizeClasses = 189
+
+// typ of mallocgc. Otherwise typ is the address of a pointer map emitted by the compiler.
+const typConservative uintptr = 0 // may contain pointers anywhere
+const typNoscan uintptr = 1       // contains no pointers
+
+// state of a slot
+const slotFree = 0
+const slotAllocated = 1
+const slotMarked = 2
+
+const sizeOfMspan uintptr = 112 // 14 words
+
+type mspan struct {
+	base       uintptr
+	npages     uintptr
+	elemsize   uintptr
+	nelems     uintptr
+	typ        uintptr
+	state      uintptr // address of state bytes
+	freelist   uintptr
+	nfree      uintptr
+	inuse      int
+	owner      uintptr // address of the head of the list of partial spans
+	next       *mspan  // in the list of partial spans or free spans
+	prev       *mspan  // in the list of free spans
+	allnext    *mspan  // in allspans
+	largestate int     // state of a large object
+}
+
+var arenaStart uintptr
+var arenaUsed uintptr
+var arenaEnd uintptr
+var persistentCurrent uintptr
+var persistentTail uintptr
+
+var heaplock int
+var pagetable uintptr // *mspan for each page
+var allspans *mspan   // spans in use
+var freespans *mspan
+var spanpool *mspan // unused mspan structs
 
-var heapHead uintptr
-var heapCurrent uintptr
-var heapTail uintptr
+// partial spans of each size class: conservative ones followed by noscan ones
+var smallspans [378]*mspan
+
+var heapLive uintptr // bytes of allocated slots
 
 func heapInit() {
-	heapHead = brk(0)
-	heapTail = brk(heapHead + heapSize)
-	heapCurrent = heapHead
+	head := brk(0)
+	tail := brk(head + persistentSize + heapSize)
+	persistentCurrent = head
+	persistentTail = head + persistentSize
+	arenaStart = ((persistentTail + pageSize - 1) / pageSize) * pageSize
+	arenaEnd = tail
+	arenaUsed = arenaStart
+	pagetable = persistentalloc((heapSize / pageSize) * 8)
+	markstackInit()
 }
 
-func malloc(size uintptr) uintptr {
-	if heapCurrent+size > heapTail {
-		panic([]byte("malloc exceeds heap capacity"))
-		return 0
+// allocate memory which is never freed nor scanned
+func persistentalloc(size uintptr) uintptr {
+	size = ((size + 7) / 8) * 8 // keep 8 byte alignment
+	r := xadd(&persistentCurrent, size)
+	if r+size > persistentTail {
+		throw("out of persistent memory")
 	}
-	r := heapCurrent
-	heapCurrent += size
 	return r
 }
+
+func sizeToClass(size uintptr) int {
+	if size <= 8 {
+		return 0
+	}
+	if size <= 1024 {
+		return int((size + 15) / 16)
+	}
+	return 64 + int((size-1024+255)/256)
+}
+
+func classToSize(class int) uintptr {
+	if class == 0 {
+		return 8
+	}
+	if class <= 64 {
+		return uintptr(class * 16)
+	}
+	return uintptr(1024 + (class-64)*256)
+}
+
+func pageIndex(p uintptr) uintptr {
+	return (p - arenaStart) / pageSize
+}
+
+func pageEntry(i uintptr) **mspan {
+	return (**mspan)(unsafe.Pointer(pagetable + i*8))
+}
+
+// the span which contains p, or nil
+func spanOf(p uintptr) *mspan {
+	if p < arenaStart || p >= arenaUsed {
+		return nil
+	}
+	s := *pageEntry(pageIndex(p))
+	if s == nil || s.inuse == 0 {
+		return nil
+	}
+	if p < s.base || p >= s.base+s.npages*pageSize {
+		return nil
+	}
+	return s
+}
+
+func listhead(owner uintptr) *mspan {
+	var head **mspan = (**mspan)(unsafe.Pointer(owner))
+	return *head
+}
+
+func setlisthead(owner uintptr, s *mspan) {
+	var head **mspan = (**mspan)(unsafe.Pointer(owner))
+	*head = s
+}
+
+func newmspan() *mspan {
+	s := spanpool
+	if s != nil {
+		spanpool = s.next
+	} else {
+		s = (*mspan)(unsafe.Pointer(persistentalloc(sizeOfMspan)))
+	}
+	memclr(uintptr(unsafe.Pointer(s)), int(sizeOfMspan))
+	return s
+}
+
+// The page table may still point to s.
+// Clear it so that it looks like neither a span in use nor a free one.
+func freemspan(s *mspan) {
+	memclr(uintptr(unsafe.Pointer(s)), int(sizeOfMspan))
+	s.next = spanpool
+	spanpool = s
+}
+
+func (s *mspan) limit() uintptr {
+	return s.base + s.npages*pageSize
+}
+
+// register a free span to the page table and the list of free spans
+func (s *mspan) insertFree() {
+	s.inuse = 0
+	first := pageEntry(pageIndex(s.base))
+	*first = s
+	last := pageEntry(pageIndex(s.limit()) - 1)
+	*last = s
+	s.prev = nil
+	s.next = freespans
+	if freespans != nil {
+		freespans.prev = s
+	}
+	freespans = s
+}
+
+func (s *mspan) removeFree() {
+	if s.prev == nil {
+		freespans = s.next
+	} else {
+		s.prev.next = s.next
+	}
+	if s.next != nil {
+		s.next.prev = s.prev
+	}
+	s.next = nil
+	s.prev = nil
+}
+
+// allocate a span of npages. heaplock must be held.
+func allocspan(npages uintptr) *mspan {
+	var s *mspan
+	for t := freespans; t != nil; t = t.next {
+		if t.npages >= npages {
+			s = t
+			break
+		}
+	}
+	if s != nil {
+		s.removeFree()
+		if s.npages > npages {
+			rest := newmspan()
+			rest.base = s.base + npages*pageSize
+			rest.npages = s.npages - npages
+			rest.insertFree()
+			s.npages = npages
+		}
+	} else {
+		size := npages * pageSize
+		if arenaUsed+size > arenaEnd {
+			return nil
+		}
+		s = newmspan()
+		s.base = arenaUsed
+		s.npages = npages
+		arenaUsed = arenaUsed + size
+	}
+	s.inuse = 1
+	first := pageIndex(s.base)
+	for i := uintptr(0); i < npages; i++ {
+		entry := pageEntry(first + i)
+		*entry = s
+	}
+	s.allnext = allspans
+	allspans = s
+	return s
+}
+
+// return the pages of a span, merging it with the neighbor free spans.
+// heaplock must be held.
+func freespan(s *mspan) {
+	s.inuse = 0
+	if s.limit() < arenaUsed {
+		t := *pageEntry(pageIndex(s.limit()))
+		if t != nil && t.inuse == 0 && t.base == s.limit() {
+			t.removeFree()
+			s.npages = s.npages + t.npages
+			freemspan(t)
+		}
+	}
+	if s.base > arenaStart {
+		t := *pageEntry(pageIndex(s.base) - 1)
+		if t != nil && t.inuse == 0 && t.limit() == s.base {
+			t.removeFree()
+			t.npages = t.npages + s.npages
+			freemspan(s)
+			s = t
+		}
+	}
+	s.insertFree()
+}
+
+func (s *mspan) stateOf(i uintptr) *byte {
+	return (*byte)(unsafe.Pointer(s.state + i))
+}
+
+// build the free list of a span
+func (s *mspan) initSlots() {
+	memclr(s.state, int(s.nelems))
+	s.freelist = 0
+	s.nfree = 0
+	for i := int(s.nelems) - 1; i >= 0; i-- {
+		slot := s.base + uintptr(i)*s.elemsize
+		var link *uintptr = (*uintptr)(unsafe.Pointer(slot))
+		*link = s.freelist
+		s.freelist = slot
+		s.nfree++
+	}
+}
+
+func newSmallSpan(class int, typ uintptr, owner uintptr) *mspan {
+	elemsize := classToSize(class)
+	npages := uintptr(1)
+	if elemsize > 1024 {
+		npages = (elemsize*8 + pageSize - 1) / pageSize
+	}
+	s := allocspan(npages)
+	if s == nil {
+		return nil
+	}
+	s.elemsize = elemsize
+	// state bytes are placed at the end of the span
+	s.nelems = (npages * pageSize) / (elemsize + 1)
+	s.state = s.limit() - s.nelems
+	s.typ = typ
+	s.owner = owner
+	s.initSlots()
+	return s
+}
+
+// the list of partial spans for objects of the class and typ
+func spanOwner(class int, typ uintptr) uintptr {
+	if typ == typConservative {
+		return uintptr(unsafe.Pointer(&smallspans[class]))
+	}
+	if typ == typNoscan {
+		return uintptr(unsafe.Pointer(&smallspans[numSizeClasses+class]))
+	}
+	// the first word of a pointer map is reserved for its spans
+	return typ
+}
+
+// heaplock must be held.
+func mallocSmall(size uintptr, typ uintptr) uintptr {
+	class := sizeToClass(size)
+	owner := spanOwner(class, typ)
+	s := listhead(owner)
+	for s != nil && s.nfree == 0 {
+		s = s.next
+		setlisthead(owner, s)
+	}
+	if s == nil {
+		s = newSmallSpan(class, typ, owner)
+		if s == nil {
+			return 0
+		}
+		setlisthead(owner, s)
+	}
+	if s.elemsize != classToSize(class) {
+		throw("malloc: pointer map used for different sizes")
+	}
+	p := s.freelist
+	var link *uintptr = (*uintptr)(unsafe.Pointer(p))
+	s.freelist = *link
+	s.nfree--
+	st := s.stateOf((p - s.base) / s.elemsize)
+	*st = slotAllocated
+	heapLive = heapLive + s.elemsize
+	return p
+}
+
+// heaplock must be held.
+func mallocLarge(size uintptr, typ uintptr) uintptr {
+	npages := (size + pageSize - 1) / pageSize
+	s := allocspan(npages)
+	if s == nil {
+		return 0
+	}
+	s.elemsize = npages * pageSize
+	s.nelems = 1
+	s.state = uintptr(unsafe.Pointer(&s.largestate))
+	s.largestate = slotAllocated
+	s.typ = typ
+	heapLive = heapLive + s.elemsize
+	return s.base
+}
+
+// size of the slot for an object
+func roundupsize(size uintptr) uintptr {
+	if size > maxSmallSize {
+		return ((size + pageSize - 1) / pageSize) * pageSize
+	}
+	return classToSize(sizeToClass(size))
+}
+
+func mallocLocked(size uintptr, typ uintptr) uintptr {
+	if size > maxSmallSize {
+		return mallocLarge(size, typ)
+	}
+	return mallocSmall(size, typ)
+}
+
+// allocate a zeroed object.
+func mallocgc(size uintptr, typ uintptr) uintptr {
+	if size == 0 {
+		size = 8
+	}
+	if gcpercent >= 0 && heapLive >= nextGC && canGC() {
+		gc()
+	}
+	lock(&heaplock)
+	p := mallocLocked(size, typ)
+	unlock(&heaplock)
+	if p == 0 && canGC() {
+		gc()
+		lock(&heaplock)
+		p = mallocLocked(size, typ)
+		unlock(&heaplock)
+	}
+	if p == 0 {
+		throw("out of memory")
+	}
+	memclr(p, int(roundupsize(size)))
+	return p
+}
+
+// malloc is called from multiple threads.
+func malloc(size uintptr) uintptr {
+	return mallocgc(size, typConservative)
+}
+
+func mallocNoscan(size uintptr) uintptr {
+	return mallocgc(size, typNoscan)
+}
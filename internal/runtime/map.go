@@ -0,0 +1,316 @@
+package runtime
+
+import "unsafe"
+
+// Maps.
+// A map keeps its entries in an array in the order of insertion,
+// and finds them through an open addressing hash table of entry numbers.
+// A deleted entry and its slot in the hash table are left as tombstones,
+// which are cleaned up when the entries are rehashed.
+// Keys and values are passed by their addresses (see gen_map.go).
+//
+// An entry is laid out as
+//   [ key (keysize), value (valsize), sequence number ]
+// Sequence numbers increase in the order of insertion.
+// The sequence number of a deleted entry is negated.
+
+const mapKeyWord = 0   // compared as a word: int, uintptr, byte, pointer and so on
+const mapKeyString = 1 // compared by contents
+
+// values of a slot in the hash table. Otherwise an entry number + slotEntryBase
+const slotEmpty uintptr = 0
+const slotDeleted uintptr = 1
+const slotEntryBase uintptr = 2
+
+const minMapEntries uintptr = 8
+
+// The compiler knows the offset of count (see gen_map.go)
+type hmap struct {
+	count      int // number of live entries
+	keykind    int
+	keysize    uintptr
+	valsize    uintptr
+	entrysize  uintptr
+	entries    uintptr
+	nentries   uintptr // number of used entries including deleted ones
+	maxentries uintptr
+	slots      uintptr // hash table
+	nslots     uintptr
+	seq        int // sequence number of the last inserted entry
+	generation int // incremented when the entries are rehashed
+}
+
+// state of for range over a map
+type hiter struct {
+	m          *hmap
+	i          uintptr // entry number to visit next
+	seq        int     // sequence number of the last visited entry
+	generation int
+}
+
+func loadWord(p uintptr) uintptr {
+	var w *uintptr = (*uintptr)(unsafe.Pointer(p))
+	return *w
+}
+
+func storeWord(p uintptr, v uintptr) {
+	var w *uintptr = (*uintptr)(unsafe.Pointer(p))
+	*w = v
+}
+
+func loadByte(p uintptr) byte {
+	var b *byte = (*byte)(unsafe.Pointer(p))
+	return *b
+}
+
+// scatter the bits of h
+func mixhash(h uintptr) uintptr {
+	h = h * 6364136223846793005
+	return h / 4294967296
+}
+
+// hash of a string whose header is at p
+func strhash(p uintptr) uintptr {
+	ptr := loadWord(p)
+	n := loadWord(p + 8)
+	var h uintptr = n
+	for i := uintptr(0); i < n; i++ {
+		h = h*31 + uintptr(loadByte(ptr+i))
+	}
+	return mixhash(h)
+}
+
+func strequal(p uintptr, q uintptr) bool {
+	n := loadWord(p + 8)
+	if n != loadWord(q+8) {
+		return false
+	}
+	pptr := loadWord(p)
+	qptr := loadWord(q)
+	if pptr == qptr {
+		return true
+	}
+	for i := uintptr(0); i < n; i++ {
+		if loadByte(pptr+i) != loadByte(qptr+i) {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *hmap) hash(key uintptr) uintptr {
+	if h.keykind == mapKeyString {
+		return strhash(key)
+	}
+	return mixhash(loadWord(key))
+}
+
+func (h *hmap) keyequal(p uintptr, q uintptr) bool {
+	if h.keykind == mapKeyString {
+		return strequal(p, q)
+	}
+	return loadWord(p) == loadWord(q)
+}
+
+func (h *hmap) entry(i uintptr) uintptr {
+	return h.entries + i*h.entrysize
+}
+
+func (h *hmap) seqOf(e uintptr) int {
+	return int(loadWord(e + h.keysize + h.valsize))
+}
+
+func (h *hmap) setSeq(e uintptr, seq int) {
+	storeWord(e+h.keysize+h.valsize, uintptr(seq))
+}
+
+func (h *hmap) slot(i uintptr) uintptr {
+	return h.slots + i*8
+}
+
+func makemap(keykind int, valsize int, hint int) *hmap {
+	if hint < 0 {
+		panic("makemap: size out of range")
+	}
+	h := &hmap{}
+	h.keykind = keykind
+	h.keysize = 8
+	if keykind == mapKeyString {
+		h.keysize = 24
+	}
+	h.valsize = uintptr(valsize)
+	h.entrysize = h.keysize + h.valsize + 8
+	h.rehash(uintptr(hint))
+	return h
+}
+
+// move live entries into a new array, which can hold at least n entries,
+// and rebuild the hash table.
+func (h *hmap) rehash(n uintptr) {
+	maxentries := minMapEntries
+	for maxentries < n {
+		maxentries = maxentries * 2
+	}
+	oldentries := h.entries
+	oldn := h.nentries
+	h.entries = malloc(maxentries * h.entrysize)
+	h.maxentries = maxentries
+	h.nentries = 0
+	// keep the load factor of the hash table under 1/2
+	h.nslots = maxentries * 2
+	h.slots = mallocNoscan(h.nslots * 8)
+	h.generation++
+	for i := uintptr(0); i < oldn; i++ {
+		e := oldentries + i*h.entrysize
+		if h.seqOf(e) < 0 {
+			continue
+		}
+		newe := h.entry(h.nentries)
+		memmove(newe, e, int(h.entrysize))
+		h.insertSlot(h.nentries)
+		h.nentries++
+	}
+}
+
+// register the entry to the hash table
+func (h *hmap) insertSlot(i uintptr) {
+	s := h.hash(h.entry(i)) % h.nslots
+	for loadWord(h.slot(s)) != slotEmpty {
+		s = (s + 1) % h.nslots
+	}
+	storeWord(h.slot(s), i+slotEntryBase)
+}
+
+// the slot number of the key, or nslots if not found
+func (h *hmap) find(key uintptr) uintptr {
+	s := h.hash(key) % h.nslots
+	for {
+		v := loadWord(h.slot(s))
+		if v == slotEmpty {
+			return h.nslots
+		}
+		if v != slotDeleted && h.keyequal(h.entry(v-slotEntryBase), key) {
+			return s
+		}
+		s = (s + 1) % h.nslots
+	}
+	return h.nslots
+}
+
+// the address of the value for the key, or 0 if not found
+func mapaccess(h *hmap, key uintptr) uintptr {
+	if h == nil || h.count == 0 {
+		return 0
+	}
+	s := h.find(key)
+	if s == h.nslots {
+		return 0
+	}
+	e := h.entry(loadWord(h.slot(s)) - slotEntryBase)
+	return e + h.keysize
+}
+
+// the address of the value for the key. A new entry is added if not found.
+func mapassign(h *hmap, key uintptr) uintptr {
+	if h == nil {
+		panicnilmap()
+	}
+	v := mapaccess(h, key)
+	if v != 0 {
+		return v
+	}
+	if h.nentries == h.maxentries {
+		h.rehash(uintptr(h.count+1) * 2)
+	}
+	e := h.entry(h.nentries)
+	memmove(e, key, int(h.keysize))
+	memclr(e+h.keysize, int(h.valsize))
+	h.seq++
+	h.setSeq(e, h.seq)
+	h.insertSlot(h.nentries)
+	h.nentries++
+	h.count++
+	return e + h.keysize
+}
+
+// delete(m, k)
+func mapdelete(h *hmap, key uintptr) {
+	if h == nil || h.count == 0 {
+		return
+	}
+	s := h.find(key)
+	if s == h.nslots {
+		return
+	}
+	e := h.entry(loadWord(h.slot(s)) - slotEntryBase)
+	seq := h.seqOf(e)
+	// let the garbage collector free the key and the value
+	memclr(e, int(h.keysize+h.valsize))
+	h.setSeq(e, -seq)
+	storeWord(h.slot(s), slotDeleted)
+	h.count--
+}
+
+func mapiterinit(h *hmap) *hiter {
+	it := &hiter{}
+	it.m = h
+	if h != nil {
+		it.generation = h.generation
+	}
+	return it
+}
+
+// the addresses of the key and the value of the next entry, or 0s at the end.
+func mapiternext(it *hiter) (uintptr, uintptr) {
+	h := it.m
+	if h == nil {
+		return 0, 0
+	}
+	if it.generation != h.generation {
+		// entries have moved. resume after the last visited entry.
+		it.generation = h.generation
+		it.i = h.searchSeq(it.seq)
+	}
+	for it.i < h.nentries {
+		e := h.entry(it.i)
+		it.i++
+		seq := h.seqOf(e)
+		if seq > 0 {
+			it.seq = seq
+			return e, e + h.keysize
+		}
+	}
+	return 0, 0
+}
+
+// the first entry number whose sequence number is greater than seq
+func (h *hmap) searchSeq(seq int) uintptr {
+	lo := uintptr(0)
+	hi := h.nentries
+	for lo < hi {
+		mid := (lo + hi) / 2
+		s := h.seqOf(h.entry(mid))
+		if s < 0 {
+			s = -s
+		}
+		if s <= seq {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// find a method in the method table of a receiver type (see gen_main.go)
+//
+//	[ name, function address, name, function address, ..., 0 ]
+func findMethod(table uintptr, name uintptr) uintptr {
+	for p := table; loadWord(p) != 0; p = p + 16 {
+		if loadWord(p) == name {
+			return loadWord(p + 8)
+		}
+	}
+	panic("method not found")
+	return 0
+}
@@ -0,0 +1,220 @@
+package runtime
+
+import "unsafe"
+
+// Garbage collector.
+//
+// A non-moving mark and sweep collector, which stops the world while it runs.
+// Roots are the data section and the stacks of goroutines,
+// which are scanned conservatively.
+// A heap object is scanned according to the typ of its span:
+// not at all (typNoscan), every word (typConservative),
+// or only the words flagged in a pointer map emitted by the compiler (see gen_gc.go):
+//   [ partial spans (reserved for the allocator), number of words, a flag byte for each word ]
+// The pointer map repeats for arrays.
+
+const minNextGC uintptr = 16777216
+
+var gcpercent int = 100 // GOGC
+var nextGC uintptr = minNextGC
+var numgc int
+
+var markstack uintptr
+var markstackLen uintptr
+var markstackCap uintptr
+
+// implemented in runtime.s
+func memclr(p uintptr, n int)
+func getsp() uintptr
+func dataSegment() (uintptr, uintptr)
+
+func throw(s string) {
+	printstring([]byte("fatal error: "))
+	printstring([]byte(s))
+	printstring([]byte("\n"))
+	exit(2)
+}
+
+func readgogc() {
+	v, ok := Envvars["GOGC"]
+	if !ok {
+		return
+	}
+	if v == "off" {
+		gcpercent = -1
+		return
+	}
+	var n int
+	for _, c := range []byte(v) {
+		n = n*10 + int(c-'0')
+	}
+	gcpercent = n
+}
+
+func markstackInit() {
+	markstackCap = 65536
+	markstack = persistentalloc(markstackCap * 8)
+}
+
+func markpush(p uintptr) {
+	if markstackLen == markstackCap {
+		newstack := persistentalloc(markstackCap * 16)
+		memmove(newstack, markstack, int(markstackCap*8))
+		markstack = newstack
+		markstackCap = markstackCap * 2
+	}
+	var slot *uintptr = (*uintptr)(unsafe.Pointer(markstack + markstackLen*8))
+	*slot = p
+	markstackLen++
+}
+
+func markpop() uintptr {
+	markstackLen--
+	var slot *uintptr = (*uintptr)(unsafe.Pointer(markstack + markstackLen*8))
+	return *slot
+}
+
+// mark the object which p points into
+func greyobject(p uintptr) {
+	s := spanOf(p)
+	if s == nil {
+		return
+	}
+	i := (p - s.base) / s.elemsize
+	if i >= s.nelems {
+		return
+	}
+	st := s.stateOf(i)
+	if int(*st) != slotAllocated {
+		return
+	}
+	*st = slotMarked
+	if s.typ != typNoscan {
+		markpush(s.base + i*s.elemsize)
+	}
+}
+
+// scan n bytes from b conservatively
+func scanblock(b uintptr, n uintptr) {
+	for i := uintptr(0); i+8 <= n; i = i + 8 {
+		var word *uintptr = (*uintptr)(unsafe.Pointer(b + i))
+		greyobject(*word)
+	}
+}
+
+func scanobject(b uintptr) {
+	s := spanOf(b)
+	if s.typ == typConservative {
+		scanblock(b, s.elemsize)
+		return
+	}
+	var pnwords *uintptr = (*uintptr)(unsafe.Pointer(s.typ + 8))
+	nwords := *pnwords
+	flags := s.typ + 16
+	n := s.elemsize / 8
+	for i := uintptr(0); i < n; i++ {
+		var flag *byte = (*byte)(unsafe.Pointer(flags + i%nwords))
+		if *flag != 0 {
+			var word *uintptr = (*uintptr)(unsafe.Pointer(b + i*8))
+			greyobject(*word)
+		}
+	}
+}
+
+func markroots() {
+	start, end := dataSegment()
+	scanblock(start, end-start)
+	me := getg()
+	if me == nil {
+		// the scheduler has not started yet
+		sp := getsp()
+		scanblock(sp, mainStackHi-sp)
+		return
+	}
+	for gp := allgs; gp != nil; gp = gp.alllink {
+		if gp.status == _Gdead {
+			continue
+		}
+		sp := gp.sp
+		if gp == me {
+			sp = getsp()
+		} else if gp.syscallsp != 0 {
+			sp = gp.syscallsp
+		}
+		scanblock(sp, gp.stackhi-sp)
+	}
+}
+
+// free unmarked objects and rebuild the lists of partial spans.
+// heaplock must be held.
+func sweep() {
+	for s := allspans; s != nil; s = s.allnext {
+		if s.owner != 0 {
+			setlisthead(s.owner, nil)
+		}
+	}
+	var live uintptr
+	var inuse *mspan
+	s := allspans
+	for s != nil {
+		next := s.allnext
+		var nlive uintptr
+		s.freelist = 0
+		s.nfree = 0
+		for i := int(s.nelems) - 1; i >= 0; i-- {
+			st := s.stateOf(uintptr(i))
+			if int(*st) == slotMarked {
+				*st = slotAllocated
+				nlive++
+			} else {
+				*st = slotFree
+				slot := s.base + uintptr(i)*s.elemsize
+				var link *uintptr = (*uintptr)(unsafe.Pointer(slot))
+				*link = s.freelist
+				s.freelist = slot
+				s.nfree++
+			}
+		}
+		if nlive == 0 {
+			freespan(s)
+		} else {
+			live = live + nlive*s.elemsize
+			s.allnext = inuse
+			inuse = s
+			if s.owner != 0 && s.nfree > 0 {
+				s.next = listhead(s.owner)
+				setlisthead(s.owner, s)
+			}
+		}
+		s = next
+	}
+	allspans = inuse
+	heapLive = live
+}
+
+func canGC() bool {
+	if getm().locks > 0 {
+		return false
+	}
+	return getg() != nil || mcount <= 1
+}
+
+func gc() {
+	if !stopTheWorld() {
+		// another goroutine has collected garbage meanwhile
+		return
+	}
+	lock(&heaplock)
+	markroots()
+	for markstackLen > 0 {
+		scanobject(markpop())
+	}
+	sweep()
+	numgc++
+	nextGC = heapLive + (heapLive/100)*uintptr(gcpercent)
+	if nextGC < minNextGC {
+		nextGC = minNextGC
+	}
+	unlock(&heaplock)
+	startTheWorld()
+}
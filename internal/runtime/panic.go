@@ -0,0 +1,295 @@
+package runtime
+
+import "unsafe"
+
+// A _defer is a deferred call which has been registered by a defer statement.
+// The function value and the arguments are evaluated at the defer statement.
+type _defer struct {
+	link  *_defer
+	bp    uintptr // frame of the function which executed the defer statement
+	entry uintptr // code address of the function
+	fn    uintptr // closure object or 0
+	args  uintptr // values of the argument registers
+}
+
+// implemented in runtime.s
+func calldefer(entry uintptr, fn uintptr, args uintptr)
+
+// deferproc pushes a deferred call of the frame bp to the current goroutine.
+// It is called by iruntime.deferstmt.
+func deferproc(entry uintptr, fn uintptr, args uintptr, bp uintptr) {
+	d := &_defer{}
+	d.bp = bp
+	d.entry = entry
+	d.fn = fn
+	d.args = malloc(sizeOfArgs)
+	memmove(d.args, args, sizeOfArgs)
+	gp := getg()
+	d.link = gp._defer
+	gp._defer = d
+}
+
+// deferreturn runs the deferred calls of the frame bp in LIFO order.
+// It is called by every function which has defer statements before it returns.
+func deferreturn(bp uintptr) {
+	gp := getg()
+	for gp._defer != nil && gp._defer.bp == bp {
+		d := gp._defer
+		gp._defer = d.link
+		calldefer(d.entry, d.fn, d.args)
+		// the deferred call may have switched threads
+		gp = getg()
+	}
+}
+
+// A _panic is an active panic of a goroutine.
+type _panic struct {
+	arg        interface{} // argument to panic
+	link       *_panic     // the panic which was active when this panic started
+	bp         uintptr     // frame of the panic function
+	recovered  bool
+	repanicked bool // panicked again with the value it has recovered from
+}
+
+// implemented in runtime.s
+func getbp() uintptr
+func recovery(bp uintptr)
+func methodTables() uintptr
+func callStringMethod(fn uintptr, recv uintptr) string
+
+// panic is called by the builtin panic.
+// It runs the deferred calls of the current goroutine until one of them recovers.
+func panic(e interface{}) {
+	if e == nil {
+		e = &runtimeError{msg: "panic called with nil argument"}
+	}
+	gp := getg()
+	p := &_panic{}
+	p.arg = e
+	p.bp = getbp()
+	p.link = gp._panic
+	if p.link != nil && p.link.recovered && ifaceeq(p.link.arg, e) {
+		p.repanicked = true
+		p.link = p.link.link
+	}
+	gp._panic = p
+	for gp._defer != nil {
+		d := gp._defer
+		gp._defer = d.link
+		calldefer(d.entry, d.fn, d.args)
+		gp = getg()
+		if p.recovered {
+			gp._panic = p.link
+			gp.sig = 0
+			// drop the panics whose frames are discarded
+			for gp._panic != nil && gp._panic.bp < d.bp {
+				gp._panic = gp._panic.link
+			}
+			recovery(d.bp)
+		}
+	}
+	printpanics(gp._panic)
+	if gp.sig != 0 {
+		printsignal(gp)
+	}
+	traceback(getcallerpc(), getbp())
+	exit(2)
+}
+
+// gorecover is called by the builtin recover with the frame of its caller.
+// recover stops panicking only when it is called directly by a deferred function.
+func gorecover(bp uintptr) interface{} {
+	gp := getg()
+	p := gp._panic
+	if p == nil || p.recovered {
+		return nil
+	}
+	// the caller is called by calldefer, which is called by panic
+	if loadWord(loadWord(bp)) != p.bp {
+		return nil
+	}
+	p.recovered = true
+	return p.arg
+}
+
+// identity of interface values
+func ifaceeq(a interface{}, b interface{}) bool {
+	pa := uintptr(unsafe.Pointer(&a))
+	pb := uintptr(unsafe.Pointer(&b))
+	return loadWord(pa) == loadWord(pb) && loadWord(pa+16) == loadWord(pb+16)
+}
+
+func printpanics(p *_panic) {
+	if p.link != nil {
+		printpanics(p.link)
+		printstring([]byte("\t"))
+	}
+	printstring([]byte("panic: "))
+	printpanicval(p.arg)
+	if p.repanicked {
+		printstring([]byte(" [recovered, repanicked]"))
+	} else if p.recovered {
+		printstring([]byte(" [recovered]"))
+	}
+	printstring([]byte("\n"))
+}
+
+func printpanicval(e interface{}) {
+	if e == nil {
+		printstring([]byte("nil"))
+		return
+	}
+	p := uintptr(unsafe.Pointer(&e))
+	data := loadWord(p)
+	typeId := loadWord(p + 8)
+	for _, name := range []string{"Error", "String"} {
+		fn := lookupMethod(typeId, name)
+		if fn != 0 {
+			s := callStringMethod(fn, loadWord(data))
+			printstring([]byte(s))
+			return
+		}
+	}
+	// the dynamic type descriptor and its underlying type (see gen_main.go)
+	dtypeAddr := loadWord(p + 16)
+	dtype := cstring2string((*byte)(unsafe.Pointer(dtypeAddr)))
+	var underlying string
+	if loadWord(dtypeAddr-8) != 0 {
+		underlying = cstring2string((*byte)(unsafe.Pointer(loadWord(dtypeAddr - 8))))
+	}
+	if len(dtype) > 8 && dtype[0:8] == "G_NAMED(" && isBasicType(underlying) {
+		// pkg.T(value) for a named type
+		printstring([]byte(typestring(dtype)))
+		printstring([]byte("("))
+		if underlying == "string" {
+			printstring([]byte("\""))
+			printbasicval(underlying, data)
+			printstring([]byte("\""))
+		} else {
+			printbasicval(underlying, data)
+		}
+		printstring([]byte(")"))
+		return
+	}
+	if isBasicType(dtype) {
+		printbasicval(dtype, data)
+		return
+	}
+	printstring([]byte("("))
+	printstring([]byte(typestring(dtype)))
+	printstring([]byte(") "))
+	if dtype[0] == '*' {
+		// a pointer is boxed
+		data = loadWord(data)
+	}
+	printhex(uint64(data))
+}
+
+func isBasicType(dtype string) bool {
+	switch dtype {
+	case "bool", "string", "float64", "float32",
+		"int", "int8", "int16", "int32", "int64",
+		"uint", "byte", "uint16", "uint32", "uint64", "uintptr":
+		return true
+	}
+	return false
+}
+
+// print a value of a basic type boxed at data
+func printbasicval(dtype string, data uintptr) {
+	switch dtype {
+	case "bool":
+		printbool(loadWord(data) != 0)
+	case "string":
+		// a string is boxed as ptr, len and cap
+		var buf []byte
+		ptr := loadWord(data)
+		for i := uintptr(0); i < loadWord(data+8); i++ {
+			b := (*byte)(unsafe.Pointer(ptr + i))
+			buf = append(buf, *b)
+		}
+		printstring(buf)
+	case "float64":
+		var f *float64 = (*float64)(unsafe.Pointer(data))
+		printfloat(*f, 64)
+	case "float32":
+		var f *float32 = (*float32)(unsafe.Pointer(data))
+		printfloat(float64(*f), 32)
+	case "int", "int8", "int16", "int32", "int64":
+		printint(int(loadWord(data)))
+	default:
+		printuint(uint64(loadWord(data)))
+	}
+}
+
+// the type name of a dynamic type label like "*G_NAMED(main.T)"
+func typestring(dtype string) string {
+	var buf []byte
+	var named int
+	for i := 0; i < len(dtype); i++ {
+		if i+8 <= len(dtype) && dtype[i:i+8] == "G_NAMED(" {
+			named++
+			i = i + 7
+			continue
+		}
+		if dtype[i] == ')' && named > 0 {
+			named--
+			continue
+		}
+		// byte and rune are aliases
+		if isTypeWord(dtype, i, "byte") {
+			buf = appendString(buf, "uint8")
+			i = i + 3
+			continue
+		}
+		if isTypeWord(dtype, i, "rune") {
+			buf = appendString(buf, "int32")
+			i = i + 3
+			continue
+		}
+		buf = append(buf, dtype[i])
+	}
+	return string(buf)
+}
+
+func appendString(buf []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		buf = append(buf, s[i])
+	}
+	return buf
+}
+
+// whether dtype[i:] starts with the predeclared type name word
+func isTypeWord(dtype string, i int, word string) bool {
+	if i+len(word) > len(dtype) || dtype[i:i+len(word)] != word {
+		return false
+	}
+	if i > 0 && (isIdentChar(dtype[i-1]) || dtype[i-1] == '.') {
+		return false
+	}
+	return i+len(word) == len(dtype) || !isIdentChar(dtype[i+len(word)])
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// find a method by name in the method table of a receiver type (see gen_main.go)
+// It returns 0 if the type does not have the method.
+func lookupMethod(typeId uintptr, name string) uintptr {
+	tables := methodTables()
+	// the number of tables is stored before the tables
+	if typeId == 0 || typeId > loadWord(tables-8) {
+		return 0
+	}
+	table := loadWord(tables + typeId*8)
+	if table == 0 {
+		return 0
+	}
+	for p := table; loadWord(p) != 0; p = p + 16 {
+		if cstring2string((*byte)(unsafe.Pointer(loadWord(p)))) == name {
+			return loadWord(p + 8)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,54 @@
+package runtime
+
+// low level printing to the standard error
+
+func printint(v int) {
+	if v < 0 {
+		printstring([]byte("-"))
+		printuint(uint64(-v))
+		return
+	}
+	printuint(uint64(v))
+}
+
+func printuint(v uint64) {
+	var buf []byte = make([]byte, 20, 20)
+	i := len(buf)
+	for {
+		i--
+		buf[i] = byte('0') + byte(v%10)
+		v = v / 10
+		if v == 0 {
+			break
+		}
+	}
+	printstring(buf[i:])
+}
+
+func printhex(v uint64) {
+	digits := "0123456789abcdef"
+	var buf []byte = make([]byte, 16, 16)
+	i := len(buf)
+	for {
+		i--
+		buf[i] = digits[v%16]
+		v = v / 16
+		if v == 0 {
+			break
+		}
+	}
+	printstring([]byte("0x"))
+	printstring(buf[i:])
+}
+
+func printfloat(v float64, bitSize int) {
+	printstring([]byte(ftoa(v, 'g', -1, bitSize)))
+}
+
+func printbool(v bool) {
+	if v {
+		printstring([]byte("true"))
+	} else {
+		printstring([]byte("false"))
+	}
+}
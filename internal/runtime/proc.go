@@ -0,0 +1,388 @@
+package runtime
+
+import "unsafe"
+
+// Goroutine scheduler.
+// Goroutines (g) are multiplexed onto OS threads (m).
+// Runnable goroutines are kept in a global run queue guarded by "schedlock".
+// Each m runs the scheduler loop on its own stack (g0),
+// and switches to a goroutine until it parks, yields or exits.
+// A goroutine yields at function prologues (see YIELD_CHECK in macro.s)
+// and blocking system calls hand off the thread to other goroutines.
+// The garbage collector stops the world by making goroutines yield.
+
+const _Grunnable = 1
+const _Grunning = 2
+const _Gwaiting = 3
+const _Gdead = 4
+
+// The context switch code knows the layout of sp, bp and pc (see runtime.s).
+type g struct {
+	sp         uintptr // saved context
+	bp         uintptr
+	pc         uintptr
+	entry      uintptr // code address of the function
+	fn         uintptr // closure object or 0
+	args       uintptr // values of the argument registers
+	stack      uintptr
+	stackhi    uintptr
+	syscallsp  uintptr // sp when it has entered a system call
+	status     int
+	schedlink  *g
+	alllink    *g
+	id         int
+	_defer     *_defer // innermost deferred call
+	_panic     *_panic // innermost panic
+	sig        int     // the signal which has caused a run-time panic
+	sigcode    int
+	sigaddr    uintptr
+	sigpc      uintptr
+	gopc       uintptr // pc of the go statement that created this goroutine
+	parentGoid int
+}
+
+// The thread local storage of a thread points to its m.
+// The compiler and runtime.s know the offsets of self and ticks.
+type m struct {
+	sp      uintptr // saved context of the scheduler
+	bp      uintptr
+	pc      uintptr
+	self    *m
+	ticks   int // number of calls until the next yield
+	curg    *g
+	unlockf *int // lock to be released after parking curg
+	locks   int  // number of locks held
+	stack   uintptr
+	id      int
+	alllink *m
+}
+
+const maxprocs = 4 // max number of threads running goroutines
+const maxmcount = 64
+const yieldTicks = 1000
+
+const sizeOfArgs = 96 // 12 registers
+const goroutineStackSize = 256 * 1024
+const g0StackSize = 64 * 1024
+
+var m0 m
+var gmain g
+var mainStackHi uintptr // set by _start
+var allm *m
+var allgs *g
+
+var schedlock int
+var schedseq int // futex word for idle threads
+var runqhead *g
+var runqtail *g
+var gfree *g // dead goroutines to reuse
+var mcount int
+var nmidle int    // number of threads waiting for goroutines
+var nmsyscall int // number of threads in system calls
+var nmstopped int // number of threads stopped by the garbage collector
+var goidgen int
+
+var gcwaiting bool // the garbage collector is stopping the world
+var gcseq int      // futex word for stopped threads
+
+// implemented in runtime.s
+func settls(mp *m)
+func getm() *m
+func swtch(from uintptr, to uintptr)
+func mstartPC() uintptr
+func gostartPC() uintptr
+
+func getg() *g {
+	return getm().curg
+}
+
+func schedinit() {
+	mp := &m0
+	mp.ticks = yieldTicks
+	mp.stack = mallocNoscan(g0StackSize)
+	mp.sp = mp.stack + g0StackSize - 8
+	mp.pc = mstartPC()
+	mcount = 1
+	allm = mp
+
+	// the main goroutine runs on the stack of the process
+	gmain.id = 1
+	goidgen = 1
+	gmain.status = _Grunning
+	gmain.stackhi = mainStackHi
+	allgs = &gmain
+	mp.curg = &gmain
+}
+
+// must be called with schedlock held
+func runqput(gp *g) {
+	gp.schedlink = nil
+	if runqtail == nil {
+		runqhead = gp
+	} else {
+		runqtail.schedlink = gp
+	}
+	runqtail = gp
+}
+
+// must be called with schedlock held
+func runqget() *g {
+	gp := runqhead
+	if gp != nil {
+		runqhead = gp.schedlink
+		if runqhead == nil {
+			runqtail = nil
+		}
+		gp.schedlink = nil
+	}
+	return gp
+}
+
+// wake up an idle thread or start a new one to run goroutines.
+// must be called with schedlock held
+func wakep() {
+	if nmidle > 0 {
+		schedseq++
+		futexwakeup(&schedseq, 1)
+		return
+	}
+	if mcount-nmsyscall < maxprocs && mcount < maxmcount {
+		newm()
+	}
+}
+
+// make gp runnable
+func ready(gp *g) {
+	lock(&schedlock)
+	gp.status = _Grunnable
+	runqput(gp)
+	wakep()
+	unlock(&schedlock)
+}
+
+func newm() {
+	mp := &m{}
+	mp.self = mp
+	mp.ticks = yieldTicks
+	mcount++
+	mp.id = mcount
+	mp.alllink = allm
+	allm = mp
+	mp.stack = mallocNoscan(stackSizeForThread)
+	// stack grows down
+	newosproc(mp.stack+stackSizeForThread, mp)
+}
+
+func newosproc(stk uintptr, mp *m) int {
+	return clone(cloneFlag, stk, mstartPC(), uintptr(unsafe.Pointer(mp)))
+}
+
+// create a new goroutine which calls entry with the argument registers.
+// fn is passed to entry in %rax as a closure object.
+func newproc(entry uintptr, fn uintptr, args uintptr, gopc uintptr) {
+	parent := getg()
+	lock(&schedlock)
+	gp := gfree
+	if gp != nil {
+		gfree = gp.schedlink
+	}
+	goidgen++
+	id := goidgen
+	unlock(&schedlock)
+	if gp == nil {
+		gp = &g{}
+		gp.stack = mallocNoscan(goroutineStackSize)
+		gp.stackhi = gp.stack + goroutineStackSize
+		gp.args = malloc(sizeOfArgs)
+		lock(&schedlock)
+		gp.alllink = allgs
+		allgs = gp
+		unlock(&schedlock)
+	}
+	gp.id = id
+	gp.gopc = gopc
+	gp.parentGoid = parent.id
+	gp.entry = entry
+	gp.fn = fn
+	memmove(gp.args, args, sizeOfArgs)
+	gp.sp = gp.stackhi - 8
+	gp.bp = 0
+	gp.pc = gostartPC()
+	ready(gp)
+}
+
+// clean up the goroutine which has just stopped running on this thread
+func dropg(mp *m) {
+	gp := mp.curg
+	mp.curg = nil
+	if mp.unlockf != nil {
+		unlock(mp.unlockf)
+		mp.unlockf = nil
+	}
+	if gp.status == _Grunnable {
+		lock(&schedlock)
+		runqput(gp)
+		unlock(&schedlock)
+	} else if gp.status == _Gdead {
+		lock(&schedlock)
+		gp.schedlink = gfree
+		gfree = gp
+		unlock(&schedlock)
+	}
+}
+
+// the scheduler loop of a thread
+func schedule() {
+	mp := getm()
+	for {
+		if mp.curg != nil {
+			dropg(mp)
+		}
+		lock(&schedlock)
+		stopm()
+		gp := runqget()
+		for gp == nil {
+			nmidle++
+			if nmidle == mcount && nmsyscall == 0 {
+				throw("all goroutines are asleep - deadlock!")
+			}
+			seq := schedseq
+			unlock(&schedlock)
+			futexsleep(&schedseq, seq)
+			lock(&schedlock)
+			nmidle--
+			stopm()
+			gp = runqget()
+		}
+		unlock(&schedlock)
+
+		gp.status = _Grunning
+		mp.curg = gp
+		mp.ticks = yieldTicks
+		swtch(uintptr(unsafe.Pointer(mp)), uintptr(unsafe.Pointer(gp)))
+	}
+}
+
+// wait while the world is stopped.
+// must be called with schedlock held
+func stopm() {
+	for gcwaiting {
+		nmstopped++
+		seq := gcseq
+		unlock(&schedlock)
+		futexsleep(&gcseq, seq)
+		lock(&schedlock)
+		nmstopped--
+	}
+}
+
+// stop all the other goroutines at their yield points.
+// If another goroutine is stopping the world,
+// it waits until the world restarts and returns false.
+func stopTheWorld() bool {
+	lock(&schedlock)
+	if gcwaiting {
+		unlock(&schedlock)
+		gosched()
+		return false
+	}
+	gcwaiting = true
+	for mp := allm; mp != nil; mp = mp.alllink {
+		mp.ticks = 0
+	}
+	for nmidle+nmsyscall+nmstopped < mcount-1 {
+		unlock(&schedlock)
+		osyield()
+		lock(&schedlock)
+	}
+	unlock(&schedlock)
+	return true
+}
+
+func startTheWorld() {
+	lock(&schedlock)
+	gcwaiting = false
+	gcseq++
+	futexwakeup(&gcseq, maxInt32)
+	if runqhead != nil {
+		wakep()
+	}
+	unlock(&schedlock)
+}
+
+// switch from the current goroutine to the scheduler
+func mcall(gp *g) {
+	mp := getm()
+	swtch(uintptr(unsafe.Pointer(gp)), uintptr(unsafe.Pointer(mp)))
+}
+
+// park the current goroutine until someone readies it.
+// l is unlocked after the goroutine has stopped.
+func park(l *int) {
+	gp := getg()
+	gp.status = _Gwaiting
+	getm().unlockf = l
+	mcall(gp)
+}
+
+// let other goroutines run
+func gosched() {
+	gp := getg()
+	if gp == nil {
+		return
+	}
+	lock(&schedlock)
+	skip := runqhead == nil && !gcwaiting
+	unlock(&schedlock)
+	if skip {
+		return
+	}
+	gp.status = _Grunnable
+	mcall(gp)
+}
+
+// called by YIELD_CHECK
+func yieldTick() {
+	getm().ticks = yieldTicks
+	gosched()
+}
+
+// called when the function of a goroutine returns
+func goexit1() {
+	gp := getg()
+	gp.status = _Gdead
+	mcall(gp)
+}
+
+// The thread may block in a system call.
+// Let another thread run goroutines meanwhile.
+func entersyscall() {
+	gp := getg()
+	if gp != nil {
+		gp.syscallsp = getsp()
+	}
+	lock(&schedlock)
+	nmsyscall++
+	if runqhead != nil {
+		wakep()
+	}
+	unlock(&schedlock)
+}
+
+func exitsyscall() {
+	lock(&schedlock)
+	nmsyscall--
+	stopm()
+	gp := getg()
+	if gp != nil {
+		gp.syscallsp = 0
+	}
+	unlock(&schedlock)
+}
+
+func Syscall(num uintptr, a1 uintptr, a2 uintptr, a3 uintptr) uintptr {
+	entersyscall()
+	r := rawSyscall(num, a1, a2, a3)
+	exitsyscall()
+	return r
+}
@@ -54,47 +54,27 @@ func runtime_getenv(key string) (string, bool) {
 
 func init() {
 	heapInit()
+	schedinit()
+	initsig()
 	envvarsInit()
+	readgogc()
 }
 
 func printstring(b []byte) {
 	write(2, b)
 }
 
-func panic(msg []byte) {
-	printstring([]byte("panic: "))
-	printstring(msg)
-	printstring([]byte("\n"))
-	exit(2)
-}
-
 const MiniGo int = 1
 
 const stackSizeForThread = 1024*1024
 
-const cloneFlag int = 331520
-
-var task uintptr
-
-func getTask() uintptr {
-	tk := task
-	return tk
-}
-
-func startm(mstart uintptr, tsk uintptr) {
-	task = tsk
-	newm(mstart)
-}
-
-func newm(mstart uintptr) {
-	newm1(mstart)
-}
+const _CLONE_VM = 0x100
+const _CLONE_FS = 0x200
+const _CLONE_FILES = 0x400
+const _CLONE_SIGHAND = 0x800
+const _CLONE_THREAD = 0x10000
+const _CLONE_SYSVSEM = 0x40000
+const _CLONE_SETTLS = 0x80000
 
-func newm1(mstart uintptr) {
-	stk := malloc(stackSizeForThread)
-	newosproc(stk, mstart)
-}
-
-func newosproc(stk uintptr, mstart uintptr) int {
-	return clone(cloneFlag, stk, mstart)
-}
+// CLONE_VM|CLONE_FS|CLONE_FILES|CLONE_SIGHAND|CLONE_SYSVSEM|CLONE_THREAD|CLONE_SETTLS
+const cloneFlag int = _CLONE_VM + _CLONE_FS + _CLONE_FILES + _CLONE_SIGHAND + _CLONE_SYSVSEM + _CLONE_THREAD + _CLONE_SETTLS
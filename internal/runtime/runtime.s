@@ -14,14 +14,20 @@ iruntime.makeSlice: # (elmSize int, len int, cap int)
   ADD_NUMBER 1 # 1 byte buffer
   PUSH_8
   POP_TO_ARG_0
+  cmpq $1, -24(%rbp)
+  je .makeSlice_noscan # bytes have no pointers
   FUNCALL iruntime.malloc
+  jmp .makeSlice_end
+.makeSlice_noscan:
+  FUNCALL iruntime.mallocNoscan
+.makeSlice_end:
   movq -16(%rbp), %rbx # len
   movq -8(%rbp), %rcx # cap
   leave
   ret
 
 // copied from https://sys.readthedocs.io/en/latest/doc/07_calling_system_calls.html
-iruntime.Syscall:
+iruntime.rawSyscall:
   movq %rdi, %rax # Syscall number
   movq %rsi, %rdi # set arg1
   movq %rdx, %rsi # set arg2
@@ -38,15 +44,15 @@ iruntime.Syscall:
 //       long clone(unsigned long flags, void *stack,
 //                  int *parent_tid, int *child_tid,
 //                  unsigned long tls);
-iruntime.clone:
+iruntime.clone: # (flags, stk, mstart, mp)
   #movq %rdi, %rdi # cloneFlag
   #movq %rsi, %rsi # stk
 
   movq %rdx, %r12 # mstart
+  movq %rcx, %r8  # tls (m of the new thread)
 
   movq $0, %rdx # parent_tid
   movq $0, %r10 # child_tid
-  movq $0, %r8  # tls or regs
   movq $0, %r9
   movq $56, %rax # Syscall number (sys_clone)
   syscall
@@ -60,8 +66,279 @@ iruntime.clone:
     movq $60, %rax # exit
     syscall
 
+// arch_prctl(ARCH_SET_FS, mp)
+iruntime.settls: # (mp)
+  movq %rdi, %rsi
+  movq $0x1002, %rdi # ARCH_SET_FS
+  movq $158, %rax # sys_arch_prctl
+  syscall
+  ret
+
+// m of the current thread
+iruntime.getm:
+  movq %fs:24, %rax # m.self
+  ret
 
+// entry of a scheduler
 iruntime.mstart:
-  callq iruntime.getTask
+  callq iruntime.schedule
+  ud2 # never returns
+
+iruntime.mstartPC:
+  leaq iruntime.mstart(%rip), %rax
+  ret
+
+// swtch(from uintptr, to uintptr)
+// saves the current context into "from" and resumes the context of "to".
+// A context is [sp, bp, pc].
+iruntime.swtch:
+  leaq .swtch_resume(%rip), %rax
+  movq %rax, 16(%rdi)
+  movq %rsp, 0(%rdi)
+  movq %rbp, 8(%rdi)
+  movq 0(%rsi), %rsp
+  movq 8(%rsi), %rbp
+  jmp *16(%rsi)
+.swtch_resume:
+  ret
+
+// entry of a goroutine
+// g: [sp, bp, pc, entry, fn, args, ...]
+iruntime.gostart:
+  callq iruntime.getg
+  movq 40(%rax), %rbx # args
+  movq 0(%rbx), %rdi
+  movq 8(%rbx), %rsi
+  movq 16(%rbx), %rdx
+  movq 24(%rbx), %rcx
+  movq 32(%rbx), %r8
+  movq 40(%rbx), %r9
+  movq 48(%rbx), %r10
+  movq 56(%rbx), %r11
+  movq 64(%rbx), %r12
+  movq 72(%rbx), %r13
+  movq 80(%rbx), %r14
+  movq 88(%rbx), %r15
+  movq 24(%rax), %rbx # entry
+  movq 32(%rax), %rax # closure
+  callq *%rbx
+  callq iruntime.goexit1
+  ud2 # never returns
+
+iruntime.gostartPC:
+  leaq iruntime.gostart(%rip), %rax
+  ret
+
+// go statement of a func value
+// The closure object is in %rax and the arguments are in registers.
+iruntime.gostmt:
+  movq %rax, %rbx # closure
+  movq 0(%rax), %rax # func addr
+  jmp .gostmt
+
+// go statement of a function
+// The function address is in %rax and the arguments are in registers.
+iruntime.gostmtcode:
+  movq $0, %rbx # no closure
+.gostmt:
+  pushq %r15
+  pushq %r14
+  pushq %r13
+  pushq %r12
+  pushq %r11
+  pushq %r10
+  pushq %r9
+  pushq %r8
+  pushq %rcx
+  pushq %rdx
+  pushq %rsi
+  pushq %rdi
+  movq %rax, %rdi # entry
+  movq %rbx, %rsi # closure
+  movq %rsp, %rdx # args
+  movq 96(%rsp), %rcx # pc of the go statement
+  callq iruntime.newproc
+  addq $96, %rsp
+  ret
+
+// defer statement of a func value
+// The closure object is in %rax and the arguments are in registers.
+iruntime.deferstmt:
+  movq %rax, %rbx # closure
+  movq 0(%rax), %rax # func addr
+  jmp .deferstmt
+
+// defer statement of a function
+// The function address is in %rax and the arguments are in registers.
+iruntime.deferstmtcode:
+  movq $0, %rbx # no closure
+.deferstmt:
+  pushq %r15
+  pushq %r14
+  pushq %r13
+  pushq %r12
+  pushq %r11
+  pushq %r10
+  pushq %r9
+  pushq %r8
+  pushq %rcx
+  pushq %rdx
+  pushq %rsi
+  pushq %rdi
+  movq %rax, %rdi # entry
+  movq %rbx, %rsi # closure
+  movq %rsp, %rdx # args
+  movq %rbp, %rcx # frame of the caller
+  callq iruntime.deferproc
+  addq $96, %rsp
+  ret
+
+// calldefer(entry uintptr, fn uintptr, args uintptr)
+// calls a deferred function with the saved values of the argument registers.
+iruntime.calldefer:
+  FUNC_PROLOGUE
+  pushq %rdi # entry
+  movq %rsi, %rax # closure
+  movq %rdx, %rbx # args
+  movq 0(%rbx), %rdi
+  movq 8(%rbx), %rsi
+  movq 16(%rbx), %rdx
+  movq 24(%rbx), %rcx
+  movq 32(%rbx), %r8
+  movq 40(%rbx), %r9
+  movq 48(%rbx), %r10
+  movq 56(%rbx), %r11
+  movq 64(%rbx), %r12
+  movq 72(%rbx), %r13
+  movq 80(%rbx), %r14
+  movq 88(%rbx), %r15
+  popq %rbx # entry
+  callq *%rbx
+  leave
+  ret
+
+// the frame pointer of the caller
+iruntime.getbp:
+  movq %rbp, %rax
+  ret
+
+// the builtin recover passes the frame of its caller
+iruntime.recover:
+  movq %rbp, %rdi
+  jmp iruntime.gorecover
+
+// recovery(bp uintptr)
+// resumes the frame bp as if its deferred call has returned normally.
+// The remaining deferred calls of the frame are run, and the function returns zero values.
+iruntime.recovery:
+  movq %rdi, %rbp
+  callq iruntime.deferreturn
+  xorq %rax, %rax
+  xorq %rbx, %rbx
+  xorq %rcx, %rcx
+  xorq %rdx, %rdx
+  xorq %rdi, %rdi
+  xorq %rsi, %rsi
+  xorq %r8, %r8
+  xorq %r9, %r9
+  xorq %r10, %r10
+  xorq %r11, %r11
+  xorq %r12, %r12
+  xorq %r13, %r13
+  xorq %r14, %r14
+  xorq %r15, %r15
+  leave
+  ret
+
+iruntime.getcallerpc:
+  movq 0(%rsp), %rax
+  ret
+
+// the function table (see gen_symtab.go)
+iruntime.functab:
+  leaq functab(%rip), %rax
+  ret
+
+// the method tables of receiver types (see gen_main.go)
+iruntime.methodTables:
+  leaq receiverTypes(%rip), %rax
+  ret
+
+// callStringMethod(fn uintptr, recv uintptr) string
+// calls a method like Error() or String()
+iruntime.callStringMethod:
+  FUNC_PROLOGUE
+  movq %rdi, %rax
+  movq %rsi, %rdi
   callq *%rax
+  leave
+  ret
+
+// rtsigaction(sig int, new *sigactiont, old *sigactiont) int
+iruntime.rtsigaction:
+  movq $13, %rax # rt_sigaction
+  movq $8, %r10 # the size of a signal mask
+  syscall
+  ret
+
+// the kernel calls the signal handler with (sig, info, ctx)
+iruntime.sigtramp:
+  FUNCALL iruntime.sighandler
+  ret
+
+iruntime.sigtrampPC:
+  leaq iruntime.sigtramp(%rip), %rax
+  ret
+
+// the signal handler returns here
+iruntime.sigreturn:
+  movq $15, %rax # rt_sigreturn
+  syscall
+  ud2 # never returns
+
+iruntime.sigreturnPC:
+  leaq iruntime.sigreturn(%rip), %rax
+  ret
+
+iruntime.sigpanicPC:
+  leaq iruntime.sigpanic(%rip), %rax
+  ret
+
+// cas(addr *int, old int, new int) bool
+iruntime.cas:
+  movq %rsi, %rax
+  lock cmpxchgq %rdx, (%rdi)
+  sete %al
+  movzbq %al, %rax
+  ret
+
+// xadd(addr *uintptr, delta uintptr) uintptr
+// returns the old value
+iruntime.xadd:
+  movq %rsi, %rax
+  lock xaddq %rax, (%rdi)
+  ret
+
+// memmove(to uintptr, from uintptr, n int)
+iruntime.memmove:
+  movq %rdx, %rcx
+  rep movsb
+  ret
+
+// memclr(p uintptr, n int)
+iruntime.memclr:
+  movq %rsi, %rcx
+  movq $0, %rax
+  rep stosb
+  ret
+
+// sp of the caller
+iruntime.getsp:
+  leaq 8(%rsp), %rax
+  ret
+
+// start and end of the data section including bss
+iruntime.dataSegment:
+  leaq iruntime.dataStart(%rip), %rax
+  leaq _end(%rip), %rbx
   ret
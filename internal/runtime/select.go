@@ -0,0 +1,106 @@
+package runtime
+
+import "unsafe"
+
+const caseRecv = 1
+const caseSend = 2
+
+// The compiler lays out select cases in this form (see gen_select.go)
+type scase struct {
+	c    *hchan
+	dir  int
+	elem uintptr // address of the value to send or the buffer to receive
+}
+
+const sizeOfScase = 24
+
+var fastrandSeed uintptr = 88172645463325252
+
+// linear congruential generator
+func fastrand() uintptr {
+	fastrandSeed = fastrandSeed*6364136223846793005 + 1442695040888963407
+	return fastrandSeed / 4294967296 // use the higher bits
+}
+
+func getScase(cases uintptr, i int) *scase {
+	return (*scase)(unsafe.Pointer(cases + uintptr(i*sizeOfScase)))
+}
+
+func (cas *scase) ready() bool {
+	c := cas.c
+	if c == nil {
+		return false
+	}
+	if cas.dir == caseRecv {
+		return c.canRecv()
+	}
+	if c.dataqsiz == 0 {
+		// unbuffered channel is ready only if a receiver is waiting.
+		return c.closed || (c.qcount == 0 && c.recvwaiting > 0)
+	}
+	return c.canSend()
+}
+
+func setRecvWaiting(cases uintptr, ncases int, start bool) {
+	for i := 0; i < ncases; i++ {
+		cas := getScase(cases, i)
+		if cas.c == nil || cas.dir != caseRecv {
+			continue
+		}
+		if start {
+			cas.c.startRecvWaiting()
+		} else {
+			cas.c.endRecvWaiting()
+		}
+	}
+}
+
+// selectgo chooses one of the ready cases at random and executes it.
+// It returns the index of the chosen case and whether the receive succeeded.
+// If blocking is false and no case is ready, it returns -1.
+func selectgo(cases uintptr, ncases int, blocking bool) (int, bool) {
+	var waiting bool
+	lock(&chanlock)
+	for {
+		var nready int
+		for i := 0; i < ncases; i++ {
+			if getScase(cases, i).ready() {
+				nready++
+			}
+		}
+		if nready > 0 {
+			n := int(fastrand() % uintptr(nready))
+			for i := 0; i < ncases; i++ {
+				cas := getScase(cases, i)
+				if !cas.ready() {
+					continue
+				}
+				if n > 0 {
+					n--
+					continue
+				}
+				if waiting {
+					setRecvWaiting(cases, ncases, false)
+				}
+				var ok bool
+				if cas.dir == caseSend {
+					cas.c.send(cas.elem)
+				} else {
+					ok = cas.c.recv(cas.elem)
+				}
+				unlock(&chanlock)
+				return i, ok
+			}
+		}
+		if !blocking {
+			unlock(&chanlock)
+			return -1, false
+		}
+		if !waiting {
+			setRecvWaiting(cases, ncases, true)
+			waiting = true
+		}
+		chanwait()
+	}
+	return -1, false
+}
@@ -0,0 +1,113 @@
+package runtime
+
+import "unsafe"
+
+// signal handling
+// A synchronous signal like SIGSEGV is turned into a run-time panic of the faulting goroutine.
+
+const __x64_sys_rt_sigaction = 13
+
+const _SIGFPE = 8
+const _SIGSEGV = 11
+
+const _SA_SIGINFO = 0x4
+const _SA_RESTORER = 0x4000000
+
+const _FPE_INTDIV = 1
+const _FPE_INTOVF = 2
+
+// offsets in siginfo_t
+const siginfoCode = 8
+const siginfoAddr = 16
+
+// offsets of registers in ucontext_t
+const ucontextRsp = 160
+const ucontextRip = 168
+
+// the sigaction structure of the kernel
+type sigactiont struct {
+	handler  uintptr
+	flags    uintptr
+	restorer uintptr
+	mask     uintptr
+}
+
+// implemented in runtime.s
+func rtsigaction(sig int, new *sigactiont, old *sigactiont) int
+func sigtrampPC() uintptr
+func sigreturnPC() uintptr
+func sigpanicPC() uintptr
+
+func initsig() {
+	for _, sig := range []int{_SIGSEGV, _SIGFPE} {
+		sa := &sigactiont{}
+		sa.handler = sigtrampPC()
+		sa.flags = _SA_SIGINFO + _SA_RESTORER
+		sa.restorer = sigreturnPC()
+		rtsigaction(sig, sa, nil)
+	}
+}
+
+func loadInt32(p uintptr) int32 {
+	var w *int32 = (*int32)(unsafe.Pointer(p))
+	return *w
+}
+
+// sighandler is called by sigtramp on the stack of the faulting goroutine.
+// It makes the goroutine look like as if the faulting instruction called sigpanic.
+func sighandler(sig int, info uintptr, ctx uintptr) {
+	gp := getg()
+	pc := loadWord(ctx + ucontextRip)
+	if gp == nil {
+		printstring([]byte("fatal error: unexpected signal\n"))
+		exit(2)
+	}
+	gp.sig = sig
+	gp.sigcode = int(loadInt32(info + siginfoCode))
+	gp.sigaddr = loadWord(info + siginfoAddr)
+	gp.sigpc = pc
+
+	sp := loadWord(ctx+ucontextRsp) - 8
+	storeWord(sp, pc)
+	storeWord(ctx+ucontextRsp, sp)
+	storeWord(ctx+ucontextRip, sigpanicPC())
+}
+
+// sigpanic turns a synchronous signal into a run-time panic.
+func sigpanic() {
+	gp := getg()
+	switch gp.sig {
+	case _SIGSEGV:
+		panicmem()
+	case _SIGFPE:
+		if gp.sigcode == _FPE_INTOVF {
+			panicoverflow()
+		}
+		panicdivide()
+	}
+	printstring([]byte("fatal error: unexpected signal\n"))
+	exit(2)
+}
+
+func signame(sig int) string {
+	switch sig {
+	case _SIGSEGV:
+		return "SIGSEGV: segmentation violation"
+	case _SIGFPE:
+		return "SIGFPE: floating-point exception"
+	}
+	return "unknown signal"
+}
+
+// e.g. "[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x401234]"
+func printsignal(gp *g) {
+	printstring([]byte("[signal "))
+	printstring([]byte(signame(gp.sig)))
+	printstring([]byte(" code="))
+	printhex(uint64(gp.sigcode))
+	printstring([]byte(" addr="))
+	printhex(uint64(gp.sigaddr))
+	printstring([]byte(" pc="))
+	printhex(uint64(gp.sigpc))
+	printstring([]byte("]\n"))
+}
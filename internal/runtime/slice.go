@@ -101,5 +101,5 @@ func append24(x []interface{}, elm interface{}) slice {
 	}
 
 	z[xlen] = elm
-	return slice(z)
+	return z
 }
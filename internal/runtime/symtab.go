@@ -0,0 +1,105 @@
+package runtime
+
+import "unsafe"
+
+// The compiler emits a function table. (see gen_symtab.go)
+
+// an entry of the function table
+type _func struct {
+	entry   uintptr // start pc
+	end     uintptr
+	name    *byte
+	file    *byte
+	hasArgs int
+	lines   uintptr // [pc, line] pairs sorted by pc
+	nlines  int
+}
+
+const sizeOfFunc = 7 * 8
+
+// implemented in runtime.s
+func functab() uintptr
+
+// findfunc returns the function which contains pc, or nil.
+func findfunc(pc uintptr) *_func {
+	tab := functab()
+	n := int(loadWord(tab - 8))
+	// functions are sorted by entry
+	lo := 0
+	hi := n
+	for lo < hi {
+		mid := (lo + hi) / 2
+		f := (*_func)(unsafe.Pointer(tab + uintptr(mid*sizeOfFunc)))
+		if pc < f.entry {
+			hi = mid
+		} else if pc >= f.end {
+			lo = mid + 1
+		} else {
+			return f
+		}
+	}
+	return nil
+}
+
+func funcname(f *_func) string {
+	return cstring2string(f.name)
+}
+
+func funcfile(f *_func) string {
+	return cstring2string(f.file)
+}
+
+// the source line of pc in f
+func funcline(f *_func, pc uintptr) int {
+	var line int
+	for i := 0; i < f.nlines; i++ {
+		p := f.lines + uintptr(i*16)
+		if loadWord(p) > pc {
+			break
+		}
+		line = int(loadWord(p + 8))
+	}
+	return line
+}
+
+// callerpc returns the return address of the skip-th caller of
+// the caller of runtime.Caller. Frames of the runtime are not counted.
+func callerpc(skip int) uintptr {
+	bp := getbp()
+	// the frame of runtime.Caller
+	bp = loadWord(bp)
+	for bp != 0 {
+		pc := loadWord(bp + 8)
+		if !isRuntimeFunc(findfunc(pc)) {
+			if skip == 0 {
+				return pc
+			}
+			skip--
+		}
+		next := loadWord(bp)
+		if next <= bp {
+			// the bottom of the stack
+			break
+		}
+		bp = next
+	}
+	return 0
+}
+
+// for runtime.FuncForPC
+func pcfuncname(pc uintptr) string {
+	f := findfunc(pc)
+	if f == nil {
+		return ""
+	}
+	return funcname(f)
+}
+
+// for runtime.Caller
+func pcfileline(pc uintptr) (string, int, bool) {
+	f := findfunc(pc)
+	if f == nil {
+		return "", 0, false
+	}
+	return funcfile(f), funcline(f, pc), true
+}
@@ -9,15 +9,18 @@ const __x64_sys_exit = 60
 const __x64_sys_exit_group = 231
 
 func brk(addr uintptr) uintptr {
-	var ret uintptr = Syscall(__x64_sys_brk, addr, 0, 0)
+	var ret uintptr = rawSyscall(__x64_sys_brk, addr, 0, 0)
 	return ret
 }
 
 func write(fd int, buf []byte) {
+	if len(buf) == 0 {
+		return
+	}
 	var addr *byte = &buf[0]
-	Syscall(__x64_sys_write, uintptr(fd), uintptr(unsafe.Pointer(addr)), uintptr(len(buf)))
+	rawSyscall(__x64_sys_write, uintptr(fd), uintptr(unsafe.Pointer(addr)), uintptr(len(buf)))
 }
 
 func exit(code int) {
-	Syscall(__x64_sys_exit_group, uintptr(code), 0 , 0)
+	rawSyscall(__x64_sys_exit_group, uintptr(code), 0 , 0)
 }
@@ -0,0 +1,82 @@
+package runtime
+
+// printing tracebacks of goroutines
+// A frame is found by the chain of frame pointers. A frame holds [caller's bp, return address].
+
+// implemented in runtime.s
+func getcallerpc() uintptr
+
+func isRuntimeFunc(f *_func) bool {
+	if f == nil {
+		return true
+	}
+	name := funcname(f)
+	return len(name) > 9 && name[0:9] == "iruntime."
+}
+
+// e.g. "main.f(...)\n\tfile.go:12 +0x1f\n"
+// The line is looked up by tracepc, which is in the instruction of pc.
+func printframe(f *_func, pc uintptr, tracepc uintptr) {
+	printstring([]byte(funcname(f)))
+	if f.hasArgs != 0 {
+		printstring([]byte("(...)\n\t"))
+	} else {
+		printstring([]byte("()\n\t"))
+	}
+	printstring([]byte(funcfile(f)))
+	printstring([]byte(":"))
+	printint(funcline(f, tracepc))
+	printstring([]byte(" +"))
+	printhex(uint64(pc - f.entry))
+	printstring([]byte("\n"))
+}
+
+// traceback prints the frames of the current goroutine
+// from the function of pc whose frame is bp.
+// Frames of the runtime are hidden.
+func traceback(pc uintptr, bp uintptr) {
+	gp := getg()
+	printstring([]byte("\ngoroutine "))
+	printint(gp.id)
+	printstring([]byte(" [running]:\n"))
+	var faulted bool
+	for {
+		f := findfunc(pc)
+		if !isRuntimeFunc(f) {
+			if faulted {
+				// pc is the faulting instruction itself
+				printframe(f, pc, pc)
+			} else {
+				// pc is a return address
+				printframe(f, pc, pc-1)
+			}
+		}
+		faulted = f != nil && funcname(f) == "iruntime.sigpanic"
+		if bp == 0 {
+			break
+		}
+		next := loadWord(bp)
+		if next <= bp {
+			// the bottom of the stack
+			break
+		}
+		pc = loadWord(bp + 8)
+		bp = next
+	}
+	if gp.gopc != 0 {
+		f := findfunc(gp.gopc)
+		if f != nil {
+			printstring([]byte("created by "))
+			printstring([]byte(funcname(f)))
+			printstring([]byte(" in goroutine "))
+			printint(gp.parentGoid)
+			printstring([]byte("\n\t"))
+			printstring([]byte(funcfile(f)))
+			printstring([]byte(":"))
+			printint(funcline(f, gp.gopc-1))
+			printstring([]byte(" +"))
+			printhex(uint64(gp.gopc - f.entry))
+			printstring([]byte("\n"))
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package runtime
+
+const runeError = 0xFFFD
+
+// decode the UTF-8 encoded code point at s[k] for "for range" over a string.
+// returns the code point and the index of the next one.
+// An invalid encoding yields runeError and advances by one byte.
+func decoderune(s string, k int) (int32, int) {
+	b0 := s[k]
+	if b0 < 0x80 {
+		return int32(b0), k + 1
+	}
+
+	var size int
+	var r int32
+	// the range of the second byte
+	var lo byte = 0x80
+	var hi byte = 0xBF
+	switch {
+	case 0xC2 <= b0 && b0 <= 0xDF:
+		size = 2
+		r = int32(b0) - 0xC0
+	case 0xE0 <= b0 && b0 <= 0xEF:
+		size = 3
+		r = int32(b0) - 0xE0
+		if b0 == 0xE0 {
+			lo = 0xA0
+		} else if b0 == 0xED {
+			hi = 0x9F
+		}
+	case 0xF0 <= b0 && b0 <= 0xF4:
+		size = 4
+		r = int32(b0) - 0xF0
+		if b0 == 0xF0 {
+			lo = 0x90
+		} else if b0 == 0xF4 {
+			hi = 0x8F
+		}
+	default:
+		return runeError, k + 1
+	}
+	if k+size > len(s) {
+		return runeError, k + 1
+	}
+	for i := 1; i < size; i++ {
+		b := s[k+i]
+		if b < lo || hi < b {
+			return runeError, k + 1
+		}
+		lo = 0x80
+		hi = 0xBF
+		r = r*64 + (int32(b) - 0x80)
+	}
+	return r, k + size
+}
+
+// string(v) for an integer v: the UTF-8 encoding of the code point
+func intstring(v int) string {
+	if v < 0 || 0x10FFFF < v || (0xD800 <= v && v <= 0xDFFF) {
+		v = runeError
+	}
+	var buf []byte
+	switch {
+	case v < 0x80:
+		buf = append(buf, byte(v))
+	case v < 0x800:
+		buf = append(buf, byte(0xC0+v/64))
+		buf = append(buf, byte(0x80+v%64))
+	case v < 0x10000:
+		buf = append(buf, byte(0xE0+v/4096))
+		buf = append(buf, byte(0x80+(v/64)%64))
+		buf = append(buf, byte(0x80+v%64))
+	default:
+		buf = append(buf, byte(0xF0+v/262144))
+		buf = append(buf, byte(0x80+(v/4096)%64))
+		buf = append(buf, byte(0x80+(v/64)%64))
+		buf = append(buf, byte(0x80+v%64))
+	}
+	return string(buf)
+}
+
+// []rune(s): the code points of s
+func stringtoslicerune(s string) []int32 {
+	var n int
+	var k int
+	for k < len(s) {
+		_, k = decoderune(s, k)
+		n++
+	}
+	r := make([]int32, n, n)
+	k = 0
+	for i := 0; i < n; i++ {
+		var c int32
+		c, k = decoderune(s, k)
+		r[i] = c
+	}
+	return r
+}
+
+// string(r) for r of []rune: the concatenated UTF-8 encodings of the code points
+func slicerunetostring(r []int32) string {
+	var buf []byte
+	for i := 0; i < len(r); i++ {
+		buf = appendString(buf, intstring(int(r[i])))
+	}
+	return string(buf)
+}
@@ -4,6 +4,15 @@
   movq %rsp, %rbp
 .endm
 
+# give other goroutines a chance to run every iruntime.yieldTicks calls
+# %fs:32 is the tick counter of the current thread (iruntime.m.ticks)
+.macro YIELD_CHECK
+  decq %fs:32
+  jg 1f
+  callq iruntime.yieldTick
+1:
+.endm
+
 .macro POP_TO_ARG_0
   popq %rdi
 .endm
@@ -187,6 +196,25 @@
   movq $\n, %rax
 .endm
 
+# the assembler converts the literal
+.macro LOAD_FLOAT64 x
+  .pushsection .rodata
+  .p2align 3
+9999:
+  .double \x
+  .popsection
+  movq 9999b(%rip), %rax
+.endm
+
+.macro LOAD_FLOAT32 x
+  .pushsection .rodata
+  .p2align 2
+9999:
+  .float \x
+  .popsection
+  movl 9999b(%rip), %eax
+.endm
+
 .macro STORE_1_TO_LOCAL offset
   movb %al, \offset(%rbp)
 .endm
@@ -195,6 +223,10 @@
   movw %ax, \offset(%rbp)
 .endm
 
+.macro STORE_4_TO_LOCAL offset
+  movl %eax, \offset(%rbp)
+.endm
+
 .macro STORE_8_TO_LOCAL offset
   movq %rax, \offset(%rbp)
 .endm
@@ -207,14 +239,6 @@
   leaq \offset(%rbp), %rax
 .endm
 
-.macro LOAD_1_FROM_LOCAL_CAST offset
-  movsbq \offset(%rbp), %rax
-.endm
-
-.macro LOAD_2_FROM_LOCAL_CAST offset
-  movswq \offset(%rbp), %rax
-.endm
-
 .macro LOAD_1_FROM_LOCAL offset
   movb \offset(%rbp), %al
 .endm
@@ -223,6 +247,10 @@
   movw \offset(%rbp), %ax
 .endm
 
+.macro LOAD_4_FROM_LOCAL offset
+  movl \offset(%rbp), %eax
+.endm
+
 .macro LOAD_8_FROM_LOCAL offset
   movq \offset(%rbp), %rax
 .endm
@@ -235,16 +263,12 @@
   movw %ax, \varname+\offset(%rip)
 .endm
 
-.macro STORE_8_TO_GLOBAL varname, offset
-  movq %rax, \varname+\offset(%rip)
-.endm
-
-.macro LOAD_1_FROM_GLOBAL_CAST varname, offset=0
-  movsbq \varname+\offset(%rip), %rax
+.macro STORE_4_TO_GLOBAL varname, offset
+  movl %eax, \varname+\offset(%rip)
 .endm
 
-.macro LOAD_2_FROM_GLOBAL_CAST varname, offset=0
-  movswq \varname+\offset(%rip), %rax
+.macro STORE_8_TO_GLOBAL varname, offset
+  movq %rax, \varname+\offset(%rip)
 .endm
 
 .macro LOAD_1_FROM_GLOBAL varname, offset=0
@@ -255,6 +279,10 @@
   movw \varname+\offset(%rip), %ax
 .endm
 
+.macro LOAD_4_FROM_GLOBAL varname, offset=0
+  movl \varname+\offset(%rip), %eax
+.endm
+
 .macro LOAD_8_FROM_GLOBAL varname, offset=0
   movq \varname+\offset(%rip), %rax
 .endm
@@ -269,6 +297,10 @@
   movq (%rax), %rax
 .endm
 
+.macro LOAD_4_BY_DEREF
+  movl (%rax), %eax
+.endm
+
 .macro LOAD_1_BY_DEREF
   movsbq (%rax), %rax
 .endm
@@ -293,6 +325,26 @@
   movzbq %al, %rax
 .endm
 
+.macro CAST_INT8_TO_INT
+  movsbq %al, %rax
+.endm
+
+.macro CAST_UINT16_TO_INT
+  movzwq %ax, %rax
+.endm
+
+.macro CAST_INT16_TO_INT
+  movswq %ax, %rax
+.endm
+
+.macro CAST_UINT32_TO_INT
+  movl %eax, %eax
+.endm
+
+.macro CAST_INT32_TO_INT
+  movslq %eax, %rax
+.endm
+
 .macro CMP_EQ_ZERO
   cmpq $0, %rax
   sete %al
@@ -331,6 +383,107 @@
   imulq %rcx , %rax
 .endm
 
+# a zero divisor panics.
+.macro CHECK_DIVISOR
+  cmpq $0, %rcx
+  jne 1f
+  callq iruntime.panicdivide
+1:
+.endm
+
+# dividing the most negative number by -1 overflows in x86,
+# while it results in the number itself in Go.
+.macro DIV_FROM_STACK
+  popq %rcx
+  popq %rax
+  CHECK_DIVISOR
+  cmpq $-1, %rcx
+  jne 2f
+  negq %rax
+  movq $0, %rdx
+  jmp 3f
+2:
+  cqto
+  idivq %rcx
+3:
+.endm
+
+.macro UDIV_FROM_STACK
+  popq %rcx
+  popq %rax
+  CHECK_DIVISOR
+  movq $0, %rdx
+  divq %rcx
+.endm
+
+# the index in %rax and the length on the stack.
+# A negative index is out of range as an unsigned integer.
+.macro CHECK_INDEX
+  popq %rcx # len
+  cmpq %rcx, %rax
+  jb 1f
+  movq %rax, %rdi
+  movq %rcx, %rsi
+  callq iruntime.panicIndex
+1:
+.endm
+
+# low in %rdi, high in %rsi, max in %rdx and cap in %rcx.
+# max is high when it is omitted.
+# three: whether max is given, has_cap: whether it is a slice.
+.macro CHECK_SLICE three, has_cap
+  cmpq %rcx, %rdx
+  ja 1f
+  cmpq %rdx, %rsi
+  ja 1f
+  cmpq %rsi, %rdi
+  jbe 2f
+1:
+  movq $\three, %r8
+  movq $\has_cap, %r9
+  callq iruntime.panicSlice
+2:
+.endm
+
+.macro MOD_FROM_STACK
+  DIV_FROM_STACK
+  movq %rdx, %rax
+.endm
+
+.macro UMOD_FROM_STACK
+  UDIV_FROM_STACK
+  movq %rdx, %rax
+.endm
+
+# shifting by the width or more leaves no bits,
+# while x86 takes the count modulo 64.
+.macro SHL_FROM_STACK
+  popq %rcx # count
+  popq %rax
+  shlq %cl, %rax
+  movq $0, %rdx
+  cmpq $64, %rcx
+  cmovaeq %rdx, %rax
+.endm
+
+.macro SHR_FROM_STACK
+  popq %rcx # count
+  popq %rax
+  shrq %cl, %rax
+  movq $0, %rdx
+  cmpq $64, %rcx
+  cmovaeq %rdx, %rax
+.endm
+
+.macro SAR_FROM_STACK
+  popq %rcx # count
+  popq %rax
+  movq $63, %rdx
+  cmpq $64, %rcx
+  cmovaeq %rdx, %rcx
+  sarq %cl, %rax
+.endm
+
 .macro IMUL_NUMBER n
   imulq $\n , %rax
 .endm
@@ -347,6 +500,12 @@
   movw %cx, (%rax)
 .endm
 
+.macro STORE_4_INDIRECT_FROM_STACK
+  popq %rax # where
+  popq %rcx # what
+  movl %ecx, (%rax)
+.endm
+
 .macro STORE_8_INDIRECT_FROM_STACK
   popq %rax # where
   popq %rcx # what
@@ -376,3 +535,111 @@
   movq $0, %rbx
   callq \fname
 .endm
+
+# float operations.
+# A float64 value is kept in %rax, and a float32 value in %eax.
+
+.macro POP_FLOAT_OPERANDS
+  popq %rcx # right
+  popq %rax # left
+  movq %rax, %xmm0
+  movq %rcx, %xmm1
+.endm
+
+.macro CONVERT_INT_TO_FLOAT64
+  cvtsi2sdq %rax, %xmm0
+  movq %xmm0, %rax
+.endm
+
+.macro CONVERT_INT_TO_FLOAT32
+  cvtsi2ssq %rax, %xmm0
+  movd %xmm0, %eax
+.endm
+
+// A 64-bit unsigned value at or above 2^63 is halved with its lowest bit kept
+// so that it rounds correctly, and doubled after the conversion.
+.macro CONVERT_UINT_TO_FLOAT64
+  testq %rax, %rax
+  js 1f
+  cvtsi2sdq %rax, %xmm0
+  jmp 2f
+1:
+  movq %rax, %rcx
+  shrq %rcx
+  andq $1, %rax
+  orq %rax, %rcx
+  cvtsi2sdq %rcx, %xmm0
+  addsd %xmm0, %xmm0
+2:
+  movq %xmm0, %rax
+.endm
+
+.macro CONVERT_UINT_TO_FLOAT32
+  testq %rax, %rax
+  js 1f
+  cvtsi2ssq %rax, %xmm0
+  jmp 2f
+1:
+  movq %rax, %rcx
+  shrq %rcx
+  andq $1, %rax
+  orq %rax, %rcx
+  cvtsi2ssq %rcx, %xmm0
+  addss %xmm0, %xmm0
+2:
+  movd %xmm0, %eax
+.endm
+
+.macro CONVERT_FLOAT64_TO_INT
+  movq %rax, %xmm0
+  cvttsd2siq %xmm0, %rax
+.endm
+
+.macro CONVERT_FLOAT32_TO_INT
+  movd %eax, %xmm0
+  cvttss2siq %xmm0, %rax
+.endm
+
+// A value at or above 2^63 is converted after subtracting 2^63,
+// which is added back by flipping the highest bit.
+.macro CONVERT_FLOAT64_TO_UINT
+  movq %rax, %xmm0
+  movabsq $0x43e0000000000000, %rcx # 2^63
+  movq %rcx, %xmm1
+  ucomisd %xmm1, %xmm0
+  jae 1f
+  cvttsd2siq %xmm0, %rax
+  jmp 2f
+1:
+  subsd %xmm1, %xmm0
+  cvttsd2siq %xmm0, %rax
+  btcq $63, %rax
+2:
+.endm
+
+.macro CONVERT_FLOAT32_TO_UINT
+  movd %eax, %xmm0
+  movl $0x5f000000, %ecx # 2^63
+  movd %ecx, %xmm1
+  ucomiss %xmm1, %xmm0
+  jae 1f
+  cvttss2siq %xmm0, %rax
+  jmp 2f
+1:
+  subss %xmm1, %xmm0
+  cvttss2siq %xmm0, %rax
+  btcq $63, %rax
+2:
+.endm
+
+.macro CONVERT_FLOAT64_TO_FLOAT32
+  movq %rax, %xmm0
+  cvtsd2ss %xmm0, %xmm0
+  movd %xmm0, %eax
+.endm
+
+.macro CONVERT_FLOAT32_TO_FLOAT64
+  movd %eax, %xmm0
+  cvtss2sd %xmm0, %xmm0
+  movq %xmm0, %rax
+.endm
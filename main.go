@@ -6,8 +6,6 @@ import (
 	"os"
 )
 
-var GENERATION int = 1
-
 var debugMode = false // execute debugf() or not
 var debugToken = false
 
@@ -35,6 +35,7 @@ type AstFile struct {
 	dynamicTypes      []*Gtype
 	namedTypes        []*DeclType
 	methods           mapToIdentToMethods
+	funcLiterals      []*DeclFunc
 }
 
 type Expr interface {
@@ -69,8 +70,16 @@ type ExprNilLiteral struct {
 }
 
 type ExprNumberLiteral struct {
-	tok *Token
-	val int
+	tok    *Token
+	val    int
+	isRune bool // rune literal like 'a'
+}
+
+// the value is kept as written in the source, and converted by the assembler
+type ExprFloatLiteral struct {
+	tok   *Token
+	val   string
+	gtype *Gtype // nil means float64
 }
 
 type IrExprBoolVal struct {
@@ -117,6 +126,7 @@ type ExprVariable struct {
 	offset     int // for local variable
 	isGlobal   bool
 	isVariadic bool
+	isBoxed    bool // captured by a closure. The slot holds the address of a heap cell.
 }
 
 type ExprConstVariable struct {
@@ -125,6 +135,7 @@ type ExprConstVariable struct {
 	gtype     *Gtype
 	val       Expr // like ExprConstExpr ?
 	iotaIndex int  // for iota
+	repeated  bool // val is repeated from the previous spec
 }
 
 // ident( ___ )
@@ -224,9 +235,11 @@ type ForForClause struct {
 }
 
 const (
-	FOR_KIND_RANGE_MAP  int = 1
-	FOR_KIND_RANGE_LIST int = 2
-	FOR_KIND_CLIKE      int = 3
+	FOR_KIND_RANGE_MAP    int = 1
+	FOR_KIND_RANGE_LIST   int = 2
+	FOR_KIND_CLIKE        int = 3
+	FOR_KIND_RANGE_CHAN   int = 4
+	FOR_KIND_RANGE_STRING int = 5
 )
 
 type LoopLabels struct {
@@ -244,6 +257,8 @@ type StmtFor struct {
 	block  *StmtSatementList
 	labels *LoopLabels
 	outer  *StmtFor // to manage lables in nested for-statements
+	// variables declared by the for clause. They are renewed in every iteration.
+	loopvars []*ExprVariable
 }
 
 type StmtIf struct {
@@ -259,6 +274,7 @@ type StmtReturn struct {
 	exprs             []Expr
 	rettypes          []*Gtype
 	labelDeferHandler string
+	bufs              []*ExprVariable // invisible copies of struct and array results
 }
 
 type StmtInc struct {
@@ -287,8 +303,9 @@ type ImportDecl struct {
 }
 
 type StmtSatementList struct {
-	tok   *Token
-	stmts []Stmt
+	tok    *Token
+	stmts  []Stmt
+	rbrace *Token // the closing brace
 }
 
 type ExprFuncRef struct {
@@ -296,21 +313,57 @@ type ExprFuncRef struct {
 	funcdef *DeclFunc
 }
 
+// func(x int) int { ... }
+type ExprFuncLiteral struct {
+	tok     *Token
+	funcdef *DeclFunc
+}
+
+// a method value x.m bound to its receiver
+// It is lowered into a closure which captures a copy of x.
+type ExprMethodValue struct {
+	tok      *Token
+	receiver Expr
+	recvvar  *ExprVariable // holds the cell of the captured receiver
+	funcdef  *DeclFunc     // calls the method on the captured receiver
+}
+
+// a call of a func value
+// e.g. f(x) where f is a variable, or func(){...}()
+type ExprFuncValueCall struct {
+	tok  *Token
+	fn   Expr
+	args []Expr
+}
+
+// a variable of an outer function referred to by a function literal
+type Capture struct {
+	outer *ExprVariable // variable in the enclosing function
+	inner *ExprVariable // variable in the function literal
+}
+
 type DeclFunc struct {
-	tok       *Token
-	pkgPath   normalizedPackagePath
-	pkg       identifier
-	receiver  *ExprVariable
-	fname     identifier
+	tok         *Token
+	pkgPath     normalizedPackagePath
+	pkg         identifier
+	receiver    *ExprVariable
+	fname       identifier
 	builtinname identifier
-	rettypes  []*Gtype
-	params    []*ExprVariable
-	localvars []*ExprVariable
-	body      *StmtSatementList
-	stmtDefer *StmtDefer
+	rettypes    []*Gtype
+	params      []*ExprVariable
+	localvars   []*ExprVariable
+	body        *StmtSatementList
+	hasDefer    bool
 	// every function has a defer handler
 	labelDeferHandler string
 	prologue          Emitter
+	// for function literals
+	captures []*Capture
+	envvar   *ExprVariable // holds the closure object
+	// forwards a promoted method to an embedded field
+	isWrapper bool
+	// the promoted method needs an addressable receiver
+	wrapsPtrMethod bool
 }
 
 type TopLevelDecl struct {
@@ -382,14 +435,88 @@ type StmtBreak struct {
 	labels *LoopLabels
 }
 
+// L: stmt
+type StmtLabeled struct {
+	tok       *Token
+	name      identifier
+	asmLabel  string
+	stmt      Stmt
+	labels    *LoopLabels // labels of the for, switch or select statement for break L and continue L
+	isLoop    bool
+	scope     *Scope
+	declCount int // the number of variables declared in the scope before the label
+	used      bool
+}
+
+type StmtFallthrough struct {
+	tok     *Token
+	isValid bool   // at the end of a non-final clause of an expression switch
+	label   string // the label of the next clause
+}
+
+type StmtGoto struct {
+	tok    *Token
+	name   identifier
+	target *StmtLabeled
+	// the scopes enclosing the goto statement (innermost first)
+	// and the number of variables declared in each of them before the goto
+	scopes     []*Scope
+	declCounts []int
+}
+
 type StmtExpr struct {
 	tok  *Token
 	expr Expr
 }
 
-type StmtGo struct {
+// ch <- value
+type StmtSend struct {
 	tok   *Token
-	expr  Expr
+	ch    Expr
+	value Expr
+	buf   *ExprVariable // invisible variable of a wide element
+}
+
+func (stmt *StmtSend) token() *Token {
+	return stmt.tok
+}
+
+// <-ch
+type ExprChanRecv struct {
+	tok *Token
+	ch  Expr
+	buf *ExprVariable // invisible variable of a wide element
+	// set when the value has been already received by select
+	selectBuf *ExprVariable
+	selectOk  *ExprVariable
+}
+
+// https://golang.org/ref/spec#Select_statements
+type StmtSelect struct {
+	tok    *Token
+	cases  []*CommClause
+	dflt   *StmtSatementList
+	scases *ExprVariable // invisible array of cases passed to the runtime
+	okvar  *ExprVariable // invisible
+	labels *LoopLabels   // for break
+}
+
+type CommClause struct {
+	tok      *Token
+	comm     Stmt          // send or receive statement
+	buf      *ExprVariable // invisible buffer of the element to send or receive
+	send     *StmtSend
+	recv     *ExprChanRecv
+	compound *StmtSatementList
+}
+
+func (stmt *StmtSelect) token() *Token {
+	return stmt.tok
+}
+
+type StmtGo struct {
+	tok  *Token
+	expr Expr
 }
 
 func (stmt *StmtGo) token() *Token {
@@ -401,9 +528,8 @@ func (stmt *StmtGo) dump() {
 }
 
 type StmtDefer struct {
-	tok   *Token
-	expr  Expr
-	label string // start of defer
+	tok  *Token
+	expr Expr
 }
 
 // f( ,...slice)
@@ -425,18 +551,20 @@ type IrExprConversionToInterface struct {
 
 // ExprCaseClause or TypeCaseClause
 type ExprCaseClause struct {
-	tok      *Token
-	exprs    []Expr
-	gtypes   []*Gtype
-	compound *StmtSatementList
+	tok             *Token
+	exprs           []Expr
+	gtypes          []*Gtype
+	compound        *StmtSatementList
+	fallthroughStmt *StmtFallthrough // the last statement of the clause if it is fallthrough
 }
 
 // https://golang.org/ref/spec#Switch_statements
 type StmtSwitch struct {
-	tok   *Token
-	cond  Expr
-	cases []*ExprCaseClause
-	dflt  *StmtSatementList
+	tok    *Token
+	cond   Expr
+	cases  []*ExprCaseClause
+	dflt   *StmtSatementList
+	labels *LoopLabels // for break
 }
 
 type KeyedElement struct {
@@ -498,12 +626,16 @@ func (node *StmtAssignment) token() *Token   { return node.tok }
 func (node *StmtShortVarDecl) token() *Token { return node.tok }
 func (node *StmtContinue) token() *Token     { return node.tok }
 func (node *StmtBreak) token() *Token        { return node.tok }
+func (node *StmtLabeled) token() *Token      { return node.tok }
+func (node *StmtGoto) token() *Token         { return node.tok }
+func (node *StmtFallthrough) token() *Token  { return node.tok }
 func (node *StmtExpr) token() *Token         { return node.tok }
 func (node *StmtDefer) token() *Token        { return node.tok }
 func (node *StmtSwitch) token() *Token       { return node.tok }
 
 func (node *ExprNilLiteral) token() *Token              { return node.tok }
 func (node *ExprNumberLiteral) token() *Token           { return node.tok }
+func (node *ExprFloatLiteral) token() *Token            { return node.tok }
 func (node *ExprStringLiteral) token() *Token           { return node.tok }
 func (node *ExprVariable) token() *Token                { return node.tok }
 func (node *ExprConstVariable) token() *Token           { return node.tok }
@@ -514,6 +646,9 @@ func (node *ExprUop) token() *Token                     { return node.tok }
 func (node *ForRangeClause) token() *Token              { return node.tok }
 func (node *ForForClause) token() *Token                { return node.tok }
 func (node *ExprFuncRef) token() *Token                 { return node.tok }
+func (node *ExprFuncLiteral) token() *Token             { return node.tok }
+func (node *ExprMethodValue) token() *Token             { return node.tok }
+func (node *ExprFuncValueCall) token() *Token           { return node.tok }
 func (node *ExprSlice) token() *Token                   { return node.tok }
 func (node *ExprIndex) token() *Token                   { return node.tok }
 func (node *ExprArrayLiteral) token() *Token            { return node.tok }
@@ -529,6 +664,7 @@ func (node *ExprTypeSwitchGuard) token() *Token         { return node.tok }
 func (node *ExprMapLiteral) token() *Token              { return node.tok }
 func (node *ExprLen) token() *Token                     { return node.tok }
 func (node *ExprCap) token() *Token                     { return node.tok }
+func (node *ExprChanRecv) token() *Token                { return node.tok }
 func (node *IrExprConversionToInterface) token() *Token { return node.tok }
 func (e *IrStringConcat) token() *Token                 { return e.tok }
 func (e *IrExprStringComparison) token() *Token         { return e.tok }
@@ -543,28 +679,57 @@ type IrStmtForRangeList struct {
 	incr      Stmt
 	block     *StmtSatementList
 	labels    *LoopLabels
+	loopvars  []*ExprVariable
 }
 
 type IrStmtRangeMap struct {
-	tok        *Token
-	labels     *LoopLabels
-	rangeexpr  Expr
-	indexvar   Expr
-	valuevar   Expr
-	mapCounter *ExprVariable
-	initstmt   Stmt
-	condition  Expr
-	indexIncr  Stmt
-	block      *StmtSatementList
+	tok       *Token
+	labels    *LoopLabels
+	rangeexpr Expr
+	indexvar  Expr
+	valuevar  Expr
+	mapIter   *ExprVariable // holds *iruntime.hiter
+	block     *StmtSatementList
+	loopvars  []*ExprVariable
+}
+
+type IrStmtRangeString struct {
+	tok       *Token
+	labels    *LoopLabels
+	rangeexpr Expr
+	indexvar  Expr
+	valuevar  Expr
+	next      *ExprVariable // the index of the next code point
+	block     *StmtSatementList
+	loopvars  []*ExprVariable
+}
+
+type IrStmtRangeChan struct {
+	tok      *Token
+	labels   *LoopLabels
+	recv     *StmtAssignment // v, ok = <-ch
+	okvar    *ExprVariable
+	block    *StmtSatementList
+	loopvars []*ExprVariable
 }
 
 type IrStmtClikeFor struct {
-	tok    *Token
-	cls    *ForForClause
-	block  *StmtSatementList
-	labels *LoopLabels
+	tok      *Token
+	cls      *ForForClause
+	block    *StmtSatementList
+	labels   *LoopLabels
+	loopvars []*ExprVariable
+}
+
+// allocate new heap cells for captured variables at their declaration
+type IrStmtNewCells struct {
+	tok       *Token
+	variables []*ExprVariable
 }
 
 func (node *IrStmtForRangeList) token() *Token { return node.tok }
 func (node *IrStmtRangeMap) token() *Token     { return node.tok }
 func (node *IrStmtClikeFor) token() *Token     { return node.tok }
+func (node *IrStmtRangeChan) token() *Token    { return node.tok }
+func (node *IrStmtRangeString) token() *Token  { return node.tok }
+func (node *IrStmtNewCells) token() *Token     { return node.tok }
@@ -17,6 +17,8 @@ type parser struct {
 	inCase         int  // > 0  while in reading case compound stmts
 	constSpecIndex int
 	currentForStmt *StmtFor
+	fnLabels       *funcLabels
+	funcLits       []*funcLitContext // function literals being parsed (innermost last)
 
 	// per file
 	parsingDir          string // current dir
@@ -33,6 +35,26 @@ type parser struct {
 	namedTypes          []*DeclType
 	dynamicTypes        []*Gtype
 	methods             map[identifier]methods
+	funcLiterals        []*DeclFunc
+}
+
+// context of a function literal being parsed
+type funcLitContext struct {
+	funcdef *DeclFunc
+	scope   *Scope // the outermost scope of the function literal
+}
+
+// sequence number to name function literals
+var funcLitSeq int
+
+// labels and jumps in a function body
+type funcLabels struct {
+	defined      []*StmtLabeled
+	gotos        []*StmtGoto
+	enclosing    []*StmtLabeled // labeled statements being parsed (innermost last)
+	breakTargets []*LoopLabels  // for, switch and select statements being parsed (innermost last)
+	fallthroughs []*StmtFallthrough
+	next         *LoopLabels // labels of the statement after a label
 }
 
 func (p *parser) clearLocalState() {
@@ -42,6 +64,7 @@ func (p *parser) clearLocalState() {
 	p.inCase = 0
 	p.constSpecIndex = 0
 	p.currentForStmt = nil
+	p.fnLabels = nil
 }
 
 type methods map[identifier]*ExprFuncRef
@@ -130,9 +153,7 @@ func (p *parser) traceIn(funcname string) int {
 	if !debugParser {
 		return 0
 	}
-	if GENERATION == 1 {
-		funcname = getCallerName(2)
-	}
+	funcname = getCallerName(2)
 	debugf("func %s is gonna read %s", funcname, p.peekToken().sval)
 	debugNest++
 	return 0
@@ -145,9 +166,7 @@ func (p *parser) traceOut(funcname string) {
 	if r := recover(); r != nil {
 		os.Exit(1)
 	}
-	if GENERATION == 1 {
-		funcname = getCallerName(2)
-	}
+	funcname = getCallerName(2)
 	debugNest--
 	debugf("func %s end after %s", funcname, p.lastToken().sval)
 }
@@ -238,6 +257,9 @@ func (p *parser) parseIdentExpr(firstIdentToken *Token) Expr {
 		p.addStringLiteral(sliteral)
 	}
 	p.tryResolve(pkg, rel)
+	if len(pkg) == 0 {
+		p.captureIfNeeded(rel)
+	}
 
 	next := p.peekToken()
 
@@ -439,6 +461,16 @@ func (p *parser) succeedingExpr(e Expr) Expr {
 		// (expr)[i]
 		e = p.parseIndexOrSliceExpr(e)
 		return p.succeedingExpr(e)
+	} else if next.isPunct("(") {
+		// (expr)(args)
+		p.skip()
+		_, args := p.readFuncallArgs(false)
+		r = &ExprFuncValueCall{
+			tok:  next,
+			fn:   e,
+			args: args,
+		}
+		return p.succeedingExpr(r)
 	} else {
 		// https://golang.org/ref/spec#OperandName
 		r = e
@@ -479,6 +511,29 @@ func (p *parser) parseMapType() *Gtype {
 	}
 }
 
+// https://golang.org/ref/spec#Channel_types
+// The direction of a channel is not checked.
+func (p *parser) parseChanType() *Gtype {
+	p.traceIn(__func__)
+	defer p.traceOut(__func__)
+	if p.peekToken().isPunct("<-") {
+		// <-chan T
+		p.skip()
+		p.expectKeyword("chan")
+	} else {
+		p.expectKeyword("chan")
+		if p.peekToken().isPunct("<-") {
+			// chan<- T
+			p.skip()
+		}
+	}
+	elementType := p.parseType()
+	return &Gtype{
+		kind:        G_CHAN,
+		elementType: elementType,
+	}
+}
+
 // https://golang.org/ref/spec#Conversions
 func (p *parser) parseTypeConversion(gtype *Gtype) Expr {
 	p.traceIn(__func__)
@@ -519,12 +574,18 @@ func (p *parser) parsePrim() Expr {
 			tok: tok,
 			val: ival,
 		}
+	case tok.isTypeFloat(): // float literal
+		p.skip()
+		return &ExprFloatLiteral{
+			tok: tok,
+			val: tok.sval,
+		}
 	case tok.isTypeChar(): // char literal
 		p.skip()
-		c := tok.sval[0]
 		return &ExprNumberLiteral{
-			tok: tok,
-			val: int(c),
+			tok:    tok,
+			val:    tok.getIntval(),
+			isRune: true,
 		}
 	case tok.isKeyword("map"): // map literal
 		ptok := tok
@@ -591,6 +652,8 @@ func (p *parser) parsePrim() Expr {
 		default:
 			errorft(tok, "internal error")
 		}
+	case tok.isKeyword("func"): // function literal
+		return p.parseFuncLiteral()
 	case tok.isTypeIdent():
 		p.skip()
 		return p.parseIdentExpr(tok)
@@ -705,6 +768,8 @@ func (p *parser) parseUnaryExpr() Expr {
 				kind:     G_NAMED,
 				relation: strctliteral.strctname,
 			})
+			// the pointer type may appear only in &T{}
+			p.registerDynamicType(uop.getGtype())
 		}
 		return uop
 	case tok.isPunct("*"):
@@ -728,6 +793,12 @@ func (p *parser) parseUnaryExpr() Expr {
 			op:      tok.sval,
 			operand: p.parsePrim(),
 		}
+	case tok.isPunct("<-"):
+		p.skip()
+		return &ExprChanRecv{
+			tok: tok,
+			ch:  p.parseUnaryExpr(),
+		}
 	default:
 		return p.parsePrim()
 	}
@@ -744,7 +815,7 @@ func priority(op string) int {
 		return 11
 	case "/", "%":
 		return 15
-	case "*":
+	case "*", "<<", ">>":
 		return 20
 	default:
 		errorf("unkown operator %s", op)
@@ -772,6 +843,8 @@ var binops = []string{
 	"||",
 	"/",
 	"%",
+	"<<",
+	">>",
 }
 
 func (p *parser) parseExprInt(prior int) Expr {
@@ -890,10 +963,11 @@ func (p *parser) parseType() *Gtype {
 				return p.registerDynamicType(gtype)
 			}
 		} else if tok.isKeyword("interface") {
-			p.skip()
-			p.expect("{")
-			p.expect("}")
-			return gInterface
+			gtype = p.parseInterfaceType()
+			if len(gtype.imethods) == 0 && len(gtype.embeddeds) == 0 {
+				return gInterface
+			}
+			return p.registerDynamicType(gtype)
 		} else if tok.isPunct("*") {
 			p.skip()
 			// pointer
@@ -908,6 +982,12 @@ func (p *parser) parseType() *Gtype {
 		} else if tok.isKeyword("map") {
 			gtype = p.parseMapType()
 			return p.registerDynamicType(gtype)
+		} else if tok.isKeyword("func") {
+			gtype = p.parseFuncType()
+			return p.registerDynamicType(gtype)
+		} else if tok.isKeyword("chan") || tok.isPunct("<-") {
+			gtype = p.parseChanType()
+			return p.registerDynamicType(gtype)
 		} else if tok.isPunct("[") {
 			p.skip()
 			// array or slice
@@ -927,9 +1007,13 @@ func (p *parser) parseType() *Gtype {
 				// array
 				p.expect("]")
 				typ := p.parseType()
+				var length int
+				if !tok.isPunct("...") {
+					length = tok.getIntval()
+				}
 				gtype = &Gtype{
 					kind:        G_ARRAY,
-					length:      tok.getIntval(),
+					length:      length,
 					elementType: typ,
 				}
 				return p.registerDynamicType(gtype)
@@ -999,11 +1083,13 @@ func (p *parser) parseVarDecl() *DeclVar {
 func (p *parser) parseConstDeclSingle(lastExpr Expr, lastGtype *Gtype, iotaIndex int) *ExprConstVariable {
 	p.traceIn(__func__)
 	defer p.traceOut(__func__)
+	ptok := p.peekToken()
 	newName := p.expectIdent()
 
 	// Type or "=" or ";"
 	var val Expr
 	var gtype *Gtype
+	var repeated bool
 	if !p.peekToken().isPunct("=") && !p.peekToken().isPunct(";") {
 		// expect Type
 		gtype = p.parseType()
@@ -1011,6 +1097,7 @@ func (p *parser) parseConstDeclSingle(lastExpr Expr, lastGtype *Gtype, iotaIndex
 
 	if p.peekToken().isPunct(";") && lastExpr != nil {
 		val = lastExpr
+		repeated = true
 	} else {
 		p.expect("=")
 		val = p.parseExpr()
@@ -1022,9 +1109,11 @@ func (p *parser) parseConstDeclSingle(lastExpr Expr, lastGtype *Gtype, iotaIndex
 	}
 
 	variable := &ExprConstVariable{
+		tok:       ptok,
 		name:      newName,
 		val:       val,
 		iotaIndex: iotaIndex,
+		repeated:  repeated,
 		gtype:     gtype,
 	}
 
@@ -1048,8 +1137,8 @@ func (p *parser) parseConstDecl() *DeclConst {
 		for {
 			// multi definitions
 			cnst := p.parseConstDeclSingle(lastExpr, lastGtype, iotaIndex)
-			if cnst.getGtype() != nil {
-				lastGtype = cnst.getGtype()
+			if cnst.gtype != nil {
+				lastGtype = cnst.gtype
 			}
 			lastExpr = cnst.val
 			iotaIndex++
@@ -1082,6 +1171,25 @@ func (p *parser) exitScope() {
 
 func (p *parser) exitForBlock() {
 	p.currentForStmt = p.currentForStmt.outer
+	p.exitBreakable()
+}
+
+// returns the labels of a for, switch or select statement.
+// They are shared with the label of the statement if any.
+func (p *parser) enterBreakable() *LoopLabels {
+	fl := p.fnLabels
+	labels := fl.next
+	if labels == nil {
+		labels = &LoopLabels{}
+	}
+	fl.next = nil
+	fl.breakTargets = append(fl.breakTargets, labels)
+	return labels
+}
+
+func (p *parser) exitBreakable() {
+	fl := p.fnLabels
+	fl.breakTargets = fl.breakTargets[:len(fl.breakTargets)-1]
 }
 
 // https://golang.org/ref/spec#For_statements
@@ -1093,7 +1201,7 @@ func (p *parser) parseForStmt() *StmtFor {
 	var r = &StmtFor{
 		tok:    ptok,
 		outer:  p.currentForStmt,
-		labels: &LoopLabels{},
+		labels: p.enterBreakable(),
 	}
 	p.currentForStmt = r
 	p.enterNewScope(identifier("for"))
@@ -1124,9 +1232,14 @@ func (p *parser) parseForStmt() *StmtFor {
 	}
 	if p.peekToken().isPunct("{") {
 		// single cond
-		r.cls = &ForForClause{
-			cond: cond,
+		cls := &ForForClause{}
+		if cond != nil {
+			cls.cond = &StmtExpr{
+				tok:  cond.token(),
+				expr: cond,
+			}
 		}
+		r.cls = cls
 	} else {
 		// for clause or range clause
 		var initstmt Stmt
@@ -1149,10 +1262,15 @@ func (p *parser) parseForStmt() *StmtFor {
 					p.shortVarDecl(lefts[1])
 				}
 
-				r := p.parseForRange(lefts, true)
-				return r
+				return p.parseForRange(lefts, true)
 			} else {
-				initstmt = p.parseShortAssignment(lefts)
+				decl := p.parseShortAssignment(lefts)
+				for _, left := range decl.lefts {
+					rel := left.(*Relation)
+					variable := rel.expr.(*ExprVariable)
+					r.loopvars = append(r.loopvars, variable)
+				}
+				initstmt = decl
 			}
 		}
 
@@ -1197,9 +1315,10 @@ func (p *parser) parseForRange(exprs []Expr, infer bool) *StmtFor {
 	rangeExpr := p.parseExpr()
 	p.requireBlock = false
 	p.expect("{")
+	// this replaces the StmtFor made by parseForStmt
 	var r = &StmtFor{
 		tok:   tokRange,
-		outer: p.currentForStmt,
+		outer: p.currentForStmt.outer,
 		rng: &ForRangeClause{
 			tok:                 tokRange,
 			invisibleMapCounter: p.newVariable(identifier(""), gInt),
@@ -1207,11 +1326,16 @@ func (p *parser) parseForRange(exprs []Expr, infer bool) *StmtFor {
 			valuevar:            eValuevar,
 			rangeexpr:           rangeExpr,
 		},
-		labels: &LoopLabels{},
+		labels: p.currentForStmt.labels,
 	}
 	p.currentForStmt = r
 	if infer {
 		p.uninferredLocals = append(p.uninferredLocals, r.rng)
+		for _, e := range exprs {
+			rel := e.(*Relation)
+			variable := rel.expr.(*ExprVariable)
+			r.loopvars = append(r.loopvars, variable)
+		}
 	}
 	r.block = p.parseCompoundStmt()
 	p.exitScope()
@@ -1335,6 +1459,14 @@ func (p *parser) parseAssignmentOperation(left Expr, assignop string) *StmtAssig
 		op = "-"
 	case "*=":
 		op = "*"
+	case "/=":
+		op = "/"
+	case "%=":
+		op = "%"
+	case "<<=":
+		op = "<<"
+	case ">>=":
+		op = ">>"
 	default:
 		errorft(ptok, "internal error")
 	}
@@ -1400,8 +1532,9 @@ func (p *parser) parseSwitchStmt() Stmt {
 
 	p.expect("{")
 	r := &StmtSwitch{
-		tok:  ptok,
-		cond: cond,
+		tok:    ptok,
+		cond:   cond,
+		labels: p.enterBreakable(),
 	}
 
 	for {
@@ -1438,8 +1571,11 @@ func (p *parser) parseSwitchStmt() Stmt {
 				}
 			}
 			ptok := p.expect(":")
+			// each clause is an implicit block
+			p.enterNewScope(identifier("case"))
 			p.inCase++
 			compound := p.parseCompoundStmt()
+			p.exitScope()
 			casestmt := &ExprCaseClause{
 				tok:      ptok,
 				exprs:    exprs,
@@ -1454,7 +1590,9 @@ func (p *parser) parseSwitchStmt() Stmt {
 		} else if tok.isKeyword("default") {
 			p.skip()
 			p.expect(":")
+			p.enterNewScope(identifier("default"))
 			compound := p.parseCompoundStmt()
+			p.exitScope()
 			r.dflt = compound
 			break
 		} else {
@@ -1462,9 +1600,105 @@ func (p *parser) parseSwitchStmt() Stmt {
 		}
 	}
 
+	p.exitBreakable()
+	p.checkFallthrough(r)
 	return r
 }
 
+// https://golang.org/ref/spec#Fallthrough_statements
+// "fallthrough" may be used only as the final statement in a non-final clause of an expression switch.
+func (p *parser) checkFallthrough(r *StmtSwitch) {
+	for i, cse := range r.cases {
+		ft := lastFallthrough(cse.compound)
+		if ft == nil {
+			continue
+		}
+		if r.isTypeSwitch() {
+			errorft(ft.token(), "cannot fallthrough in type switch")
+		}
+		if i == len(r.cases)-1 && r.dflt == nil {
+			errorft(ft.token(), "cannot fallthrough final case in switch")
+		}
+		ft.isValid = true
+		cse.fallthroughStmt = ft
+	}
+	if ft := lastFallthrough(r.dflt); ft != nil {
+		errorft(ft.token(), "cannot fallthrough final case in switch")
+	}
+}
+
+func lastFallthrough(compound *StmtSatementList) *StmtFallthrough {
+	if compound == nil || len(compound.stmts) == 0 {
+		return nil
+	}
+	ft, ok := compound.stmts[len(compound.stmts)-1].(*StmtFallthrough)
+	if !ok {
+		return nil
+	}
+	return ft
+}
+
+// https://golang.org/ref/spec#Select_statements
+func (p *parser) parseSelectStmt() *StmtSelect {
+	p.traceIn(__func__)
+	defer p.traceOut(__func__)
+	ptok := p.expectKeyword("select")
+	p.expect("{")
+	r := &StmtSelect{
+		tok:    ptok,
+		labels: p.enterBreakable(),
+	}
+	if p.peekToken().isPunct("}") {
+		// empty select blocks forever
+		p.skip()
+	} else {
+		p.parseCommClauses(r)
+	}
+	p.exitBreakable()
+	r.scases = p.newVariable(identifier(""), &Gtype{kind: G_ARRAY, length: 3 * len(r.cases), elementType: gInt})
+	r.okvar = p.newVariable(identifier(""), gBool)
+	return r
+}
+
+func (p *parser) parseCommClauses(r *StmtSelect) {
+	p.traceIn(__func__)
+	defer p.traceOut(__func__)
+	for {
+		tok := p.readToken()
+		if tok.isKeyword("case") {
+			p.enterNewScope(identifier("case"))
+			comm := p.parseStmt()
+			ptok := p.expect(":")
+			p.inCase++
+			compound := p.parseCompoundStmt()
+			p.inCase--
+			p.exitScope()
+			clause := &CommClause{
+				tok:      ptok,
+				comm:     comm,
+				buf:      p.newVariable(identifier(""), &Gtype{kind: G_ARRAY, length: 3, elementType: gInt}),
+				compound: compound,
+			}
+			r.cases = append(r.cases, clause)
+		} else if tok.isKeyword("default") {
+			if r.dflt != nil {
+				errorft(tok, "multiple defaults in select")
+			}
+			p.expect(":")
+			p.inCase++
+			r.dflt = p.parseCompoundStmt()
+			p.inCase--
+		} else {
+			errorft(tok, "unexpected token in select statement: %s", tok.String())
+		}
+		// the compound statement stops before the next clause or consumes the closing brace
+		next := p.peekToken()
+		if !next.isKeyword("case") && !next.isKeyword("default") {
+			return
+		}
+	}
+}
+
 func (p *parser) parseDeferStmt() *StmtDefer {
 	p.traceIn(__func__)
 	defer p.traceOut(__func__)
@@ -1475,10 +1709,167 @@ func (p *parser) parseDeferStmt() *StmtDefer {
 		tok:  ptok,
 		expr: callExpr,
 	}
-	p.currentFunc.stmtDefer = stmtDefer
+	p.currentFunc.hasDefer = true
 	return stmtDefer
 }
 
+// https://golang.org/ref/spec#Labeled_statements
+func (p *parser) parseLabeledStmt() *StmtLabeled {
+	p.traceIn(__func__)
+	defer p.traceOut(__func__)
+	tok := p.readToken()
+	p.expect(":")
+	fl := p.fnLabels
+	name := tok.getIdent()
+	for _, lbl := range fl.defined {
+		if string(lbl.name) == string(name) {
+			errorft(tok, "label %s already defined", name)
+		}
+	}
+	r := &StmtLabeled{
+		tok:       tok,
+		name:      name,
+		asmLabel:  makeLabel(),
+		scope:     p.currentScope,
+		declCount: p.currentScope.nvars,
+	}
+	fl.defined = append(fl.defined, r)
+
+	next := p.peekToken()
+	if next.isPunct("}") || next.isSemicolon() {
+		// empty statement
+		return r
+	}
+	if next.isKeyword("for") || next.isKeyword("switch") || next.isKeyword("select") {
+		r.labels = &LoopLabels{}
+		r.isLoop = next.isKeyword("for")
+		fl.next = r.labels
+	}
+	fl.enclosing = append(fl.enclosing, r)
+	r.stmt = p.parseStmt()
+	fl.enclosing = fl.enclosing[:len(fl.enclosing)-1]
+	return r
+}
+
+// find the label of a statement enclosing the current position
+func (p *parser) findEnclosingLabel(name identifier) *StmtLabeled {
+	for _, lbl := range p.fnLabels.enclosing {
+		if string(lbl.name) == string(name) {
+			lbl.used = true
+			return lbl
+		}
+	}
+	return nil
+}
+
+// https://golang.org/ref/spec#Break_statements
+func (p *parser) parseBreakStmt() *StmtBreak {
+	ptok := p.expectKeyword("break")
+	breakTargets := p.fnLabels.breakTargets
+	if p.peekToken().isTypeIdent() {
+		tok := p.readToken()
+		lbl := p.findEnclosingLabel(tok.getIdent())
+		if lbl == nil || lbl.labels == nil {
+			errorft(tok, "invalid break label %s", tok.getIdent())
+		}
+		return &StmtBreak{
+			tok:    ptok,
+			labels: lbl.labels,
+		}
+	}
+	if len(breakTargets) == 0 {
+		errorft(ptok, "break is not in a loop, switch, or select")
+	}
+	return &StmtBreak{
+		tok:    ptok,
+		labels: breakTargets[len(breakTargets)-1],
+	}
+}
+
+// https://golang.org/ref/spec#Continue_statements
+func (p *parser) parseContinueStmt() *StmtContinue {
+	ptok := p.expectKeyword("continue")
+	if p.peekToken().isTypeIdent() {
+		tok := p.readToken()
+		lbl := p.findEnclosingLabel(tok.getIdent())
+		if lbl == nil || !lbl.isLoop {
+			errorft(tok, "invalid continue label %s", tok.getIdent())
+		}
+		return &StmtContinue{
+			tok:    ptok,
+			labels: lbl.labels,
+		}
+	}
+	if p.currentForStmt == nil {
+		errorft(ptok, "continue is not in a loop")
+	}
+	return &StmtContinue{
+		tok:    ptok,
+		labels: p.currentForStmt.labels,
+	}
+}
+
+// https://golang.org/ref/spec#Goto_statements
+func (p *parser) parseGotoStmt() *StmtGoto {
+	ptok := p.expectKeyword("goto")
+	tok := p.readToken()
+	if !tok.isTypeIdent() {
+		errorft(tok, "identifier expected after goto")
+	}
+	r := &StmtGoto{
+		tok:  ptok,
+		name: tok.getIdent(),
+	}
+	for sc := p.currentScope; sc != nil; sc = sc.outer {
+		r.scopes = append(r.scopes, sc)
+		r.declCounts = append(r.declCounts, sc.nvars)
+	}
+	fl := p.fnLabels
+	fl.gotos = append(fl.gotos, r)
+	return r
+}
+
+// resolve goto statements and check the jumps at the end of a function body
+func (p *parser) resolveLabels() {
+	fl := p.fnLabels
+	for _, stmt := range fl.gotos {
+		for _, lbl := range fl.defined {
+			if string(lbl.name) == string(stmt.name) {
+				stmt.target = lbl
+			}
+		}
+		target := stmt.target
+		if target == nil {
+			errorft(stmt.token(), "label %s not defined", stmt.name)
+		}
+		target.used = true
+		// Executing the goto must not cause any variables to come into scope
+		// that were not already in scope at the point of the goto.
+		var found bool
+		for i, sc := range stmt.scopes {
+			if sc == target.scope {
+				found = true
+				if target.declCount > stmt.declCounts[i] {
+					errorft(stmt.token(), "goto %s jumps over variable declaration", stmt.name)
+				}
+			}
+		}
+		if !found {
+			errorft(stmt.token(), "goto %s jumps into block", stmt.name)
+		}
+	}
+	for _, lbl := range fl.defined {
+		if !lbl.used {
+			errorft(lbl.token(), "label %s defined and not used", lbl.name)
+		}
+	}
+	for _, ft := range fl.fallthroughs {
+		if !ft.isValid {
+			errorft(ft.token(), "fallthrough statement out of place")
+		}
+	}
+}
+
 // this is in function scope
 func (p *parser) parseStmt() Stmt {
 	p.traceIn(__func__)
@@ -1499,18 +1890,24 @@ func (p *parser) parseStmt() Stmt {
 		return p.parseReturnStmt()
 	} else if tok.isKeyword("switch") {
 		return p.parseSwitchStmt()
+	} else if tok.isKeyword("select") {
+		return p.parseSelectStmt()
 	} else if tok.isKeyword("continue") {
-		ptok := p.expectKeyword("continue")
-		return &StmtContinue{
-			tok:    ptok,
-			labels: p.currentForStmt.labels,
-		}
+		return p.parseContinueStmt()
 	} else if tok.isKeyword("break") {
-		ptok := p.expectKeyword("break")
-		return &StmtBreak{
-			tok:    ptok,
-			labels: p.currentForStmt.labels,
-		}
+		return p.parseBreakStmt()
+	} else if tok.isKeyword("goto") {
+		return p.parseGotoStmt()
+	} else if tok.isKeyword("fallthrough") {
+		ptok := p.expectKeyword("fallthrough")
+		r := &StmtFallthrough{
+			tok: ptok,
+		}
+		fl := p.fnLabels
+		fl.fallthroughs = append(fl.fallthroughs, r)
+		return r
+	} else if tok.isTypeIdent() && p.peek2Token().isPunct(":") {
+		return p.parseLabeledStmt()
 	} else if tok.isKeyword("defer") {
 		return p.parseDeferStmt()
 	} else if tok.isKeyword("go") {
@@ -1537,9 +1934,17 @@ func (p *parser) parseStmt() Stmt {
 	} else if tok2.isPunct(":=") {
 		// Single value ShortVarDecl
 		return p.parseShortAssignment([]Expr{expr1})
-	} else if tok2.isPunct("+=") || tok2.isPunct("-=") || tok2.isPunct("*=") {
+	} else if tok2.isPunct("+=") || tok2.isPunct("-=") || tok2.isPunct("*=") ||
+		tok2.isPunct("/=") || tok2.isPunct("%=") || tok2.isPunct("<<=") || tok2.isPunct(">>=") {
 		p.skip()
 		return p.parseAssignmentOperation(expr1, tok2.sval)
+	} else if tok2.isPunct("<-") {
+		p.skip()
+		return &StmtSend{
+			tok:   tok2,
+			ch:    expr1,
+			value: p.parseExpr(),
+		}
 	} else if tok2.isPunct("++") {
 		p.skip()
 		return &StmtInc{
@@ -1554,7 +1959,7 @@ func (p *parser) parseStmt() Stmt {
 		}
 	} else {
 		return &StmtExpr{
-			tok:  tok2,
+			tok:  tok,
 			expr: expr1,
 		}
 	}
@@ -1572,6 +1977,7 @@ func (p *parser) parseCompoundStmt() *StmtSatementList {
 		tok := p.peekToken()
 		if tok.isPunct("}") {
 			p.skip()
+			r.rbrace = tok
 			return r
 		}
 		if p.inCase > 0 && (tok.isKeyword("case") || tok.isKeyword("default")) {
@@ -1591,6 +1997,15 @@ func (p *parser) parseFuncSignature() (*Token, []*ExprVariable, []*Gtype) {
 	defer p.traceOut(__func__)
 
 	fnameToken := p.readToken()
+	params, rettypes := p.parseSignature()
+	return fnameToken, params, rettypes
+}
+
+// https://golang.org/ref/spec#Signature
+func (p *parser) parseSignature() ([]*ExprVariable, []*Gtype) {
+	p.traceIn(__func__)
+	defer p.traceOut(__func__)
+
 	p.expect("(")
 
 	var params []*ExprVariable
@@ -1641,7 +2056,7 @@ func (p *parser) parseFuncSignature() (*Token, []*ExprVariable, []*Gtype) {
 
 	next := p.peekToken()
 	if next.isPunct("{") || next.isSemicolon() {
-		return fnameToken, params, nil
+		return params, nil
 	}
 
 	var rettypes []*Gtype
@@ -1665,7 +2080,190 @@ func (p *parser) parseFuncSignature() (*Token, []*ExprVariable, []*Gtype) {
 		rettypes = []*Gtype{p.parseType()}
 	}
 
-	return fnameToken, params, rettypes
+	return params, rettypes
+}
+
+// https://golang.org/ref/spec#Function_types
+func (p *parser) parseFuncType() *Gtype {
+	p.traceIn(__func__)
+	defer p.traceOut(__func__)
+	p.expectKeyword("func")
+
+	p.expect("(")
+	params := p.parseParameterTypes()
+	var rettypes []*Gtype
+	next := p.peekToken()
+	if next.isPunct("(") {
+		p.skip()
+		results := p.parseParameterTypes()
+		for _, result := range results {
+			rettypes = append(rettypes, result.gtype)
+		}
+	} else if next.isTypeStart() {
+		rettypes = []*Gtype{p.parseType()}
+	}
+
+	return &Gtype{
+		kind: G_FUNC,
+		sig: &signature{
+			params:   params,
+			rettypes: rettypes,
+		},
+	}
+}
+
+// parse "(int, string)" or "(a int, b string)" in a func type until ")".
+// Parameter names are ignored.
+func (p *parser) parseParameterTypes() []*ExprVariable {
+	var params []*ExprVariable
+	for {
+		tok := p.peekToken()
+		if tok.isPunct(")") {
+			p.skip()
+			break
+		}
+		next := p.peek2Token()
+		if tok.isTypeIdent() && !next.isPunct(",") && !next.isPunct(")") && !next.isPunct(".") {
+			// skip the parameter name
+			p.skip()
+		}
+		variable := &ExprVariable{
+			tok: tok,
+		}
+		if p.peekToken().isPunct("...") {
+			p.skip()
+			variable.isVariadic = true
+			variable.gtype = &Gtype{
+				kind:        G_SLICE,
+				elementType: p.parseType(),
+			}
+		} else {
+			variable.gtype = p.parseType()
+		}
+		params = append(params, variable)
+		if !p.peekToken().isPunct(")") {
+			p.expect(",")
+		}
+	}
+	return params
+}
+
+// https://golang.org/ref/spec#Function_literals
+func (p *parser) parseFuncLiteral() Expr {
+	p.traceIn(__func__)
+	defer p.traceOut(__func__)
+	ptok := p.expectKeyword("func")
+
+	var outerName identifier = "glob"
+	if !p.isGlobal() {
+		outerName = p.currentFunc.fname
+	}
+
+	// save the state of the enclosing function
+	outerFunc := p.currentFunc
+	outerLocalvars := p.localvars
+	outerForStmt := p.currentForStmt
+	outerFnLabels := p.fnLabels
+	outerRequireBlock := p.requireBlock
+	outerInCase := p.inCase
+
+	p.localvars = nil
+	p.currentForStmt = nil
+	p.fnLabels = &funcLabels{}
+	p.requireBlock = false
+	p.inCase = 0
+	p.enterNewScope(identifier("funclit"))
+
+	params, rettypes := p.parseSignature()
+
+	funcLitSeq++
+	fname := identifier(Sprintf("%s.func%d", string(outerName), funcLitSeq))
+	r := &DeclFunc{
+		tok:      ptok,
+		pkgPath:  p.packagePath,
+		pkg:      p.packageName,
+		fname:    fname,
+		rettypes: rettypes,
+		params:   params,
+	}
+	l := string(makeLabel()) + "_defer_handler"
+	r.labelDeferHandler = l
+
+	p.funcLits = append(p.funcLits, &funcLitContext{
+		funcdef: r,
+		scope:   p.currentScope,
+	})
+	p.expect("{")
+	p.currentFunc = r
+	r.body = p.parseCompoundStmt()
+	p.resolveLabels()
+	r.localvars = p.localvars
+	p.funcLits = p.funcLits[:len(p.funcLits)-1]
+	p.exitScope()
+	p.funcLiterals = append(p.funcLiterals, r)
+
+	p.currentFunc = outerFunc
+	p.localvars = outerLocalvars
+	p.currentForStmt = outerForStmt
+	p.fnLabels = outerFnLabels
+	p.requireBlock = outerRequireBlock
+	p.inCase = outerInCase
+
+	var e Expr = &ExprFuncLiteral{
+		tok:     ptok,
+		funcdef: r,
+	}
+	return p.succeedingExpr(e)
+}
+
+// If rel refers to a local variable of an enclosing function,
+// make the function literals in between capture it.
+func (p *parser) captureIfNeeded(rel *Relation) {
+	if len(p.funcLits) == 0 {
+		return
+	}
+	variable, ok := rel.expr.(*ExprVariable)
+	if !ok || variable.isGlobal {
+		return
+	}
+
+	// find the function literal where the variable is declared
+	level := len(p.funcLits) - 1
+	for s := p.currentScope; s != nil; s = s.outer {
+		if _, found := s.idents[rel.name]; found {
+			break
+		}
+		if level >= 0 && s == p.funcLits[level].scope {
+			level--
+		}
+	}
+
+	for i := level + 1; i < len(p.funcLits); i++ {
+		variable = p.capture(p.funcLits[i].funcdef, variable)
+	}
+	rel.expr = variable
+}
+
+func (p *parser) capture(funcdef *DeclFunc, outer *ExprVariable) *ExprVariable {
+	for _, c := range funcdef.captures {
+		if c.outer == outer {
+			return c.inner
+		}
+	}
+	outer.isBoxed = true
+	inner := &ExprVariable{
+		tok:     outer.tok,
+		varname: outer.varname,
+		gtype:   outer.gtype,
+		isBoxed: true,
+	}
+	c := &Capture{
+		outer: outer,
+		inner: inner,
+	}
+	funcdef.captures = append(funcdef.captures, c)
+	p.uninferredLocals = append(p.uninferredLocals, c)
+	return inner
 }
 
 func (p *parser) parseFuncDecl() *DeclFunc {
@@ -1746,7 +2344,9 @@ func (p *parser) parseFuncDecl() *DeclFunc {
 		// parse func body
 		p.expect("{")
 		p.currentFunc = r
+		p.fnLabels = &funcLabels{}
 		body := p.parseCompoundStmt()
+		p.resolveLabels()
 		r.body = body
 		r.localvars = p.localvars
 	}
@@ -1818,7 +2418,7 @@ func (p *parser) parseImportDecls() []*ImportDecl {
 	return r
 }
 
-const MaxAlign = 16
+const MaxAlign = 8
 
 func (p *parser) parseStructDef() *Gtype {
 	p.traceIn(__func__)
@@ -1833,9 +2433,23 @@ func (p *parser) parseStructDef() *Gtype {
 			p.skip()
 			break
 		}
+		if tok.isPunct("*") || p.peek2Token().isPunct(";") || p.peek2Token().isPunct(".") {
+			// embedded field like "T", "*T" or "pkg.T"
+			fieldtype := p.parseType()
+			fieldtype.fieldname = p.embeddedFieldName(tok, fieldtype)
+			fieldtype.isEmbedded = true
+			fieldtype.offset = undefinedSize // will be calculated later
+			fields = append(fields, fieldtype)
+			p.expect(";")
+			continue
+		}
 		fieldname := tok.getIdent()
 		p.skip()
 		gtype := p.parseType()
+		if gtype == gInterface {
+			// a field holds its own name and offset
+			gtype = &Gtype{kind: G_INTERFACE, size: sizeOfInterface}
+		}
 		fieldtype := gtype
 		//fieldtype.origType = gtype
 		fieldtype.fieldname = fieldname
@@ -1852,43 +2466,77 @@ func (p *parser) parseStructDef() *Gtype {
 	}
 }
 
+// the unqualified type name acts as the field name of an embedded field
+// https://golang.org/ref/spec#Struct_types
+func (p *parser) embeddedFieldName(tok *Token, gtype *Gtype) identifier {
+	if gtype.kind == G_POINTER {
+		gtype = gtype.origType
+	}
+	if gtype.kind != G_NAMED {
+		errorft(tok, "embedded type must be a type name")
+	}
+	return gtype.relation.name
+}
+
 func (p *parser) parseInterfaceDef(newName identifier) *DeclType {
 	p.traceIn(__func__)
 	defer p.traceOut(__func__)
+
+	gtype := p.parseInterfaceType()
+	// registered to compose its method set later
+	p.registerDynamicType(gtype)
+
+	p.currentScope.setGtype(newName, gtype)
+	r := &DeclType{
+		name:  newName,
+		gtype: gtype,
+	}
+	return r
+}
+
+// https://golang.org/ref/spec#Interface_types
+func (p *parser) parseInterfaceType() *Gtype {
+	p.traceIn(__func__)
+	defer p.traceOut(__func__)
 	p.expectKeyword("interface")
 
 	p.expect("{")
 	var imethods map[identifier]*signature = map[identifier]*signature{}
+	var embeddeds []*Gtype
 
 	for {
 		if p.peekToken().isPunct("}") {
 			break
 		}
 
-		fnameToken, params, rettypes := p.parseFuncSignature()
-		fname := fnameToken.getIdent()
-		p.expect(";")
-
-		method := &signature{
-			fname:      fname,
-			params: params,
-			rettypes:   rettypes,
+		if p.peek2Token().isPunct("(") {
+			fnameToken, params, rettypes := p.parseFuncSignature()
+			fname := fnameToken.getIdent()
+			if _, ok := imethodGet(imethods, fname); ok {
+				errorft(fnameToken, "duplicate method %s", fname)
+			}
+			method := &signature{
+				fname:      fname,
+				params: params,
+				rettypes:   rettypes,
+			}
+			imethodSet(imethods, fname, method)
+		} else {
+			// embedded interface like "Reader" or "io.Reader"
+			embeddeds = append(embeddeds, p.parseType())
+		}
+		// ";" can be omitted before "}"
+		if !p.peekToken().isPunct("}") {
+			p.expect(";")
 		}
-		imethodSet(imethods, fname, method)
 	}
 	p.expect("}")
 
-	gtype := &Gtype{
-		kind:     G_INTERFACE,
-		imethods: imethods,
-	}
-
-	p.currentScope.setGtype(newName, gtype)
-	r := &DeclType{
-		name:  newName,
-		gtype: gtype,
+	return &Gtype{
+		kind:      G_INTERFACE,
+		imethods:  imethods,
+		embeddeds: embeddeds,
 	}
-	return r
 }
 
 func (p *parser) tryResolve(pkg identifier, rel *Relation) {
@@ -1953,6 +2601,16 @@ func (p *parser) parseTypeDecl() *DeclType {
 
 	p.namedTypes = append(p.namedTypes, r)
 	p.currentScope.setGtype(newName, gtype)
+	// the type may appear only in conversions like T(x)
+	p.registerDynamicType(&Gtype{
+		kind: G_NAMED,
+		relation: &Relation{
+			tok:   ptok,
+			pkg:   p.packageName,
+			name:  newName,
+			gtype: gtype,
+		},
+	})
 	return r
 }
 
@@ -2086,6 +2744,7 @@ func (p *parser) Parse(bs *ByteStream, packageBlockScope *Scope, importOnly bool
 		namedTypes:        p.namedTypes,
 		methods:           p.methods,
 		imports:           imports,
+		funcLiterals:      p.funcLiterals,
 	}
 }
 
@@ -13,6 +13,24 @@ var sByte = Gtype{kind: G_BYTE, size: 1}
 var gByte = &sByte
 var sUint16 = Gtype{kind: G_UINT_16, size: 2}
 var gUint16 = &sUint16
+var sInt8 = Gtype{kind: G_INT_8, size: 1}
+var gInt8 = &sInt8
+var sInt16 = Gtype{kind: G_INT_16, size: 2}
+var gInt16 = &sInt16
+var sInt32 = Gtype{kind: G_INT_32, size: 4}
+var gInt32 = &sInt32
+var sInt64 = Gtype{kind: G_INT_64, size: 8}
+var gInt64 = &sInt64
+var sUint = Gtype{kind: G_UINT, size: 8}
+var gUint = &sUint
+var sUint32 = Gtype{kind: G_UINT_32, size: 4}
+var gUint32 = &sUint32
+var sUint64 = Gtype{kind: G_UINT_64, size: 8}
+var gUint64 = &sUint64
+var sFloat64 = Gtype{kind: G_FLOAT64, size: 8}
+var gFloat64 = &sFloat64
+var sFloat32 = Gtype{kind: G_FLOAT32, size: 4}
+var gFloat32 = &sFloat32
 var gBool = &Gtype{kind: G_BOOL, size: 8} // we treat bool as quad length data for now
 var gString = &Gtype{
 	kind:        G_STRING,
@@ -29,7 +47,16 @@ var builtinTypesAsString []string = []string{
 	"bool",
 	"byte",
 	"int",
+	"int8",
+	"int16",
+	"int32",
+	"int64",
+	"uint",
 	"uint16",
+	"uint32",
+	"uint64",
+	"float64",
+	"float32",
 	"unintptr",
 	"string",
 	"func",
@@ -39,12 +66,25 @@ var eIota = &ExprConstVariable{
 	name: identifier("iota"),
 }
 
+// the argument of panic is converted into an interface
+var sPanicArg = ExprVariable{
+	varname: "v",
+	gtype:   &sInterface,
+}
+
 var builtinPanic = &DeclFunc{
 	builtinname:"panic",
 	pkgPath:  "/builtin",
+	params: []*ExprVariable{&sPanicArg},
 	rettypes: []*Gtype{},
 }
 
+var builtinRecover = &DeclFunc{
+	builtinname: "recover",
+	pkgPath:  "/builtin",
+	rettypes: []*Gtype{&sInterface},
+}
+
 var builtinLen = &DeclFunc{
 	builtinname: "len",
 	pkgPath:  "/builtin",
@@ -69,6 +109,18 @@ var builtinMake = &DeclFunc{
 	rettypes: []*Gtype{},
 }
 
+var builtinDelete = &DeclFunc{
+	builtinname: "delete",
+	pkgPath:  "/builtin",
+	rettypes: []*Gtype{},
+}
+
+var builtinClose = &DeclFunc{
+	builtinname: "close",
+	pkgPath:  "/builtin",
+	rettypes: []*Gtype{},
+}
+
 var builtinSyscall = &DeclFunc{
 	builtinname: "Syscall",
 	pkgPath:  "/builtin",
@@ -131,10 +183,13 @@ func predeclareFuncs(universe *Scope) {
 	var builtinFuncs []*DeclFunc = []*DeclFunc{
 		// Inject genuine builtin funcs
 		builtinPanic,
+		builtinRecover,
 		builtinLen,
 		builtinCap,
 		builtinAppend,
 		builtinMake,
+		builtinDelete,
+		builtinClose,
 		// Inject my builtin funcs
 		builtinSyscall,
 		builtinClone,
@@ -166,10 +221,20 @@ func predeclareNil(universe *Scope) {
 func predeclareTypes(universe *Scope) {
 	universe.setGtype(identifier("bool"), gBool)
 	universe.setGtype(identifier("byte"), gByte)
+	universe.setGtype(identifier("float32"), gFloat32)
+	universe.setGtype(identifier("float64"), gFloat64)
 	universe.setGtype(identifier("int"), gInt)
+	universe.setGtype(identifier("int8"), gInt8)
+	universe.setGtype(identifier("int16"), gInt16)
+	universe.setGtype(identifier("int32"), gInt32)
+	universe.setGtype(identifier("int64"), gInt64)
+	universe.setGtype(identifier("rune"), gInt32)
 	universe.setGtype(identifier("string"), gString)
+	universe.setGtype(identifier("uint"), gUint)
 	universe.setGtype(identifier("uint8"), gByte)
 	universe.setGtype(identifier("uint16"), gUint16)
+	universe.setGtype(identifier("uint32"), gUint32)
+	universe.setGtype(identifier("uint64"), gUint64)
 	universe.setGtype(identifier("uintptr"), gUintptr)
 }
 
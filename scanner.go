@@ -1,87 +1,459 @@
 package main
 
-import "github.com/DQNEO/minigo/util"
+import (
+	"github.com/DQNEO/minigo/stdlib/strconv"
+	"github.com/DQNEO/minigo/stdlib/unicode/utf8"
+	"github.com/DQNEO/minigo/util"
+)
 
 type Tokenizer struct {
 	bs *ByteStream
+	// the position of the first byte of the current token
+	line   int
+	column int
 }
 
-func (tn *Tokenizer) read_number(c0 byte) string {
+// https://golang.org/ref/spec#Integer_literals
+// https://golang.org/ref/spec#Floating-point_literals
+// returns T_INT or T_FLOAT with the text of the literal without '_'.
+// A hexadecimal float is converted to decimal for the assembler.
+func (tn *Tokenizer) read_number(c0 byte) (TokenType, string) {
+	var typ TokenType = T_INT
 	var chars = []byte{c0}
+	base := 10
+	if c0 == '0' {
+		if tn.skipByte('x') || tn.skipByte('X') {
+			base = 16
+			chars = append(chars, 'x')
+		} else if tn.skipByte('o') || tn.skipByte('O') {
+			base = 8
+			chars = append(chars, 'o')
+		} else if tn.skipByte('b') || tn.skipByte('B') {
+			base = 2
+			chars = append(chars, 'b')
+		}
+	}
+	if c0 == '.' {
+		typ = T_FLOAT
+	}
+	chars = tn.readDigits(chars, base)
+	if base == 16 || base == 10 {
+		if typ == T_INT && tn.skipByte('.') {
+			typ = T_FLOAT
+			chars = append(chars, '.')
+			chars = tn.readDigits(chars, base)
+		}
+	}
+	if base == 10 && (tn.skipByte('e') || tn.skipByte('E')) {
+		typ = T_FLOAT
+		chars = tn.readExponent(append(chars, 'e'))
+	} else if tn.skipByte('p') || tn.skipByte('P') {
+		if base != 16 {
+			tn.literalError(chars, "'p' exponent requires hexadecimal mantissa")
+		}
+		typ = T_FLOAT
+		chars = tn.readExponent(append(chars, 'p'))
+	} else if base == 16 && typ == T_FLOAT {
+		tn.literalError(chars, "hexadecimal mantissa requires a 'p' exponent")
+	}
+	if tn.skipByte('i') {
+		tn.literalError(chars, "imaginary literals are not supported")
+	}
+
+	if invalidSep(string(chars)) >= 0 {
+		tn.literalError(chars, "'_' must separate successive digits")
+	}
+	chars = removeUnderscores(chars)
+	switch base {
+	case 16:
+		if !hasDigits(chars[2:]) {
+			tn.literalError(chars, "hexadecimal literal has no digits")
+		}
+		if typ == T_FLOAT {
+			return typ, hexFloatToDecimal(chars[2:])
+		}
+	case 8:
+		tn.checkDigits(chars, 2, 8, "octal literal")
+	case 2:
+		tn.checkDigits(chars, 2, 2, "binary literal")
+	case 10:
+		if typ == T_INT && len(chars) > 1 && chars[0] == '0' {
+			// legacy octal like 0755
+			tn.checkDigits(chars, 1, 8, "octal literal")
+		}
+		if typ == T_FLOAT {
+			return typ, floatText(chars)
+		}
+	}
+	return typ, string(chars)
+}
+
+// read digits and '_'.
+// Decimal digits are read even if base is smaller to report them as invalid.
+func (tn *Tokenizer) readDigits(chars []byte, base int) []byte {
 	for {
 		c, err := tn.bs.get()
 		if err != nil {
-			return string(chars)
+			return chars
 		}
-		if tn.isUnicodeDigit(c) {
+		if c == '_' || digitVal(c) < 10 || (base == 16 && digitVal(c) < 16) {
 			chars = append(chars, c)
 			continue
 		} else {
 			tn.bs.unget()
-			return string(chars)
+			return chars
 		}
 	}
 }
 
+// read an optional sign and decimal digits
+func (tn *Tokenizer) readExponent(chars []byte) []byte {
+	if tn.skipByte('-') {
+		chars = append(chars, '-')
+	} else if tn.skipByte('+') {
+		chars = append(chars, '+')
+	}
+	n := len(chars)
+	chars = tn.readDigits(chars, 10)
+	if len(chars) == n {
+		tn.literalError(chars, "exponent has no digits")
+	}
+	return chars
+}
+
+// check the digits after the prefix
+func (tn *Tokenizer) checkDigits(chars []byte, prefixLen int, base int, kind string) {
+	if !hasDigits(chars[prefixLen:]) {
+		tn.literalError(chars, kind+" has no digits")
+	}
+	for _, c := range chars[prefixLen:] {
+		if digitVal(c) >= base {
+			tn.literalError(chars, Sprintf("invalid digit '%c' in %s", c, kind))
+		}
+	}
+}
+
+// report a malformed literal at the current position
+func (tn *Tokenizer) literalError(chars []byte, msg string) {
+	errorft(tn.makeToken(T_INT, string(chars)), "%s", msg)
+}
+
+// the value of a digit in base 16, or 16 if c is not a digit
+func digitVal(c byte) int {
+	switch {
+	case '0' <= c && c <= '9':
+		return int(c - '0')
+	case 'a' <= c && c <= 'f':
+		return int(c-'a') + 10
+	case 'A' <= c && c <= 'F':
+		return int(c-'A') + 10
+	}
+	return 16
+}
+
+func hasDigits(chars []byte) bool {
+	for _, c := range chars {
+		if digitVal(c) < 16 {
+			return true
+		}
+	}
+	return false
+}
+
+func removeUnderscores(chars []byte) []byte {
+	var r []byte
+	for _, c := range chars {
+		if c != '_' {
+			r = append(r, c)
+		}
+	}
+	return r
+}
+
+// the index of the first '_' which does not separate successive digits, or -1.
+// A base prefix counts as a digit.
+func invalidSep(x string) int {
+	var x1 byte = ' ' // prefix char
+	var d byte = '.'  // '_', '0' (a digit) or '.' (anything else)
+	i := 0
+	if len(x) >= 2 && x[0] == '0' {
+		x1 = x[1]
+		if x1 == 'x' || x1 == 'o' || x1 == 'b' {
+			d = '0'
+			i = 2
+		}
+	}
+	for i < len(x) {
+		p := d // previous digit
+		d = x[i]
+		if d == '_' {
+			if p != '0' {
+				return i
+			}
+		} else if digitVal(d) < 10 || (x1 == 'x' && digitVal(d) < 16) {
+			d = '0'
+		} else {
+			if p == '_' {
+				return i - 1
+			}
+			d = '.'
+		}
+		i++
+	}
+	if d == '_' {
+		return len(x) - 1
+	}
+	return -1
+}
+
+// text of a decimal float for the assembler.
+// Redundant leading zeros are removed, and "0e5" becomes "0.0e5"
+// because gas reads "0e" as a prefix of a float.
+func floatText(chars []byte) string {
+	for len(chars) > 1 && chars[0] == '0' && digitVal(chars[1]) < 10 {
+		chars = chars[1:]
+	}
+	if len(chars) > 1 && chars[0] == '0' && chars[1] == 'e' {
+		return "0.0" + string(chars[1:])
+	}
+	return string(chars)
+}
+
+// convert the mantissa and exponent of a hexadecimal float like "1.8p3" to decimal text
+func hexFloatToDecimal(chars []byte) string {
+	var mant int
+	var exp int
+	var inFraction bool
+	var i int
+	for i = 0; i < len(chars); i++ {
+		c := chars[i]
+		if c == 'p' {
+			break
+		}
+		if c == '.' {
+			inFraction = true
+			continue
+		}
+		if mant < 1<<55 {
+			mant = mant*16 + digitVal(c)
+			if inFraction {
+				exp = exp - 4
+			}
+		} else if !inFraction {
+			// drop the digits beyond the precision
+			exp = exp + 4
+		}
+	}
+	var pexp int
+	var negative bool
+	for _, c := range chars[i+1:] {
+		if c == '-' {
+			negative = true
+		} else if c != '+' {
+			pexp = pexp*10 + digitVal(c)
+		}
+	}
+	if negative {
+		exp = exp - pexp
+	} else {
+		exp = exp + pexp
+	}
+
+	f := float64(mant)
+	for exp > 0 {
+		f = f * 2
+		exp--
+	}
+	for exp < 0 {
+		f = f / 2
+		exp++
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// consume the next byte if it is c
+func (tn *Tokenizer) skipByte(c byte) bool {
+	c2, err := tn.bs.get()
+	if err != nil {
+		return false
+	}
+	if c2 == c {
+		return true
+	}
+	tn.bs.unget()
+	return false
+}
+
 // https://golang.org/ref/spec#unicode_letter
-func (tn *Tokenizer) isUnicodeLetter(b byte) bool {
-	// tentative implementation
-	return ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+func (tn *Tokenizer) isUnicodeLetter(r rune) bool {
+	if r < utf8.RuneSelf {
+		return ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z')
+	}
+	// tentative implementation:
+	// non-ASCII characters are letters except for some blocks of symbols and punctuation
+	switch {
+	case 0x80 <= r && r <= 0xBF: // Latin-1 controls and symbols
+		return false
+	case r == 0xD7 || r == 0xF7: // multiplication and division signs
+		return false
+	case 0x2000 <= r && r <= 0x2BFF: // punctuation, arrows, math symbols and so on
+		return false
+	case 0x3000 <= r && r <= 0x3004: // CJK symbols and punctuation
+		return false
+	case 0x3008 <= r && r <= 0x3020:
+		return false
+	case 0xFE30 <= r && r <= 0xFE4F: // CJK compatibility forms
+		return false
+	case r == 0xFEFF: // BOM
+		return false
+	case 0xFF01 <= r && r <= 0xFF0F: // fullwidth punctuation
+		return false
+	}
+	return true
 }
 
 // https://golang.org/ref/spec#unicode_digit
-func (tn *Tokenizer) isUnicodeDigit(c byte) bool {
+func (tn *Tokenizer) isUnicodeDigit(r rune) bool {
 	// tentative implementation
-	return '0' <= c && c <= '9'
+	return '0' <= r && r <= '9'
 }
 
 // https://golang.org/ref/spec#Letters_and_digits
-func (tn *Tokenizer) isLetter(b byte) bool {
-	return tn.isUnicodeLetter(b) || b == '_'
+func (tn *Tokenizer) isLetter(r rune) bool {
+	return tn.isUnicodeLetter(r) || r == '_'
+}
+
+// https://golang.org/ref/spec#Source_code_representation
+// read a UTF-8 encoded character whose first byte c0 has already been read.
+// returns the code point and the encoded bytes.
+func (tn *Tokenizer) readRune(c0 byte) (rune, []byte) {
+	if c0 < utf8.RuneSelf {
+		return rune(c0), []byte{c0}
+	}
+	start := tn.bs.nextIndex - 1
+	end := start + utf8.UTFMax
+	if end > len(tn.bs.source) {
+		end = len(tn.bs.source)
+	}
+	r, size := utf8.DecodeRune(tn.bs.source[start:end])
+	if size == 1 {
+		tn.literalError(nil, "invalid UTF-8 encoding")
+	}
+	for i := 1; i < size; i++ {
+		tn.bs.get()
+	}
+	return r, tn.bs.source[start : start+size]
 }
 
 // https://golang.org/ref/spec#Identifiers
-func (tn *Tokenizer) readIdentifier(c0 byte) string {
-	var chars = []byte{c0}
+func (tn *Tokenizer) readIdentifier(first []byte) string {
+	var chars = first
 	for {
 		c, err := tn.bs.get()
 		if err != nil {
 			return string(chars)
 		}
-		if tn.isLetter(c) || tn.isUnicodeDigit(c) {
-			chars = append(chars, c)
+		r, encoded := tn.readRune(c)
+		if tn.isLetter(r) || tn.isUnicodeDigit(r) {
+			for _, b := range encoded {
+				chars = append(chars, b)
+			}
 			continue
 		} else {
-			tn.bs.unget()
+			for i := 0; i < len(encoded); i++ {
+				tn.bs.unget()
+			}
 			return string(chars)
 		}
 	}
 }
 
+// https://golang.org/ref/spec#Rune_literals
+// read an escape sequence after a backslash in a literal quoted by quote.
+// returns the value and whether it is a byte value (\x and octal escapes)
+// which is not encoded in UTF-8 in a string literal.
+func (tn *Tokenizer) readEscape(quote byte) (int, bool) {
+	c, err := tn.bs.get()
+	if err != nil {
+		tn.literalError(nil, "escape sequence not terminated")
+	}
+	switch c {
+	case 'a':
+		return 7, false
+	case 'b':
+		return 8, false
+	case 'f':
+		return 12, false
+	case 'n':
+		return 10, false
+	case 'r':
+		return 13, false
+	case 't':
+		return 9, false
+	case 'v':
+		return 11, false
+	case '\\':
+		return int(c), false
+	case quote:
+		return int(c), false
+	case 'x':
+		return tn.readEscapeDigits(2, 16), true
+	case 'u', 'U':
+		var n int = 4
+		if c == 'U' {
+			n = 8
+		}
+		val := tn.readEscapeDigits(n, 16)
+		if !utf8.ValidRune(rune(val)) || val > utf8.MaxRune {
+			tn.literalError(nil, "escape sequence is invalid Unicode code point")
+		}
+		return val, false
+	case '0', '1', '2', '3', '4', '5', '6', '7':
+		tn.bs.unget()
+		val := tn.readEscapeDigits(3, 8)
+		if val > 255 {
+			tn.literalError(nil, "octal escape value > 255")
+		}
+		return val, true
+	}
+	tn.literalError(nil, "unknown escape sequence")
+	return 0, false
+}
+
+// read exactly n digits of an escape sequence
+func (tn *Tokenizer) readEscapeDigits(n int, base int) int {
+	var val int
+	for i := 0; i < n; i++ {
+		c, err := tn.bs.get()
+		if err != nil || digitVal(c) >= base {
+			tn.literalError(nil, "invalid character in escape sequence")
+		}
+		val = val*base + digitVal(c)
+	}
+	return val
+}
+
+// https://golang.org/ref/spec#String_literals
 func (tn *Tokenizer) read_string() string {
 	var chars []byte
 	for {
 		c, err := tn.bs.get()
-		if err != nil {
-			panic("invalid string literal")
-		}
-		if c == '\\' {
-			chars = append(chars, c)
-			c, err = tn.bs.get()
-			chars = append(chars, c)
-			continue
+		if err != nil || c == '\n' {
+			tn.literalError(nil, "string literal not terminated")
 		}
-		if c == '\n' {
-			chars = append(chars, '\\')
-			chars = append(chars, 'n')
-			continue
+		if c == '"' {
+			return escapeString(chars)
 		}
-		if c != '"' {
-			chars = append(chars, c)
+		if c == '\\' {
+			val, isByte := tn.readEscape('"')
+			if isByte {
+				chars = append(chars, byte(val))
+			} else {
+				chars = utf8.AppendRune(chars, rune(val))
+			}
 			continue
-		} else {
-			return string(chars)
 		}
+		chars = append(chars, c)
 	}
 }
 
@@ -90,68 +462,64 @@ func (tn *Tokenizer) read_raw_string() string {
 	for {
 		c, err := tn.bs.get()
 		if err != nil {
-			panic("invalid string literal")
+			tn.literalError(nil, "raw string literal not terminated")
 		}
-		if c == '\\' {
-			// @FIXME
-			chars = append(chars, c)
-			c, err = tn.bs.get()
-			chars = append(chars, c)
-			continue
+		if c == '`' {
+			return escapeString(chars)
 		}
-		if c == '"' {
-			chars = append(chars, '\\')
+		// carriage returns are discarded from the raw string value
+		if c != '\r' {
 			chars = append(chars, c)
-			continue
 		}
-		if c != '`' {
-			if c == '\n' {
-				chars = append(chars, '\\')
-				chars = append(chars, 'n')
-			} else {
-				chars = append(chars, c)
-			}
-			continue
-		} else {
-			return string(chars)
+	}
+}
+
+// escape a string value for the assembler's .string directive
+func escapeString(chars []byte) string {
+	var r []byte
+	for _, c := range chars {
+		switch {
+		case c == '"' || c == '\\':
+			r = append(r, '\\')
+			r = append(r, c)
+		case c == '\n':
+			r = append(r, '\\')
+			r = append(r, 'n')
+		case c == '\t':
+			r = append(r, '\\')
+			r = append(r, 't')
+		case c < ' ' || c == 0x7F:
+			// octal escape of 3 digits
+			r = append(r, '\\')
+			r = append(r, '0'+c/64)
+			r = append(r, '0'+(c/8)%8)
+			r = append(r, '0'+c%8)
+		default:
+			r = append(r, c)
 		}
 	}
+	return string(r)
 }
 
-func (tn *Tokenizer) read_char() string {
+// https://golang.org/ref/spec#Rune_literals
+// returns the code point of a rune literal
+func (tn *Tokenizer) read_char() int {
 	c, err := tn.bs.get()
-	if err != nil {
-		panic("invalid char literal")
+	if err != nil || c == '\n' || c == '\'' {
+		tn.literalError(nil, "empty rune literal or unescaped ' in rune literal")
 	}
+	var val int
 	if c == '\\' {
-		var sval string
-		c, err = tn.bs.get()
-		switch c {
-		case 'n':
-			sval = "\n"
-		case 't':
-			sval = "\t"
-		case 'r':
-			sval = "\r"
-		case '\\':
-			sval = "\\"
-		case '\'':
-			sval = "'"
-		default:
-			errorf("unexpected char 1:%c", c)
-		}
-
-		end, _ := tn.bs.get()
-		if end != '\'' {
-			errorf("unexpected char 2:%c", end)
-		}
-		return sval
+		val, _ = tn.readEscape('\'')
+	} else {
+		r, _ := tn.readRune(c)
+		val = int(r)
 	}
-	end, _ := tn.bs.get()
-	if end != '\'' {
-		errorf("unexpected char:%c", end)
+	end, err := tn.bs.get()
+	if err != nil || end != '\'' {
+		tn.literalError(nil, "more than one character in rune literal")
 	}
-	return string([]byte{c})
+	return val
 }
 
 func (tn *Tokenizer) isSpace(c byte) bool {
@@ -178,8 +546,8 @@ func (tn *Tokenizer) makeToken(typ TokenType, sval string) *Token {
 		typ:      typ,
 		sval:     sval,
 		filename: tn.bs.filename,
-		line:     tn.bs.line,
-		column:   tn.bs.column,
+		line:     tn.line,
+		column:   tn.column,
 	}
 }
 
@@ -188,7 +556,7 @@ func (tn *Tokenizer) autoSemicolonInsert(last *Token) bool {
 	if last.isTypeIdent() {
 		return true
 	}
-	if last.typ == T_INT || last.typ == T_STRING || last.typ == T_CHAR {
+	if last.typ == T_INT || last.typ == T_FLOAT || last.typ == T_STRING || last.typ == T_CHAR {
 		return true
 	}
 	if last.isKeyword("break") || last.isKeyword("continue") || last.isKeyword("fallthrough") || last.isKeyword("return") {
@@ -241,6 +609,8 @@ func (tn *Tokenizer) tokenize() []*Token {
 			tn.skipSpace()
 			continue
 		}
+		tn.line = tn.bs.line
+		tn.column = tn.bs.column
 		var tok *Token
 		switch c {
 		case 0: // no need?
@@ -256,19 +626,19 @@ func (tn *Tokenizer) tokenize() []*Token {
 			}
 			continue
 		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-			sval := tn.read_number(c)
-			tok = tn.makeToken(T_INT, sval)
+			typ, sval := tn.read_number(c)
+			tok = tn.makeToken(typ, sval)
 		case '_', 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z',
 			'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z':
-			sval := tn.readIdentifier(c)
+			sval := tn.readIdentifier([]byte{c})
 			if util.InArray(string(sval), keywords) {
 				tok = tn.makeToken(T_KEYWORWD, sval)
 			} else {
 				tok = tn.makeToken(T_IDENT, sval)
 			}
 		case '\'':
-			sval := tn.read_char()
-			tok = tn.makeToken(T_CHAR, sval)
+			val := tn.read_char()
+			tok = tn.makeToken(T_CHAR, strconv.Itoa(val))
 		case '"':
 			sval := tn.read_string()
 			tok = tn.makeToken(T_STRING, sval)
@@ -403,7 +773,12 @@ func (tn *Tokenizer) tokenize() []*Token {
 			}
 		case '.':
 			c, _ = tn.bs.get()
-			if c == '.' {
+			if tn.isUnicodeDigit(rune(c)) {
+				// .5
+				tn.bs.unget()
+				typ, sval := tn.read_number('.')
+				tok = tn.makeToken(typ, sval)
+			} else if c == '.' {
 				c, _ = tn.bs.get()
 				if c == '.' {
 					tok = tn.makeToken(T_PUNCT, "...")
@@ -449,8 +824,12 @@ func (tn *Tokenizer) tokenize() []*Token {
 				tok = tn.makeToken(T_PUNCT, "<")
 			}
 		default:
-			msg := Sprintf("unknown char:%d\n", c)
-			panic(msg)
+			ch, encoded := tn.readRune(c)
+			if !tn.isUnicodeLetter(ch) {
+				errorft(tn.makeToken(T_PUNCT, string(encoded)), "invalid character '%s'", string(encoded))
+			}
+			sval := tn.readIdentifier(encoded)
+			tok = tn.makeToken(T_IDENT, sval)
 		}
 		if debugToken {
 			tok.dump()
@@ -4,6 +4,7 @@ type Scope struct {
 	idents map[identifier]*IdentBody
 	name   identifier
 	outer  *Scope
+	nvars  int // the number of variables declared so far
 }
 
 type IdentBody struct {
@@ -35,6 +36,11 @@ func (sc *Scope) setConst(name identifier, cnst *ExprConstVariable) {
 }
 
 func (sc *Scope) setVar(name identifier, variable *ExprVariable) {
+	sc.nvars++
+	if name == "_" {
+		// the blank identifier is never bound
+		return
+	}
 	sc.set(name, &IdentBody{
 		expr: variable,
 	})
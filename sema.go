@@ -6,8 +6,9 @@ import "github.com/DQNEO/minigo/util"
 var symbolTable *SymbolTable
 
 type SymbolTable struct {
-	allScopes     map[normalizedPackagePath]*Scope
-	uniquedDTypes []string
+	allScopes        map[normalizedPackagePath]*Scope
+	uniquedDTypes    []string
+	underlyingDTypes []string // the underlying type of each of uniquedDTypes
 }
 
 func makeDynamicTypeLabel(id int) string {
@@ -71,6 +72,226 @@ func attachMethodsToTypes(pmethods map[identifier]methods, packageScope *Scope)
 	}
 }
 
+// promoteMethods adds methods promoted from embedded fields to the method sets of struct types.
+// A promoted method gets a wrapper method which forwards the call to the embedded field,
+// so that it can be called statically and put in the method table.
+func promoteMethods(pkg *AstPackage) {
+	for _, namedType := range pkg.namedTypes {
+		gtype := namedType.gtype
+		if gtype.getKind() != G_STRUCT {
+			continue
+		}
+		var names []string
+		var seen []*Gtype
+		names = collectEmbeddedMethodNames(gtype, names, seen)
+		for _, sname := range names {
+			name := identifier(sname)
+			if gtype.hasOwnMember(name) {
+				continue
+			}
+			path, _ := gtype.lookupPromoted(name)
+			if path == nil {
+				// ambiguous at the shallowest depth
+				continue
+			}
+			fn := makeMethodWrapper(pkg, namedType, path, name)
+			if fn == nil {
+				continue
+			}
+			if gtype.methods == nil {
+				gtype.methods = map[identifier]*ExprFuncRef{}
+			}
+			ref := &ExprFuncRef{
+				tok:     namedType.tok,
+				funcdef: fn,
+			}
+			methodSet(gtype.methods, name, ref)
+			pkg.funcs = append(pkg.funcs, fn)
+		}
+	}
+}
+
+// collect method names of all types embedded in gtype directly or indirectly
+func collectEmbeddedMethodNames(gtype *Gtype, names []string, seen []*Gtype) []string {
+	strct := gtype.Underlying()
+	if strct.kind != G_STRUCT {
+		return names
+	}
+	seen = append(seen, gtype)
+	for _, field := range strct.fields {
+		if !field.isEmbedded {
+			continue
+		}
+		embedded := field.embeddedType()
+		for name, ref := range embedded.methods {
+			if !ref.funcdef.isWrapper && !util.InArray(string(name), names) {
+				names = append(names, string(name))
+			}
+		}
+		underlying := embedded.Underlying()
+		if underlying.kind == G_INTERFACE {
+			for name, _ := range underlying.imethods {
+				if !util.InArray(string(name), names) {
+					names = append(names, string(name))
+				}
+			}
+		}
+		if !inGtypes(embedded, seen) {
+			names = collectEmbeddedMethodNames(embedded, names, seen)
+		}
+	}
+	return names
+}
+
+// makeMethodWrapper builds a method of namedType like
+//
+//	func (r *S) m(a0 int) int { return r.T.m(a0) }
+//
+// where T is the embedded field reached by path.
+func makeMethodWrapper(pkg *AstPackage, namedType *DeclType, path []identifier, name identifier) *DeclFunc {
+	tok := namedType.tok
+	recvType := &Gtype{
+		kind: G_POINTER,
+		origType: &Gtype{
+			kind: G_NAMED,
+			relation: &Relation{
+				tok:   tok,
+				pkg:   pkg.name,
+				name:  namedType.name,
+				gtype: namedType.gtype,
+			},
+		},
+	}
+	// the receiver type can be converted into an interface
+	pkg.dynamicTypes = append(pkg.dynamicTypes, recvType)
+	receiver := &ExprVariable{
+		tok:     tok,
+		varname: identifier("r"),
+		gtype:   recvType,
+	}
+
+	// go through embedded struct values by their addresses
+	var recv Expr = receiver
+	var field *Gtype
+	var throughPointer bool
+	var wrapsPtrMethod bool
+	for _, fieldname := range path {
+		field = recv.getGtype().origType.Underlying().getField(fieldname)
+		if field.getKind() == G_POINTER {
+			throughPointer = true
+		}
+		recv = &ExprStructField{
+			tok:       tok,
+			strct:     recv,
+			fieldname: fieldname,
+		}
+		if field.getKind() != G_POINTER {
+			recv = &ExprUop{
+				tok:     tok,
+				op:      "&",
+				operand: recv,
+			}
+		}
+	}
+
+	var params []*ExprVariable
+	var rettypes []*Gtype
+	embedded := field.embeddedType()
+	if embedded.Underlying().kind == G_INTERFACE {
+		sig, _ := imethodGet(embedded.Underlying().imethods, name)
+		recv = recv.(*ExprUop).operand
+		params = sig.params
+		rettypes = sig.rettypes
+	} else {
+		ref, ok := methodGet(embedded.methods, name)
+		if !ok {
+			// shadowed by a field
+			return nil
+		}
+		if ref.funcdef.hasPointerReceiver() {
+			// it is in the method set of S too if reached through a pointer
+			wrapsPtrMethod = !throughPointer
+		}
+		if ref.funcdef.receiver.getGtype().getKind() != G_POINTER {
+			// a value receiver
+			recv = &ExprUop{
+				tok:     tok,
+				op:      "*",
+				operand: recv,
+			}
+		}
+		params = ref.funcdef.params
+		rettypes = ref.funcdef.rettypes
+	}
+
+	var newParams []*ExprVariable
+	var args []Expr
+	for i, param := range params {
+		newParam := &ExprVariable{
+			tok:        tok,
+			varname:    identifier(Sprintf("a%d", i)),
+			gtype:      param.gtype,
+			isVariadic: param.isVariadic,
+		}
+		newParams = append(newParams, newParam)
+		var arg Expr = newParam
+		if newParam.isVariadic {
+			arg = &ExprVaArg{
+				tok:  tok,
+				expr: newParam,
+			}
+		}
+		args = append(args, arg)
+	}
+
+	fn := &DeclFunc{
+		tok:            tok,
+		pkgPath:        pkg.normalizedPath,
+		pkg:            pkg.name,
+		receiver:       receiver,
+		fname:          name,
+		rettypes:       rettypes,
+		params:         newParams,
+		isWrapper:      true,
+		wrapsPtrMethod: wrapsPtrMethod,
+	}
+	fn.labelDeferHandler = string(makeLabel()) + "_defer_handler"
+
+	var call Expr = &ExprMethodcall{
+		tok:      tok,
+		receiver: recv,
+		fname:    name,
+		args:     args,
+	}
+	var stmt Stmt
+	if len(rettypes) == 0 {
+		stmt = &StmtExpr{
+			tok:  tok,
+			expr: call,
+		}
+	} else {
+		stmt = &StmtReturn{
+			tok:               tok,
+			exprs:             []Expr{call},
+			rettypes:          rettypes,
+			labelDeferHandler: fn.labelDeferHandler,
+		}
+	}
+	fn.body = &StmtSatementList{
+		tok:   tok,
+		stmts: []Stmt{stmt},
+	}
+	return fn
+}
+
+// whether the method is only in the method set of the pointer type
+func (f *DeclFunc) hasPointerReceiver() bool {
+	if f.isWrapper {
+		return f.wrapsPtrMethod
+	}
+	return f.receiver.getGtype().getKind() == G_POINTER
+}
+
 func collectDecls(pkg *AstPackage) {
 	for _, f := range pkg.files {
 		for _, decl := range f.DeclList {
@@ -83,6 +304,9 @@ func collectDecls(pkg *AstPackage) {
 				pkg.funcs = append(pkg.funcs, decl.funcdecl)
 			}
 		}
+		for _, funcLiteral := range f.funcLiterals {
+			pkg.funcs = append(pkg.funcs, funcLiteral)
+		}
 	}
 }
 
@@ -103,15 +327,55 @@ func calcStructSize(gtypes []*Gtype) {
 	}
 }
 
-func uniqueDynamicTypes(dynamicTypes []*Gtype) []string {
+// merge methods of embedded interfaces into each interface
+func composeInterfaces(gtypes []*Gtype) {
+	for _, gtype := range gtypes {
+		if gtype.kind == G_INTERFACE {
+			gtype.composeInterface()
+		}
+	}
+}
+
+var composingInterfaces []*Gtype
+
+func (iface *Gtype) composeInterface() {
+	if len(iface.embeddeds) == 0 {
+		return
+	}
+	embeddeds := iface.embeddeds
+	if inGtypes(iface, composingInterfaces) {
+		errorft(embeddeds[0].relation.tok, "invalid recursive type %s", embeddeds[0].relation.name)
+	}
+	composingInterfaces = append(composingInterfaces, iface)
+	for _, embedded := range embeddeds {
+		tok := embedded.relation.tok
+		embeddedIface := embedded.Underlying()
+		if embeddedIface.kind != G_INTERFACE {
+			errorft(tok, "interface contains embedded non-interface %s", embedded.String())
+		}
+		embeddedIface.composeInterface()
+		for name, sig := range embeddedIface.imethods {
+			if existing, ok := imethodGet(iface.imethods, name); ok && !identicalSignatures(existing, sig) {
+				errorft(tok, "duplicate method %s", name)
+			}
+			imethodSet(iface.imethods, name, sig)
+		}
+	}
+	iface.embeddeds = nil
+	composingInterfaces = composingInterfaces[0 : len(composingInterfaces)-1]
+}
+
+func uniqueDynamicTypes(dynamicTypes []*Gtype) ([]string, []string) {
 	var r []string = builtinTypesAsString
+	var underlyings []string = builtinTypesAsString
 	for _, gtype := range dynamicTypes {
 		gs := gtype.String()
 		if !util.InArray(gs, r) {
 			r = append(r, gs)
+			underlyings = append(underlyings, gtype.Underlying().String())
 		}
 	}
-	return r
+	return r, underlyings
 }
 
 func composeMethodTable(funcs []*DeclFunc) map[int][]string {
@@ -139,12 +403,254 @@ func composeMethodTable(funcs []*DeclFunc) map[int][]string {
 	return methodTable
 }
 
+// the function whose body is being walked
+var walkingPkg *AstPackage
+var walkingFunc *DeclFunc
+
 func walkFunc(f *DeclFunc) *DeclFunc {
-	f.prologue = f.prepare()
+	walkingFunc = f
 	f.body = walkStmtList(f.body)
+	walkingFunc = nil
+	// temporary variables may have been added while walking
+	f.prologue = f.prepare()
 	return f
 }
 
+// newTempVariable makes an invisible local variable of the walking function.
+func newTempVariable(tok *Token, gtype *Gtype) *ExprVariable {
+	variable := &ExprVariable{
+		tok:     tok,
+		varname: identifier(""),
+		gtype:   gtype,
+	}
+	walkingFunc.localvars = append(walkingFunc.localvars, variable)
+	return variable
+}
+
+// makeMethodValue lowers a method value x.m into a closure
+// which calls m on the receiver captured by it, like
+//
+//	r := x; func(a0, a1, ...) { return r.m(a0, a1, ...) }
+func makeMethodValue(e *ExprStructField) *ExprMethodValue {
+	tok := e.token()
+	origType := e.getStrctType().relation.gtype
+	recv := e.strct
+	recvType := recv.getGtype()
+	if origType.kind != G_INTERFACE {
+		ref, _ := methodGet(origType.methods, e.fieldname)
+		recvType = ref.funcdef.receiver.getGtype()
+		if recvType.getKind() == G_POINTER && recv.getGtype().getKind() != G_POINTER {
+			// x.m is shorthand for (&x).m
+			recv = &ExprUop{
+				tok:     tok,
+				op:      "&",
+				operand: recv,
+			}
+		} else if recvType.getKind() != G_POINTER && recv.getGtype().getKind() == G_POINTER {
+			// p.m is shorthand for (*p).m
+			recv = &ExprUop{
+				tok:     tok,
+				op:      "*",
+				operand: recv,
+			}
+		}
+	}
+
+	recvvar := newTempVariable(tok, recvType)
+	recvvar.isBoxed = true
+	inner := &ExprVariable{
+		tok:     tok,
+		varname: identifier("r"),
+		gtype:   recvType,
+		isBoxed: true,
+	}
+
+	sig := e.getMethodSig()
+	var params []*ExprVariable
+	var args []Expr
+	for i, param := range sig.params {
+		newParam := &ExprVariable{
+			tok:        tok,
+			varname:    identifier(Sprintf("a%d", i)),
+			gtype:      param.gtype,
+			isVariadic: param.isVariadic,
+		}
+		params = append(params, newParam)
+		var arg Expr = newParam
+		if newParam.isVariadic {
+			arg = &ExprVaArg{
+				tok:  tok,
+				expr: newParam,
+			}
+		}
+		args = append(args, arg)
+	}
+
+	funcLitSeq++
+	fn := &DeclFunc{
+		tok:      tok,
+		pkgPath:  walkingFunc.pkgPath,
+		pkg:      walkingFunc.pkg,
+		fname:    identifier(Sprintf("%s.func%d", string(walkingFunc.fname), funcLitSeq)),
+		rettypes: sig.rettypes,
+		params:   params,
+		captures: []*Capture{
+			&Capture{
+				outer: recvvar,
+				inner: inner,
+			},
+		},
+	}
+	fn.labelDeferHandler = string(makeLabel()) + "_defer_handler"
+
+	var call Expr = &ExprMethodcall{
+		tok:      tok,
+		receiver: inner,
+		fname:    e.fieldname,
+		args:     args,
+	}
+	var stmt Stmt
+	if len(sig.rettypes) == 0 {
+		stmt = &StmtExpr{
+			tok:  tok,
+			expr: call,
+		}
+	} else {
+		stmt = &StmtReturn{
+			tok:               tok,
+			exprs:             []Expr{call},
+			rettypes:          sig.rettypes,
+			labelDeferHandler: fn.labelDeferHandler,
+		}
+	}
+	fn.body = &StmtSatementList{
+		tok:   tok,
+		stmts: []Stmt{stmt},
+	}
+	// the closure is walked after the enclosing function
+	walkingPkg.funcs = append(walkingPkg.funcs, fn)
+
+	return &ExprMethodValue{
+		tok:      tok,
+		receiver: walkExpr(recv),
+		recvvar:  recvvar,
+		funcdef:  fn,
+	}
+}
+
+// https://golang.org/ref/spec#Assignments
+// The assignment proceeds in two phases.
+// First, the operands of index expressions and pointer indirections
+// on the left and the expressions on the right are all evaluated in the usual order.
+// Second, the assignments are carried out in left-to-right order.
+//
+// lowerTupleAssignment rewrites "a, b = x, y" into single assignments
+// that go through temporary variables.
+func lowerTupleAssignment(s *StmtAssignment) Stmt {
+	var stmts []Stmt
+	tok := s.token()
+
+	// phase 1: operands on the left
+	var targets []Expr
+	for _, left := range s.lefts {
+		left = unwrapRel(left)
+		var target Expr = left
+		switch left.(type) {
+		case *ExprIndex:
+			e := left.(*ExprIndex)
+			mapType := e.collection.getGtype()
+			if mapType.getKind() == G_MAP {
+				collection := newTempVariable(tok, mapType)
+				stmts = append(stmts, newAssignment(tok, collection, e.collection))
+				index := newTempVariable(tok, mapType.Underlying().mapKey)
+				stmts = append(stmts, newAssignment(tok, index, e.index))
+				target = &ExprIndex{
+					tok:        e.tok,
+					collection: collection,
+					index:      index,
+				}
+			} else {
+				var stmt Stmt
+				target, stmt = lowerToIndirection(tok, left)
+				stmts = append(stmts, stmt)
+			}
+		case *ExprStructField:
+			var stmt Stmt
+			target, stmt = lowerToIndirection(tok, left)
+			stmts = append(stmts, stmt)
+		case *ExprUop:
+			e := left.(*ExprUop)
+			if e.op == "*" {
+				pointer := newTempVariable(tok, e.operand.getGtype())
+				stmts = append(stmts, newAssignment(tok, pointer, e.operand))
+				target = &ExprUop{
+					tok:     e.tok,
+					op:      e.op,
+					operand: pointer,
+				}
+			}
+		}
+		targets = append(targets, target)
+	}
+
+	// phase 1: expressions on the right
+	var values []Expr
+	for i, right := range s.rights {
+		left := targets[i]
+		if left == nil || left.getGtype() == nil {
+			// "_"
+			stmts = append(stmts, newAssignment(tok, s.lefts[i], right))
+			values = append(values, nil)
+			continue
+		}
+		if evalConst(right) != nil {
+			values = append(values, right)
+			continue
+		}
+		value := newTempVariable(tok, left.getGtype())
+		stmts = append(stmts, newAssignment(tok, value, right))
+		values = append(values, value)
+	}
+
+	// phase 2: assignments in left-to-right order
+	for i, target := range targets {
+		if values[i] == nil {
+			continue
+		}
+		stmts = append(stmts, newAssignment(tok, target, values[i]))
+	}
+
+	return &StmtSatementList{
+		tok:   tok,
+		stmts: stmts,
+	}
+}
+
+func newAssignment(tok *Token, left Expr, right Expr) Stmt {
+	return &StmtAssignment{
+		tok:    tok,
+		lefts:  []Expr{left},
+		rights: []Expr{right},
+	}
+}
+
+// lowerToIndirection evaluates the address of an addressable operand
+// into a temporary pointer, and returns the indirection through it.
+func lowerToIndirection(tok *Token, left Expr) (Expr, Stmt) {
+	var address Expr = &ExprUop{
+		tok:     left.token(),
+		op:      "&",
+		operand: left,
+	}
+	pointer := newTempVariable(tok, address.getGtype())
+	var indirection Expr = &ExprUop{
+		tok:     left.token(),
+		op:      "*",
+		operand: pointer,
+	}
+	return indirection, newAssignment(tok, pointer, address)
+}
+
 func walkStmtList(stmtList *StmtSatementList) *StmtSatementList {
 	if stmtList == nil {
 		return nil
@@ -179,6 +685,7 @@ func walkExpr(expr Expr) Expr {
 		return e.expr
 	case *ExprNilLiteral:
 	case *ExprNumberLiteral:
+	case *ExprFloatLiteral:
 	case *ExprStringLiteral:
 	case *ExprVariable:
 	case *ExprConstVariable:
@@ -201,9 +708,21 @@ func walkExpr(expr Expr) Expr {
 		if funcall.rel.expr == nil && funcall.rel.gtype != nil {
 			// Conversion
 			r = &IrExprConversion{
-				tok:     funcall.token(),
-				toGtype: funcall.rel.gtype,
-				arg:     funcall.args[0],
+				tok: funcall.token(),
+				toGtype: &Gtype{
+					kind:     G_NAMED,
+					relation: funcall.rel,
+				},
+				arg: funcall.args[0],
+			}
+			return r
+		}
+		if _, ok := funcall.rel.expr.(*ExprFuncRef); !ok {
+			// call of a func value
+			r = &ExprFuncValueCall{
+				tok:  funcall.token(),
+				fn:   funcall.rel.expr,
+				args: funcall.args,
 			}
 			return r
 		}
@@ -233,8 +752,31 @@ func walkExpr(expr Expr) Expr {
 				tok: arg.token(),
 				arg: arg,
 			}
-		case builtinSyscall, builtinClone:
+		case builtinSyscall, builtinClone, builtinRecover:
 			return proxyToIRuntimeFunc(funcall)
+		case builtinClose:
+			assert(len(funcall.args) == 1, funcall.token(), "invalid arguments for close()")
+			if funcall.args[0].getGtype().getKind() != G_CHAN {
+				errorft(funcall.token(), "invalid operation: close of non-chan type %s", funcall.args[0].getGtype().String())
+			}
+			var staticCall *IrCall = &IrCall{
+				tok:      funcall.token(),
+				origExpr: funcall,
+				callee:   decl,
+				symbol:   getFuncSymbol(IRuntimePath, "closechan"),
+				args:     funcall.args,
+			}
+			return staticCall
+		case builtinDelete:
+			assert(len(funcall.args) == 2, funcall.token(), "invalid arguments for delete()")
+			if funcall.args[0].getGtype().getKind() != G_MAP {
+				errorft(funcall.token(), "invalid argument: %s is not a map", funcall.args[0].getGtype().String())
+			}
+			return &IrMapDelete{
+				tok: funcall.token(),
+				m:   funcall.args[0],
+				key: funcall.args[1],
+			}
 		case builtinMake:
 			assert(funcall.typarg != nil, funcall.token(), "make() should take Type argment")
 			var staticCall *IrCall = &IrCall{
@@ -264,6 +806,15 @@ func walkExpr(expr Expr) Expr {
 					lenArg: lenArg,
 				}
 				return mapInitializer
+			case G_CHAN:
+				var sizeArg Expr = &ExprNumberLiteral{val: 0}
+				if len(funcall.args) >= 1 {
+					sizeArg = funcall.args[0]
+				}
+				elementType := funcall.typarg.Underlying().elementType
+				staticCall.symbol = getFuncSymbol(IRuntimePath, "makechan")
+				staticCall.args = []Expr{&ExprNumberLiteral{val: elementType.getSize()}, sizeArg}
+				return staticCall
 			default:
 				errorft(funcall.token(), "make for invalid type:%s", funcall.typarg.String())
 			}
@@ -305,6 +856,31 @@ func walkExpr(expr Expr) Expr {
 			methodCall.args[i] = arg
 		}
 		methodCall.receiver = walkExpr(methodCall.receiver)
+		if methodCall.getFuncField() != nil {
+			// call of a func value in a struct field
+			fn := &ExprStructField{
+				tok:       methodCall.token(),
+				strct:     methodCall.receiver,
+				fieldname: methodCall.fname,
+			}
+			r = &ExprFuncValueCall{
+				tok:  methodCall.token(),
+				fn:   fn,
+				args: methodCall.args,
+			}
+			return r
+		}
+		if methodCall.getOrigType().getKind() != G_INTERFACE {
+			recvType := methodCall.getMethod().funcdef.receiver.getGtype()
+			if recvType.getKind() == G_POINTER && methodCall.receiver.getGtype().getKind() != G_POINTER {
+				// x.m() is shorthand for (&x).m()
+				methodCall.receiver = &ExprUop{
+					tok:     methodCall.token(),
+					op:      "&",
+					operand: methodCall.receiver,
+				}
+			}
+		}
 		expr = methodCall
 		return expr
 	case *ExprBinop:
@@ -317,6 +893,15 @@ func walkExpr(expr Expr) Expr {
 		e.operand = walkExpr(e.operand)
 		return e
 	case *ExprFuncRef:
+	case *ExprFuncLiteral:
+		// the body is walked as an independent function
+	case *ExprFuncValueCall:
+		e := expr.(*ExprFuncValueCall)
+		e.fn = walkExpr(e.fn)
+		for i, arg := range e.args {
+			e.args[i] = walkExpr(arg)
+		}
+		return e
 	case *ExprSlice:
 		e := expr.(*ExprSlice)
 		e.collection = walkExpr(e.collection)
@@ -338,6 +923,9 @@ func walkExpr(expr Expr) Expr {
 		}
 		return e
 	case *ExprTypeAssertion:
+		e := expr.(*ExprTypeAssertion)
+		e.expr = walkExpr(e.expr)
+		return e
 	case *ExprVaArg:
 		e := expr.(*ExprVaArg)
 		e.expr = walkExpr(e.expr)
@@ -351,6 +939,11 @@ func walkExpr(expr Expr) Expr {
 	case *ExprStructField:
 		e := expr.(*ExprStructField)
 		e.strct = walkExpr(e.strct)
+		// a promoted field x.f is expanded into x.T.f here
+		e.getGtype()
+		if e.getMethodSig() != nil {
+			return makeMethodValue(e)
+		}
 		return e
 	case *ExprTypeSwitchGuard:
 	case *ExprMapLiteral:
@@ -359,6 +952,13 @@ func walkExpr(expr Expr) Expr {
 			elm.key = walkExpr(elm.key)
 			elm.value = walkExpr(elm.value)
 		}
+	case *ExprChanRecv:
+		e := expr.(*ExprChanRecv)
+		e.ch = walkExpr(e.ch)
+		if e.selectBuf == nil && isWideElement(e.getGtype()) {
+			e.buf = newTempVariable(e.token(), e.getGtype())
+		}
+		return e
 	case *ExprLen:
 
 	case *ExprCap:
@@ -386,6 +986,16 @@ func walkStmt(stmt Stmt) Stmt {
 		s.rangeexpr = walkExpr(s.rangeexpr)
 		s.block = walkStmtList(s.block)
 		return s
+	case *IrStmtRangeString:
+		s := stmt.(*IrStmtRangeString)
+		s.rangeexpr = walkExpr(s.rangeexpr)
+		s.block = walkStmtList(s.block)
+		return s
+	case *IrStmtRangeChan:
+		s := stmt.(*IrStmtRangeChan)
+		walkStmt(s.recv)
+		s.block = walkStmtList(s.block)
+		return s
 	case *IrStmtForRangeList:
 		s := stmt.(*IrStmtForRangeList)
 		s.init = walkStmt(s.init)
@@ -413,6 +1023,18 @@ func walkStmt(stmt Stmt) Stmt {
 			e := walkExpr(expr)
 			s.exprs[i] = e
 		}
+		if len(s.exprs) == len(s.rettypes) {
+			for i, rettype := range s.rettypes {
+				gtype := s.exprs[i].getGtype()
+				if !isAggregate(rettype) || gtype == nil || !isAggregate(gtype) {
+					continue
+				}
+				if s.bufs == nil {
+					s.bufs = make([]*ExprVariable, len(s.rettypes), len(s.rettypes))
+				}
+				s.bufs[i] = newTempVariable(s.token(), rettype)
+			}
+		}
 		return s
 	case *StmtInc:
 		s := stmt.(*StmtInc)
@@ -438,6 +1060,9 @@ func walkStmt(stmt Stmt) Stmt {
 			s.lefts[i] = left
 		}
 
+		if len(s.lefts) > 1 && len(s.rights) > 1 {
+			return lowerTupleAssignment(s)
+		}
 		return s
 	case *StmtShortVarDecl:
 		s := stmt.(*StmtShortVarDecl)
@@ -446,7 +1071,25 @@ func walkStmt(stmt Stmt) Stmt {
 			lefts:  s.lefts,
 			rights: s.rights,
 		}
+		// captured variables get new cells at every declaration
+		var boxed []*ExprVariable
+		for _, left := range s.lefts {
+			variable, ok := unwrapRel(left).(*ExprVariable)
+			if ok && variable.isBoxed {
+				boxed = append(boxed, variable)
+			}
+		}
 		s2 = walkStmt(s2)
+		if len(boxed) > 0 {
+			var newCells Stmt = &IrStmtNewCells{
+				tok:       s.tok,
+				variables: boxed,
+			}
+			s2 = &StmtSatementList{
+				tok:   s.tok,
+				stmts: []Stmt{newCells, s2},
+			}
+		}
 		return s2
 	case *StmtContinue:
 		s := stmt.(*StmtContinue)
@@ -454,6 +1097,16 @@ func walkStmt(stmt Stmt) Stmt {
 	case *StmtBreak:
 		s := stmt.(*StmtBreak)
 		return s
+	case *StmtLabeled:
+		s := stmt.(*StmtLabeled)
+		s.stmt = walkStmt(s.stmt)
+		return s
+	case *StmtGoto:
+		s := stmt.(*StmtGoto)
+		return s
+	case *StmtFallthrough:
+		s := stmt.(*StmtFallthrough)
+		return s
 	case *StmtExpr:
 		s := stmt.(*StmtExpr)
 		s.expr = walkExpr(s.expr)
@@ -462,12 +1115,37 @@ func walkStmt(stmt Stmt) Stmt {
 		s := stmt.(*StmtDefer)
 		s.expr = walkExpr(s.expr)
 		return s
+	case *StmtSelect:
+		s := stmt.(*StmtSelect)
+		for _, clause := range s.cases {
+			clause.walk(s)
+		}
+		s.dflt = walkStmtList(s.dflt)
+		return s
+	case *StmtSend:
+		s := stmt.(*StmtSend)
+		s.ch = walkExpr(s.ch)
+		s.value = walkExpr(s.value)
+		gtype := s.ch.getGtype()
+		if gtype.getKind() == G_CHAN && gtype.Underlying().elementType.getKind() == G_INTERFACE {
+			if s.value.getGtype() != nil && s.value.getGtype().getKind() != G_INTERFACE {
+				s.value = &IrExprConversionToInterface{
+					tok: s.value.token(),
+					arg: s.value,
+				}
+			}
+		}
+		if gtype.getKind() == G_CHAN && isWideElement(s.elementType()) {
+			s.buf = newTempVariable(s.token(), s.elementType())
+		}
+		return s
 	case *StmtGo:
 		s := stmt.(*StmtGo)
 		s.expr = walkExpr(s.expr)
-		_, ok := s.expr.(*ExprFuncallOrConversion)
-		if !ok {
-			panic("invalid expresson for go routine")
+		switch s.expr.(type) {
+		case *ExprFuncallOrConversion, *ExprFuncValueCall, *ExprMethodcall, *IrCall:
+		default:
+			errorft(s.token(), "expression in go must be function call")
 		}
 		return s
 	case *StmtSwitch:
@@ -7,10 +7,6 @@ package builtin
 //	append cap close complex copy delete imag len
 //	make new panic print println real recover
 
-func recover() interface{} {
-	return nil
-}
-
 type error interface {
-	Error() []byte
+	Error() string
 }
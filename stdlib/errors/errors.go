@@ -1,5 +1,13 @@
 package errors
 
-func New() error {
-	return nil
+func New(text string) error {
+	return &errorString{s: text}
+}
+
+type errorString struct {
+	s string
+}
+
+func (e *errorString) Error() string {
+	return e.s
 }
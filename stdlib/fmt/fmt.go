@@ -54,20 +54,30 @@ func (p *printer) doPrintf(format string, a []interface{}) {
 	var buf []byte
 	var inPercent bool
 	var argIndex int
+	var prec int = -1 // -1 means no precision is given
 
 	for _, c := range []byte(format) {
 		if inPercent {
+			if c == '.' {
+				prec = 0
+				continue
+			}
+			if prec >= 0 && '0' <= c && c <= '9' {
+				prec = prec*10 + int(c-'0')
+				continue
+			}
 			if c == '%' {
 				buf = append(buf, c)
 			} else {
 				arg := a[argIndex]
 				argIndex++
-				s := p.printArg(arg, c)
+				s := p.printArg(arg, c, prec)
 				for _, _c := range []byte(s) {
 					buf = append(buf, _c)
 				}
 			}
 			inPercent = false
+			prec = -1
 		} else {
 			if  c == '%' {
 				inPercent = true
@@ -80,7 +90,21 @@ func (p *printer) doPrintf(format string, a []interface{}) {
 	p.buf = buf
 }
 
-func (p *printer) printArg(arg interface{}, verb byte) string {
+// %e, %f and %g. The precision of %e and %f is 6 by default.
+func formatFloat(f float64, verb byte, prec int, bitSize int) string {
+	switch verb {
+	case 'e', 'f':
+		if prec < 0 {
+			prec = 6
+		}
+	case 'g':
+	default:
+		verb = 'g'
+	}
+	return strconv.FormatFloat(f, verb, prec, bitSize)
+}
+
+func (p *printer) printArg(arg interface{}, verb byte, prec int) string {
 
 	switch verb {
 	case 'T':
@@ -99,7 +123,7 @@ func (p *printer) printArg(arg interface{}, verb byte) string {
 		switch verb {
 		case 'c':
 			b := arg.(byte)
-			s = string([]byte{b})
+			s = string(rune(b))
 		case 'd':
 			b := arg.(byte)
 			i := int(b)
@@ -109,8 +133,32 @@ func (p *printer) printArg(arg interface{}, verb byte) string {
 		}
 	case int: // for %d
 		s = strconv.Itoa(arg.(int))
+	case int8: // for %d
+		s = strconv.FormatInt(int64(arg.(int8)), 10)
+	case int16: // for %d
+		s = strconv.FormatInt(int64(arg.(int16)), 10)
+	case int32: // for %c or %d
+		if verb == 'c' {
+			s = string(arg.(int32))
+		} else {
+			s = strconv.FormatInt(int64(arg.(int32)), 10)
+		}
+	case int64: // for %d
+		s = strconv.FormatInt(arg.(int64), 10)
+	case uint: // for %d
+		s = strconv.FormatUint(uint64(arg.(uint)), 10)
 	case uint16: // for %d
 		s = strconv.Itoa(int(arg.(uint16)))
+	case uint32: // for %d
+		s = strconv.FormatUint(uint64(arg.(uint32)), 10)
+	case uint64: // for %d
+		s = strconv.FormatUint(arg.(uint64), 10)
+	case uintptr: // for %d
+		s = strconv.FormatUint(uint64(arg.(uintptr)), 10)
+	case float64: // for %e %f %g
+		s = formatFloat(arg.(float64), verb, prec, 64)
+	case float32: // for %e %f %g
+		s = formatFloat(float64(arg.(float32)), verb, prec, 32)
 	case bool: // for %v
 		if arg.(bool) {
 			s = "true"
@@ -15,8 +15,28 @@ func TypeOf(arg interface{}) *Type {
 		name = "uint8"
 	case bool:
 		name = "bool"
+	case int8:
+		name = "int8"
+	case int16:
+		name = "int16"
+	case int32:
+		name = "int32"
+	case int64:
+		name = "int64"
+	case uint:
+		name = "uint"
+	case uint16:
+		name = "uint16"
+	case uint32:
+		name = "uint32"
+	case uint64:
+		name = "uint64"
 	case uintptr:
 		name = "uintptr"
+	case float64:
+		name = "float64"
+	case float32:
+		name = "float32"
 	}
 	typ := &Type{
 		name: name,
@@ -0,0 +1,15 @@
+// Declarations only. Minigo won't parse this file.
+package runtime
+
+// Actual definitions are in iruntime code
+func callerpc(skip int) uintptr {
+	return 0
+}
+
+func pcfuncname(pc uintptr) string {
+	return ""
+}
+
+func pcfileline(pc uintptr) (string, int, bool) {
+	return "", 0, false
+}
@@ -1,18 +1,35 @@
 package runtime
 
+// The function table is emitted by the compiler and read by iruntime.
+
 // func Caller(skip int) (pc uintptr, file string, line int, ok bool) {
-func Caller(n int) (int, string, int, bool) {
-	return 0, "", 0, false
+func Caller(skip int) (uintptr, string, int, bool) {
+	pc := callerpc(skip)
+	if pc == 0 {
+		return 0, "", 0, false
+	}
+	// pc is a return address
+	file, line, ok := pcfileline(pc - 1)
+	return pc, file, line, ok
 }
 
-func FuncForPC(pc int) *Func {
-	return nil
+func FuncForPC(pc uintptr) *Func {
+	name := pcfuncname(pc)
+	if name == "" {
+		return nil
+	}
+	return &Func{
+		name: name,
+	}
 }
 
 type Func struct {
-	id int
+	name string
 }
 
 func (f *Func) Name() string {
-	return ""
+	if f == nil {
+		return ""
+	}
+	return f.name
 }
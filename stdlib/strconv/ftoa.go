@@ -0,0 +1,9 @@
+package strconv
+
+// FormatFloat converts the floating-point number f to a string,
+// according to the format ('e', 'f' or 'g') and the precision prec.
+// The precision -1 uses the smallest number of digits necessary to represent the value uniquely.
+// bitSize is 32 when f was converted from a float32.
+func FormatFloat(f float64, format byte, prec int, bitSize int) string {
+	return ftoa(f, format, prec, bitSize)
+}
@@ -0,0 +1,9 @@
+// Declarations only. Minigo won't parse this file.
+package strconv
+
+import gostrconv "strconv"
+
+// Actual definition is in iruntime code
+func ftoa(f float64, format byte, prec int, bitSize int) string {
+	return gostrconv.FormatFloat(f, format, prec, bitSize)
+}
@@ -29,26 +29,40 @@ func Atoi(gs string) (int, error) {
 	return n, nil
 }
 
+// Itoa is equivalent to FormatInt(int64(i), 10).
 func Itoa(i int) string {
-	var r []byte
-	var tmp []byte
+	return FormatInt(int64(i), 10)
+}
 
-	if i < 0 {
-		i = i * -1
-		r = append(r, '-')
+// FormatUint returns the string representation of i in the given base,
+// for 2 <= base <= 36.
+func FormatUint(i uint64, base int) string {
+	if i == 0 {
+		return "0"
 	}
+	b := uint64(base)
+	var tmp []byte
 	for i > 0 {
-		mod := i % 10
-		tmp = append(tmp, byte('0')+byte(mod))
-		i = i / 10
+		d := byte(i % b)
+		if d < 10 {
+			tmp = append(tmp, '0'+d)
+		} else {
+			tmp = append(tmp, 'a'+d-10)
+		}
+		i = i / b
 	}
-
+	var r []byte
 	for j := len(tmp) - 1; j >= 0; j-- {
 		r = append(r, tmp[j])
 	}
+	return string(r)
+}
 
-	if len(r) == 0 {
-		return "0"
+// FormatInt returns the string representation of i in the given base,
+// for 2 <= base <= 36.
+func FormatInt(i int64, base int) string {
+	if i < 0 {
+		return "-" + FormatUint(uint64(-i), base)
 	}
-	return string(r)
+	return FormatUint(uint64(i), base)
 }
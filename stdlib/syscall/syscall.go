@@ -20,14 +20,22 @@ func Open(path string, flag int, perm int) (int, error) {
 	var fd int
 	var _p0 *byte
 	_p0,_ = BytePtrFromString(path)
-	fd = Syscall(__x64_sys_open, uintptr(unsafe.Pointer(_p0)), uintptr(flag), 0)
+	fd = int(Syscall(__x64_sys_open, uintptr(unsafe.Pointer(_p0)), uintptr(flag), 0))
 	return fd, nil
 }
 
+// Single-word zero for use when we need a valid pointer to 0 bytes.
+var _zero uintptr
+
 func Write(fd int, b []byte) (int, error) {
-	var addr *byte = &b[0]
+	var _p0 unsafe.Pointer
+	if len(b) > 0 {
+		_p0 = unsafe.Pointer(&b[0])
+	} else {
+		_p0 = unsafe.Pointer(&_zero)
+	}
 	var n int
-	n = Syscall(__x64_sys_write, uintptr(fd), uintptr(unsafe.Pointer(addr)), uintptr(len(b)))
+	n = int(Syscall(__x64_sys_write, uintptr(fd), uintptr(_p0), uintptr(len(b))))
 	return n, nil
 }
 
@@ -35,7 +43,7 @@ func Read(fd int, b []byte) (int, error) {
 	var ptr *byte
 	ptr = &b[0]
 	var nread int
-	nread = Syscall(__x64_sys_read, uintptr(fd), uintptr(unsafe.Pointer(ptr)), uintptr(cap(b)))
+	nread = int(Syscall(__x64_sys_read, uintptr(fd), uintptr(unsafe.Pointer(ptr)), uintptr(cap(b))))
 	return nread, nil
 }
 
@@ -46,7 +54,7 @@ func ReadDirent(fd int, buf []byte) (int, error) {
 func Getdents(fd int, buf []byte) (int, error) {
 	var _p0 unsafe.Pointer
 	_p0 = unsafe.Pointer(&buf[0])
-	nread := Syscall(_x64_getdents64, uintptr(fd), uintptr(_p0), uintptr(len(buf)))
+	nread := int(Syscall(_x64_getdents64, uintptr(fd), uintptr(_p0), uintptr(len(buf))))
 	return nread, nil
 }
 
@@ -80,6 +88,7 @@ type Timespec struct {
 	Nsec int
 }
 
-func Nanosleep(ts *Timespec) {
-	Syscall(__x64_sys_nanosleep, uintptr(unsafe.Pointer(ts)), 0, 0)
+func Nanosleep(ts *Timespec, leftover *Timespec) error {
+	Syscall(__x64_sys_nanosleep, uintptr(unsafe.Pointer(ts)), uintptr(unsafe.Pointer(leftover)), 0)
+	return nil
 }
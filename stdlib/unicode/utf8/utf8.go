@@ -0,0 +1,134 @@
+package utf8
+
+// https://golang.org/pkg/unicode/utf8/
+const (
+	RuneError = '\uFFFD'     // the "error" Rune or "Unicode replacement character"
+	RuneSelf  = 0x80         // characters below RuneSelf are represented as themselves in a single byte.
+	MaxRune   = '\U0010FFFF' // Maximum valid Unicode code point.
+	UTFMax    = 4            // maximum number of bytes of a UTF-8 encoded Unicode character.
+)
+
+const (
+	surrogateMin = 0xD800
+	surrogateMax = 0xDFFF
+)
+
+// DecodeRune unpacks the first UTF-8 encoding in p and returns the rune and its width in bytes.
+// If p is empty it returns (RuneError, 0).
+// If the encoding is invalid, it returns (RuneError, 1).
+func DecodeRune(p []byte) (rune, int) {
+	n := len(p)
+	if n < 1 {
+		return RuneError, 0
+	}
+	b0 := p[0]
+	if b0 < RuneSelf {
+		return rune(b0), 1
+	}
+
+	var size int
+	var r rune
+	// the range of the second byte
+	var lo byte = 0x80
+	var hi byte = 0xBF
+	switch {
+	case 0xC2 <= b0 && b0 <= 0xDF:
+		size = 2
+		r = rune(b0) - 0xC0
+	case 0xE0 <= b0 && b0 <= 0xEF:
+		size = 3
+		r = rune(b0) - 0xE0
+		if b0 == 0xE0 {
+			// overlong
+			lo = 0xA0
+		} else if b0 == 0xED {
+			// surrogate halves
+			hi = 0x9F
+		}
+	case 0xF0 <= b0 && b0 <= 0xF4:
+		size = 4
+		r = rune(b0) - 0xF0
+		if b0 == 0xF0 {
+			// overlong
+			lo = 0x90
+		} else if b0 == 0xF4 {
+			// beyond MaxRune
+			hi = 0x8F
+		}
+	default:
+		return RuneError, 1
+	}
+	if n < size {
+		return RuneError, 1
+	}
+	for i := 1; i < size; i++ {
+		b := p[i]
+		if b < lo || hi < b {
+			return RuneError, 1
+		}
+		lo = 0x80
+		hi = 0xBF
+		r = r*64 + (rune(b) - 0x80)
+	}
+	return r, size
+}
+
+// DecodeRuneInString is like DecodeRune but its input is a string.
+func DecodeRuneInString(s string) (rune, int) {
+	if len(s) > UTFMax {
+		s = s[0:UTFMax]
+	}
+	return DecodeRune([]byte(s))
+}
+
+// AppendRune appends the UTF-8 encoding of r to the end of p and returns the extended buffer.
+// An invalid rune is encoded as RuneError.
+func AppendRune(p []byte, r rune) []byte {
+	if !ValidRune(r) {
+		r = RuneError
+	}
+	switch {
+	case r < RuneSelf:
+		p = append(p, byte(r))
+	case r < 0x800:
+		p = append(p, byte(0xC0+r/64))
+		p = append(p, byte(0x80+r%64))
+	case r < 0x10000:
+		p = append(p, byte(0xE0+r/4096))
+		p = append(p, byte(0x80+(r/64)%64))
+		p = append(p, byte(0x80+r%64))
+	default:
+		p = append(p, byte(0xF0+r/262144))
+		p = append(p, byte(0x80+(r/4096)%64))
+		p = append(p, byte(0x80+(r/64)%64))
+		p = append(p, byte(0x80+r%64))
+	}
+	return p
+}
+
+// RuneLen returns the number of bytes required to encode the rune.
+// It returns -1 if the rune is not a valid value to encode in UTF-8.
+func RuneLen(r rune) int {
+	switch {
+	case !ValidRune(r):
+		return -1
+	case r < RuneSelf:
+		return 1
+	case r < 0x800:
+		return 2
+	case r < 0x10000:
+		return 3
+	}
+	return 4
+}
+
+// ValidRune reports whether r can be legally encoded as UTF-8.
+func ValidRune(r rune) bool {
+	switch {
+	case 0 <= r && r < surrogateMin:
+		return true
+	case surrogateMax < r && r <= MaxRune:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+func two() (int, bool) {
+	return 1, true
+}
+
+func next(k int) (string, int) {
+	return "skipped", k + 2
+}
+
+func ignore(_ int, s string) string {
+	_ = s
+	return s
+}
+
+var errNotFound error
+
+func find(key string) (string, error) {
+	if key == "" {
+		return "", errNotFound
+	}
+	return key, nil
+}
+
+func main() {
+	errNotFound = errors.New("not found")
+	_, ok := two()
+	var s string = "str"
+	_ = s
+	_ = ok
+	_ = 1.5
+	var _ = 3
+	fmt.Printf("%v %s\n", ok, ignore(0, "x"))
+	for _, c := range "ab" {
+		_ = c
+	}
+	var k int
+	for k < 5 {
+		_, k = next(k)
+	}
+	_, b := two()
+	fmt.Printf("%d %v\n", k, b)
+
+	_, err := find("")
+	if err != nil {
+		s = err.Error()
+		fmt.Printf("%s\n", s)
+	}
+	if err == errNotFound {
+		fmt.Printf("errNotFound\n")
+	}
+	v, err := find("key")
+	if err == nil {
+		fmt.Printf("%s\n", v)
+	}
+	e := errors.New("other")
+	if e != nil && e != errNotFound {
+		fmt.Printf("%s\n", e.Error())
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import "fmt"
+
+type point struct {
+	x int
+	y int
+}
+
+var minInt int = -9223372036854775808
+
+// run f and report whether it has caused a run-time panic
+func try(name string, f func()) {
+	defer func() {
+		r := recover()
+		if r != nil {
+			fmt.Printf("%s: recovered\n", name)
+		}
+	}()
+	f()
+	fmt.Printf("%s: ok\n", name)
+}
+
+func index(i int) {
+	var s []int = []int{1, 2, 3}
+	fmt.Printf("%d\n", s[i])
+}
+
+func indexArray(i int) {
+	var a [3]int
+	a[i] = 1
+	fmt.Printf("%d\n", a[i])
+}
+
+func indexString(i int) {
+	s := "abc"
+	fmt.Printf("%c\n", s[i])
+}
+
+func storeString(i int) {
+	var names []string = []string{"a", "b"}
+	names[i] = "c"
+	fmt.Printf("%s\n", names[i])
+}
+
+func slice(low int, high int) {
+	var s []int = make([]int, 3, 5)
+	t := s[low:high]
+	fmt.Printf("%d %d\n", len(t), cap(t))
+}
+
+func slice3(low int, high int, max int) {
+	var s []int = make([]int, 3, 5)
+	t := s[low:high:max]
+	fmt.Printf("%d %d\n", len(t), cap(t))
+}
+
+func sliceString(low int, high int) {
+	s := "hello"
+	fmt.Printf("%s\n", s[low:high])
+}
+
+func divide(a int, b int) {
+	fmt.Printf("%d %d\n", a/b, a%b)
+}
+
+func deref(p *point) {
+	fmt.Printf("%d\n", p.y)
+}
+
+// the checks run even when the value is discarded
+func blank(i int, p *point, z int) {
+	s := []int{1, 2, 3}
+	_ = s[i]
+	_ = s[1:i]
+	_ = p.y
+	_ = 5 / z
+}
+
+// len and cap of a slice expression check its bounds
+func lenCap(high int) {
+	u := make([]int, 2, 2)
+	str := "abc"
+	fmt.Printf("%d %d\n", len(u[0:high]), cap(u[1:high]))
+	fmt.Printf("%d\n", len(str[1:high]))
+}
+
+func main() {
+	try("index 2", func() { index(2) })
+	try("index 3", func() { index(3) })
+	try("index -1", func() { index(-1) })
+	try("array 2", func() { indexArray(2) })
+	try("array 3", func() { indexArray(3) })
+	try("string 2", func() { indexString(2) })
+	try("string 3", func() { indexString(3) })
+	try("store 1", func() { storeString(1) })
+	try("store 2", func() { storeString(2) })
+	try("slice 1:5", func() { slice(1, 5) })
+	try("slice 1:6", func() { slice(1, 6) })
+	try("slice 2:1", func() { slice(2, 1) })
+	try("slice 0:2:5", func() { slice3(0, 2, 5) })
+	try("slice 0:2:6", func() { slice3(0, 2, 6) })
+	try("slice 0:3:2", func() { slice3(0, 3, 2) })
+	try("string 1:5", func() { sliceString(1, 5) })
+	try("string 1:6", func() { sliceString(1, 6) })
+	try("divide 7/2", func() { divide(7, 2) })
+	try("divide 7/0", func() { divide(7, 0) })
+	try("divide min/-1", func() { divide(minInt, -1) })
+	try("deref", func() { deref(&point{x: 1, y: 2}) })
+	try("deref nil", func() { deref(nil) })
+	try("deref nil again", func() { deref(nil) })
+	try("blank ok", func() { blank(2, &point{}, 1) })
+	try("blank index", func() { blank(5, &point{}, 1) })
+	try("blank nil", func() { blank(2, nil, 1) })
+	try("blank divide", func() { blank(2, &point{}, 0) })
+	try("lenCap 2", func() { lenCap(2) })
+	try("lenCap 3", func() { lenCap(3) })
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+type T struct {
+	name string
+}
+
+func baseName(file string) string {
+	var i int
+	for j := 0; j < len(file); j++ {
+		if file[j] == '/' {
+			i = j + 1
+		}
+	}
+	return file[i:]
+}
+
+func where(skip int) {
+	pc, file, line, ok := runtime.Caller(skip)
+	if !ok {
+		fmt.Printf("unknown\n")
+		return
+	}
+	fmt.Printf("%s %s:%d\n", runtime.FuncForPC(pc).Name(), baseName(file), line)
+}
+
+func (t *T) method() {
+	where(1)
+}
+
+func (t T) valueMethod() {
+	where(1)
+}
+
+func inner() {
+	where(2)
+}
+
+func outer() {
+	inner()
+}
+
+func deferred() {
+	defer where(1)
+	fmt.Printf("deferred\n")
+}
+
+func main() {
+	where(0)
+	where(1)
+	t := &T{name: "t"}
+	t.method()
+	var v T
+	v.valueMethod()
+	outer()
+	deferred()
+	if runtime.FuncForPC(0) == nil {
+		fmt.Printf("FuncForPC(0) is nil\n")
+	}
+}
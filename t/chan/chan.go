@@ -0,0 +1,188 @@
+package main
+
+import "fmt"
+
+func f1() {
+	ch := make(chan int, 3)
+	ch <- 1
+	ch <- 2
+	fmt.Printf("%d\n", len(ch)+cap(ch)-4) // 1
+	v := <-ch
+	fmt.Printf("%d\n", v+1) // 2
+	v = <-ch
+	fmt.Printf("%d\n", v+1) // 3
+}
+
+func f2() {
+	ch := make(chan int)
+	done := make(chan bool)
+	go func() {
+		v := <-ch
+		fmt.Printf("%d\n", v) // 4
+		ch <- v + 1
+		done <- true
+	}()
+	ch <- 4
+	v := <-ch
+	<-done
+	fmt.Printf("%d\n", v) // 5
+}
+
+func f3() {
+	ch := make(chan string, 1)
+	ch <- "6"
+	close(ch)
+	s, ok := <-ch
+	if ok {
+		fmt.Printf("%s\n", s) // 6
+	}
+	s, ok = <-ch
+	if !ok && s == "" {
+		fmt.Printf("7\n")
+	}
+}
+
+func producer(ch chan<- int, from int, to int) {
+	for i := from; i <= to; i++ {
+		ch <- i
+	}
+	close(ch)
+}
+
+func f4() {
+	ch := make(chan int)
+	go func() {
+		producer(ch, 8, 11)
+	}()
+	for v := range ch {
+		fmt.Printf("%d\n", v) // 8,9,10,11
+	}
+}
+
+func worker(jobs <-chan int, results chan<- int) {
+	for j := range jobs {
+		results <- j * j
+	}
+}
+
+func f5() {
+	jobs := make(chan int, 100)
+	results := make(chan int, 100)
+	for w := 0; w < 4; w++ {
+		go func() {
+			worker(jobs, results)
+		}()
+	}
+	for i := 1; i <= 10; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	var sum int
+	for i := 1; i <= 10; i++ {
+		sum = sum + <-results
+	}
+	fmt.Printf("%d\n", sum-373) // 12
+}
+
+func f6() {
+	var ch chan int
+	if ch == nil {
+		fmt.Printf("13\n")
+	}
+	fmt.Printf("%d\n", len(ch)+14) // 14
+}
+
+func f7() {
+	ch := make(chan interface{}, 2)
+	ch <- 15
+	ch <- "16"
+	x := <-ch
+	i, _ := x.(int)
+	fmt.Printf("%d\n", i) // 15
+	x = <-ch
+	s, _ := x.(string)
+	fmt.Printf("%s\n", s) // 16
+}
+
+func f8() {
+	var chans []chan int
+	for i := 0; i < 3; i++ {
+		chans = append(chans, make(chan int))
+	}
+	for i := 0; i < 3; i++ {
+		c := chans[i]
+		n := i
+		go func() {
+			c <- n + 17
+		}()
+	}
+	for _, c := range chans {
+		fmt.Printf("%d\n", <-c) // 17,18,19
+	}
+}
+
+type point struct {
+	x    int
+	y    int
+	name string
+}
+
+type small struct {
+	n int
+}
+
+func producePoints(ch chan point, n int) {
+	for i := 0; i < n; i++ {
+		ch <- point{x: i, y: i * i, name: fmt.Sprintf("p%d", i)}
+	}
+	close(ch)
+}
+
+// channels of structs and arrays
+func f9() {
+	ch := make(chan point, 2)
+	go producePoints(ch, 3)
+	for p := range ch {
+		fmt.Printf("%d %d %s\n", p.x, p.y, p.name)
+	}
+	v, ok := <-ch
+	fmt.Printf("%d %s %v\n", v.x, v.name, ok) // 0  false
+
+	sc := make(chan small, 1)
+	sc <- small{n: 5}
+	s := <-sc
+	fmt.Printf("%d\n", s.n) // 5
+
+	ac := make(chan [3]int, 1)
+	arr := [3]int{7, 8, 9}
+	ac <- arr
+	got := <-ac
+	fmt.Printf("%d %d\n", got[0], got[2]) // 7 9
+
+	pc := make(chan point)
+	done := make(chan bool)
+	go func() {
+		q := point{x: 10, name: "sel"}
+		select {
+		case pc <- q:
+		}
+		done <- true
+	}()
+	select {
+	case r, ok := <-pc:
+		fmt.Printf("%d %s %v\n", r.x, r.name, ok) // 10 sel true
+	}
+	<-done
+}
+
+func main() {
+	f1()
+	f2()
+	f3()
+	f4()
+	f5()
+	f6()
+	f7()
+	f8()
+	f9()
+}
@@ -0,0 +1,105 @@
+package main
+
+import "fmt"
+
+func f1() {
+	add := func(a int, b int) int {
+		return a + b
+	}
+	fmt.Printf("%d\n", add(0, 1))
+}
+
+func f2() {
+	x := 1
+	inc := func() {
+		x = x + 1
+	}
+	inc()
+	fmt.Printf("%d\n", x)
+	x = 2
+	inc()
+	fmt.Printf("%d\n", x)
+}
+
+func f3() {
+	counter := 2
+	next := func() int {
+		counter++
+		return counter
+	}
+	next()
+	fmt.Printf("%d\n", next())
+}
+
+func f4() {
+	var s string = "five"
+	fmt.Printf("%d\n", func(prefix string) int {
+		return len(prefix) + len(s) - 3
+	}("abcd"))
+}
+
+func f5(n int) {
+	add := func(a int) {
+		n = n + a
+	}
+	add(3)
+	fmt.Printf("%d\n", n)
+}
+
+func f6() {
+	a := 3
+	outer := func() int {
+		b := 4
+		inner := func() int {
+			return a + b
+		}
+		return inner()
+	}
+	fmt.Printf("%d\n", outer())
+}
+
+type point struct {
+	x int
+	y int
+}
+
+func f7() {
+	p := point{x: 3, y: 5}
+	move := func() {
+		p.x = p.x + p.y
+	}
+	move()
+	fmt.Printf("%d\n", p.x)
+}
+
+func f8() {
+	var total int
+	for _, v := range []int{1, 2, 3, 3} {
+		func() {
+			total = total + v
+		}()
+	}
+	fmt.Printf("%d\n", total)
+}
+
+func f9() {
+	s := []int{8}
+	appendOne := func(v int) {
+		s = append(s, v)
+	}
+	appendOne(9)
+	appendOne(10)
+	fmt.Printf("%d\n", s[len(s)-1])
+}
+
+func main() {
+	f1()
+	f2()
+	f3()
+	f4()
+	f5(3)
+	f6()
+	f7()
+	f8()
+	f9()
+}
@@ -0,0 +1,63 @@
+package main
+
+import "fmt"
+
+type ByteSize float64
+
+const (
+	_           = iota
+	KB ByteSize = 1 << (10 * iota)
+	MB
+	GB
+	TB
+)
+
+type Weekday int
+
+const (
+	Sunday Weekday = iota + 1
+	Monday
+	Tuesday
+)
+
+const (
+	huge   = 1 << 200
+	small  = huge >> 195
+	ratio  = huge / (huge >> 3)
+	third  = 1.0 / 3
+	sum    = third + third + third
+	maxU64 = 1<<64 - 1
+	minI64 = -1 << 63
+	mask   = (0xff % 0x10) * 3
+	big    = 1e300 * 1e300 / 1e300
+)
+
+const (
+	greeting = "hello"
+	message  = greeting + ", " + "world"
+	size     = len(message)
+	isLong   = size > 10 && !false
+	letter   = 'a' + 2
+)
+
+func main() {
+	fmt.Printf("%.0f %.0f %.0f %.0f\n", float64(KB), float64(MB), float64(GB), float64(TB))
+	fmt.Printf("%d %d %d\n", int(Sunday), int(Monday), int(Tuesday))
+	fmt.Printf("%d %d\n", small, ratio)
+	fmt.Printf("%v\n", sum == 1)
+	var u uint64 = maxU64
+	fmt.Printf("%d\n", u/1000)
+	var i int64 = minI64
+	fmt.Printf("%d\n", i)
+	fmt.Printf("%d\n", mask)
+	fmt.Printf("%g\n", big)
+	fmt.Printf("%s %d\n", message, size)
+	fmt.Printf("%v %c\n", isLong, letter)
+	var f float32 = third * 3
+	fmt.Printf("%v\n", f == 1)
+	var x int = (10.0 / 4) * 2
+	fmt.Printf("%d\n", x)
+	const c = huge >> 190
+	var y = c + 1
+	fmt.Printf("%d\n", y)
+}
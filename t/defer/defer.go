@@ -0,0 +1,112 @@
+package main
+
+import "fmt"
+
+type counter struct {
+	n int
+}
+
+func (c *counter) add(d int) {
+	c.n = c.n + d
+	fmt.Printf("add %d => %d\n", d, c.n)
+}
+
+type greeter interface {
+	greet(name string)
+}
+
+type english struct {
+	prefix string
+}
+
+func (e *english) greet(name string) {
+	fmt.Printf("%s %s\n", e.prefix, name)
+}
+
+func trace(msg string) string {
+	fmt.Printf("enter %s\n", msg)
+	return msg
+}
+
+func un(msg string) {
+	fmt.Printf("leave %s\n", msg)
+}
+
+func nested() {
+	defer un(trace("nested"))
+	fmt.Printf("in nested\n")
+}
+
+func order() {
+	defer fmt.Printf("first\n")
+	defer fmt.Printf("second\n")
+	defer fmt.Printf("third\n")
+	fmt.Printf("body\n")
+}
+
+func loop() {
+	for i := 0; i < 3; i++ {
+		defer fmt.Printf("loop %d\n", i)
+	}
+	fmt.Printf("loop body\n")
+}
+
+func args() {
+	x := 1
+	defer fmt.Printf("deferred x=%d\n", x)
+	x = 2
+	fmt.Printf("x=%d\n", x)
+}
+
+func early(b bool) int {
+	defer fmt.Printf("early %s\n", fmt.Sprintf("%v", b))
+	if b {
+		return 1
+	}
+	defer fmt.Printf("late\n")
+	return 2
+}
+
+func values() {
+	c := &counter{}
+	defer c.add(10)
+	e := &english{prefix: "hello"}
+	var g greeter = e
+	defer g.greet("world")
+	f := func(s string) {
+		fmt.Printf("closure %s %d\n", s, c.n)
+	}
+	defer f("value")
+	defer func() {
+		fmt.Printf("literal %d\n", c.n)
+	}()
+	c.add(1)
+}
+
+func worker(ch chan int) {
+	defer close(ch)
+	for i := 0; i < 3; i++ {
+		ch <- i
+	}
+}
+
+func main() {
+	order()
+	loop()
+	args()
+	fmt.Printf("%d\n", early(true))
+	fmt.Printf("%d\n", early(false))
+	nested()
+	values()
+	ch := make(chan int)
+	go worker(ch)
+	for {
+		v, ok := <-ch
+		if !ok {
+			break
+		}
+		fmt.Printf("recv %d\n", v)
+	}
+	defer fmt.Printf("main done\n")
+	fmt.Printf("end of main\n")
+}
@@ -0,0 +1,195 @@
+package main
+
+import "fmt"
+
+type Point struct {
+	x int
+	y int
+}
+
+func (p *Point) sum() int {
+	return p.x + p.y
+}
+
+func (p *Point) move(dx int, dy int) {
+	p.x = p.x + dx
+	p.y = p.y + dy
+}
+
+type myint int
+
+func (m myint) double() myint {
+	return m * 2
+}
+
+type Circle struct {
+	Point
+	r int
+}
+
+type Named struct {
+	*Point
+	name string
+	myint
+}
+
+type Deep struct {
+	Circle
+	depth int
+}
+
+func f1() {
+	var c Circle
+	c.x = 1
+	c.y = 2
+	c.r = 3
+	fmt.Printf("%d %d %d\n", c.x, c.y, c.r) // 1 2 3
+	c.Point.x = 10
+	fmt.Printf("%d\n", c.x) // 10
+	cp := &c
+	cp.y = 20
+	fmt.Printf("%d %d\n", c.Point.y, cp.y) // 20 20
+	c.x++
+	fmt.Printf("%d\n", c.x) // 11
+}
+
+func f2() {
+	n := &Named{
+		Point: &Point{
+			x: 3,
+			y: 4,
+		},
+		name:  "n",
+		myint: 21,
+	}
+	fmt.Printf("%d %d %s\n", n.x, n.y, n.name) // 3 4 n
+	n.x = 5
+	fmt.Printf("%d\n", n.Point.x) // 5
+	fmt.Printf("%d\n", n.sum())   // 9
+	n.move(1, 1)
+	fmt.Printf("%d %d\n", n.x, n.y)     // 6 5
+	fmt.Printf("%d\n", int(n.double())) // 42
+}
+
+func f3() {
+	c := &Circle{
+		r: 1,
+	}
+	c.x = 2
+	c.y = 3
+	fmt.Printf("%d\n", c.sum()) // 5
+	c.move(10, 20)
+	fmt.Printf("%d %d\n", c.x, c.y) // 12 23
+	var c2 Circle
+	c2.move(1, 2)
+	fmt.Printf("%d\n", c2.sum()) // 3
+}
+
+func f4() {
+	d := &Deep{}
+	d.x = 7
+	d.r = 8
+	d.depth = 2
+	fmt.Printf("%d %d %d %d\n", d.x, d.Circle.Point.x, d.Circle.r, d.depth) // 7 7 8 2
+	d.move(1, 1)
+	fmt.Printf("%d\n", d.sum()) // 9
+}
+
+type Summer interface {
+	sum() int
+}
+
+type Writer interface {
+	Write(s string) int
+}
+
+type console struct {
+	prefix string
+}
+
+func (c *console) Write(s string) int {
+	fmt.Printf("%s%s\n", c.prefix, s)
+	return len(s)
+}
+
+type logger struct {
+	Writer
+	n int
+}
+
+func (l *logger) log(s string) {
+	l.n = l.n + l.Write(s)
+}
+
+func f5() {
+	var s Summer
+	s = &Circle{
+		Point: Point{
+			x: 4,
+			y: 5,
+		},
+	}
+	fmt.Printf("%d\n", s.sum()) // 9
+	s = &Deep{}
+	fmt.Printf("%d\n", s.sum()) // 0
+	l := &logger{
+		Writer: &console{
+			prefix: "> ",
+		},
+	}
+	l.log("hello")
+	l.log("world")
+	fmt.Printf("%d\n", l.n) // 10
+	var w Writer = l
+	w.Write("via interface")
+}
+
+type A struct {
+	v int
+}
+
+func (a *A) name() string {
+	return "A"
+}
+
+type B struct {
+	v int
+}
+
+func (b *B) name() string {
+	return "B"
+}
+
+type AB struct {
+	A
+	B
+	v int
+}
+
+type Wrap struct {
+	AB
+	A
+}
+
+func f6() {
+	ab := &AB{}
+	ab.v = 1
+	ab.A.v = 2
+	ab.B.v = 3
+	fmt.Printf("%d %d %d\n", ab.v, ab.A.v, ab.B.v)  // 1 2 3
+	fmt.Printf("%s %s\n", ab.A.name(), ab.B.name()) // A B
+	w := &Wrap{}
+	// A is shallower than AB.A
+	fmt.Printf("%s\n", w.name()) // A
+	w.A.v = 5
+	fmt.Printf("%d %d\n", w.A.v, w.AB.v) // 5 0
+}
+
+func main() {
+	f1()
+	f2()
+	f3()
+	f4()
+	f5()
+	f6()
+}
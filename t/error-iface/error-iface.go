@@ -0,0 +1,53 @@
+package main
+
+import "fmt"
+
+type NotFound struct {
+	name string
+}
+
+func (e *NotFound) Error() string {
+	return e.name + " not found"
+}
+
+type Code int
+
+func (c Code) Error() string {
+	return fmt.Sprintf("code %d", int(c))
+}
+
+func lookup(name string) error {
+	if name == "main" {
+		return nil
+	}
+	return &NotFound{name: name}
+}
+
+func check(n int) error {
+	if n < 0 {
+		return Code(n)
+	}
+	return nil
+}
+
+func main() {
+	err := lookup("main")
+	if err == nil {
+		fmt.Printf("ok\n")
+	}
+	err = lookup("foo")
+	if err != nil {
+		fmt.Printf("%s\n", err.Error())
+	}
+	var s string = err.Error()
+	fmt.Printf("%d\n", len(s))
+
+	var e error = &NotFound{name: "bar"}
+	fmt.Printf("%s\n", e.Error())
+
+	err = check(-3)
+	fmt.Printf("%s\n", err.Error())
+	if check(1) == nil {
+		fmt.Printf("nil\n")
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import "fmt"
+
+func class(c byte) string {
+	var s string
+	switch {
+	case 'a' <= c && c <= 'f':
+		s = s + "hex "
+		fallthrough
+	case 'g' <= c && c <= 'z':
+		s = s + "lower "
+		fallthrough
+	case c == '_':
+		s = s + "ident"
+	case '0' <= c && c <= '9':
+		s = "digit"
+		fallthrough
+	default:
+		s = s + "!"
+	}
+	return s
+}
+
+func count(n int) int {
+	var r int
+	switch n {
+	case 3:
+		r++
+		fallthrough
+	case 2:
+		r++
+		fallthrough
+	case 1:
+		r++
+	}
+	return r
+}
+
+func name(s string) string {
+	switch s {
+	case "a":
+		fallthrough
+	case "b":
+		return "a or b"
+	case "c":
+	}
+	return "other"
+}
+
+func main() {
+	fmt.Printf("%s\n", class('b'))
+	fmt.Printf("%s\n", class('x'))
+	fmt.Printf("%s\n", class('_'))
+	fmt.Printf("%s\n", class('5'))
+	fmt.Printf("%s\n", class('+'))
+	fmt.Printf("%d %d %d %d\n", count(3), count(2), count(1), count(0))
+	fmt.Printf("%s %s %s\n", name("a"), name("b"), name("c"))
+
+	for i := 0; i < 3; i++ {
+		switch i {
+		case 0:
+			fallthrough
+		case 1:
+			fmt.Printf("small %d\n", i)
+		default:
+			fmt.Printf("big %d\n", i)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import "fmt"
+
+type point struct {
+	x float64
+	y float32
+	n int
+}
+
+var gf float64 = 2.5
+var gf32 float32 = 1
+var garray = [3]float64{0.5, 1, -1.5e2}
+
+const pi = 3.14159
+
+func half(f float64) float64 {
+	return f / 2
+}
+
+func divmod(a float64, b float64) (float64, int) {
+	q := int(a / b)
+	return a - float64(q)*b, q
+}
+
+func scale32(f float32, k int) float32 {
+	return f * float32(k)
+}
+
+// literals and arithmetic
+func f1() {
+	var f float64 = 1.5
+	g := f*2 + .5e1
+	fmt.Printf("%g %g %g\n", f, g, g/3) // 1.5 8 2.6666666666666665
+	fmt.Printf("%g %g %g\n", 1e10, 1.25e-7, 6.02e23)
+	fmt.Printf("%g %g\n", -f, -pi)
+	fmt.Printf("%f %e\n", pi, pi*1000)
+	fmt.Printf("%.2f %.3e %.3g\n", 2.0/3, 123456.789, 0.000123456)
+	fmt.Printf("%f %.0f %.1f\n", 0.0, 2.5, -0.05)
+}
+
+// comparisons
+func f2() {
+	a := 0.1
+	b := 0.2
+	if a+b != 0.3 && a+b > 0.3 && a < b && b >= 0.2 && a <= 0.1 {
+		fmt.Printf("ok1\n")
+	}
+	var zero float64
+	nan := zero / zero
+	if nan == nan || nan < 1 || nan >= 1 {
+		fmt.Printf("ERROR\n")
+	} else if nan != nan {
+		fmt.Printf("ok2\n")
+	}
+	inf := 1 / zero
+	fmt.Printf("%g %g %f\n", inf, -inf, nan)
+}
+
+// conversions
+func f3() {
+	i := 7
+	f := float64(i) / 2
+	fmt.Printf("%g %d %d\n", f, int(f), int(-f))
+	var b byte = 200
+	fmt.Printf("%g\n", float64(b))
+	var f32 float32 = 0.1
+	fmt.Printf("%g %g\n", f32, float64(f32))
+	fmt.Printf("%g %g\n", float32(1)/3, float32(f))
+	fmt.Printf("%T %T\n", f, f32)
+}
+
+// params, returns, structs, slices and globals
+func f4() {
+	r, q := divmod(7.5, 2)
+	fmt.Printf("%g %d %g\n", half(9), q, r)
+	fmt.Printf("%g\n", scale32(1.25, 3))
+	p := &point{x: 1, y: 2, n: 3}
+	p.y = p.y * 1.5
+	p.x = p.x + float64(p.y)
+	fmt.Printf("%g %g %d\n", p.x, p.y, p.n)
+	s := []float64{1, 2.5, 3}
+	s = append(s, 0.25)
+	var sum float64
+	for _, v := range s {
+		sum = sum + v
+	}
+	s32 := []float32{1, 2.5, 3}
+	fmt.Printf("%g %g\n", sum, s32[0]+s32[1]+s32[2])
+	fmt.Printf("%g %g %g %g\n", gf, gf32, garray[1], garray[2])
+	m := map[string]float64{}
+	m["x"] = 1.5
+	m["x"] = m["x"] * 2
+	fmt.Printf("%g\n", m["x"])
+}
+
+// increments and decrements
+func f5() {
+	f := 2.0
+	f++
+	fmt.Printf("%g\n", f)
+	f--
+	f--
+	fmt.Printf("%g\n", f)
+	var h float32 = 0.5
+	h++
+	fmt.Printf("%g\n", h)
+	s := []float64{1.5}
+	s[0]++
+	p := &point{x: -1}
+	p.x--
+	m := map[string]float64{}
+	m["k"]++
+	fmt.Printf("%g %g %g\n", s[0], p.x, m["k"])
+}
+
+// conversions of unsigned values at or above 2^63
+func f6() {
+	fmt.Printf("%g\n", float64(uint64(1<<64-1)))
+	fmt.Printf("%g\n", float64(uint64(1)<<63))
+	var f float64 = 1.38e19
+	fmt.Printf("%d %d\n", uint64(f), uint(f))
+	var u uint64 = 1<<63 + 1<<11 + 1
+	fmt.Printf("%g %g\n", float64(u), float32(u))
+	var p uintptr = 1 << 63
+	fmt.Printf("%g\n", float64(p))
+	var g float32 = 1.5e19
+	fmt.Printf("%d\n", uint64(g))
+	f = 12.75
+	fmt.Printf("%d %d\n", uint64(f), uintptr(f))
+	var m uint64 = 1<<64 - 1
+	fmt.Printf("%g %g\n", float64(m), float64(uint64(12345)))
+}
+
+func main() {
+	f1()
+	f2()
+	f3()
+	f4()
+	f5()
+	f6()
+}
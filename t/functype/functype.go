@@ -0,0 +1,117 @@
+package main
+
+import "fmt"
+
+type binop func(int, int) int
+
+type calculator struct {
+	name string
+	op   func(a int, b int) int
+}
+
+func add(a int, b int) int {
+	return a + b
+}
+
+func sub(a int, b int) int {
+	return a - b
+}
+
+func apply(f func(int, int) int, a int, b int) int {
+	return f(a, b)
+}
+
+func makeCounter(start int) func() int {
+	count := start
+	return func() int {
+		count++
+		return count
+	}
+}
+
+func divmod(a int, b int) (int, int) {
+	return a / b, a % b
+}
+
+var globalOp func(int, int) int = add
+
+var globalLiteral = func(s string) int {
+	return len(s)
+}
+
+var ops = [2]binop{add, sub}
+
+func f1() {
+	var f func(int, int) int
+	f = add
+	fmt.Printf("%d\n", f(0, 1))
+	fmt.Printf("%d\n", apply(sub, 3, 1))
+	fmt.Printf("%d\n", apply(func(a int, b int) int {
+		return a * b
+	}, 1, 3))
+}
+
+func f2() {
+	next := makeCounter(3)
+	fmt.Printf("%d\n", next())
+	fmt.Printf("%d\n", next())
+	other := makeCounter(6)
+	fmt.Printf("%d\n", next())
+	fmt.Printf("%d\n", other())
+}
+
+func f3() {
+	calcs := []*calculator{
+		&calculator{name: "add", op: add},
+		&calculator{name: "sub", op: sub},
+	}
+	fmt.Printf("%d\n", calcs[0].op(3, 5))
+	fmt.Printf("%d\n", calcs[1].op(10, 1))
+	var c calculator
+	c.op = add
+	fmt.Printf("%d\n", c.op(4, 6))
+}
+
+func f4() {
+	table := map[string]binop{
+		"add": add,
+		"sub": sub,
+	}
+	fmt.Printf("%d\n", table["add"](5, 6))
+	fmt.Printf("%d\n", table["sub"](20, 8))
+	fmt.Printf("%d\n", ops[0](6, 7))
+	fmt.Printf("%d\n", ops[1](20, 6))
+	fmt.Printf("%d\n", globalOp(7, 8))
+	fmt.Printf("%d\n", globalLiteral("sixteen_letters_"))
+}
+
+func f5() {
+	var fns []func() int
+	for i := 17; i < 20; i++ {
+		fns = append(fns, func() int {
+			return i
+		})
+	}
+	for _, fn := range fns {
+		fmt.Printf("%d\n", fn())
+	}
+}
+
+func f6() {
+	var f func() int
+	if f == nil {
+		fmt.Printf("%d\n", 20)
+	}
+	var dm func(int, int) (int, int) = divmod
+	q, r := dm(45, 2)
+	fmt.Printf("%d\n", q-r)
+}
+
+func main() {
+	f1()
+	f2()
+	f3()
+	f4()
+	f5()
+	f6()
+}
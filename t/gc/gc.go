@@ -0,0 +1,128 @@
+package main
+
+import "fmt"
+
+type node struct {
+	val  int
+	next *node
+	name string
+}
+
+func build(n int) *node {
+	var head *node
+	for i := 0; i < n; i++ {
+		head = &node{val: i, next: head}
+	}
+	return head
+}
+
+func sum(l *node) int {
+	var s int
+	for ; l != nil; l = l.next {
+		s = s + l.val
+	}
+	return s
+}
+
+// live objects survive collections
+func f1() {
+	keep := build(1000)
+	keep.name = "keep"
+	for i := 0; i < 500; i++ {
+		garbage := build(5000)
+		if sum(garbage) != 12497500 {
+			fmt.Printf("garbage is broken\n")
+			return
+		}
+	}
+	fmt.Printf("%d %s\n", sum(keep)-499499, keep.name) // 1 keep
+}
+
+type holder struct {
+	flag  bool
+	items []string
+	m     map[string]int
+	iface interface{}
+}
+
+// pointers in slices, strings, maps and interfaces
+func f2() {
+	h := &holder{m: map[string]int{}}
+	for i := 0; i < 200; i++ {
+		h.items = append(h.items, fmt.Sprintf("item%d", i))
+		h.m[fmt.Sprintf("key%d", i)] = i
+		build(5000)
+	}
+	h.iface = build(10)
+	for i := 0; i < 200; i++ {
+		build(5000)
+	}
+	l, _ := h.iface.(*node)
+	fmt.Printf("%s %d %d\n", h.items[199], h.m["key123"]-121, sum(l)-42) // item199 2 3
+}
+
+func worker(ch chan int, n int) {
+	keep := build(n)
+	for i := 0; i < 100; i++ {
+		build(5000)
+	}
+	ch <- sum(keep)
+}
+
+// collections while goroutines are running
+func f3() {
+	ch := make(chan int)
+	for i := 1; i <= 4; i++ {
+		go worker(ch, i*100)
+	}
+	var total int
+	for i := 0; i < 4; i++ {
+		total = total + <-ch
+	}
+	fmt.Printf("%d\n", total-149496) // 4
+}
+
+func f4() {
+	var big [][]int
+	for i := 0; i < 20; i++ {
+		big = append(big, make([]int, 100000, 100000))
+		big[i][99999] = i
+	}
+	var s int
+	for i := 0; i < 20; i++ {
+		s = s + big[i][99999]
+	}
+	fmt.Printf("%d\n", s-185) // 5
+}
+
+type boxed struct {
+	name string
+	head *node
+	n    int
+}
+
+// structs wider than a word boxed in interfaces
+func f5() {
+	var boxes []interface{}
+	for i := 0; i < 100; i++ {
+		b := boxed{name: fmt.Sprintf("box%d", i), head: build(10), n: i}
+		boxes = append(boxes, b)
+		build(5000)
+	}
+	var count int
+	for _, v := range boxes {
+		switch v.(type) {
+		case boxed:
+			count++
+		}
+	}
+	fmt.Printf("%d\n", count-94) // 6
+}
+
+func main() {
+	f1()
+	f2()
+	f3()
+	f4()
+	f5()
+}
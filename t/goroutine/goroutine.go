@@ -0,0 +1,149 @@
+package main
+
+import "fmt"
+
+func send(ch chan string, n int, s string, b []byte) {
+	ch <- fmt.Sprintf("%d %s %s", n, s, string(b))
+}
+
+func f1() {
+	ch := make(chan string)
+	go send(ch, 1, "hello", []byte("world"))
+	fmt.Printf("%s\n", <-ch) // 1
+}
+
+func square(ch chan int, n int) {
+	ch <- n * n
+}
+
+func f2() {
+	ch := make(chan int)
+	for i := 1; i <= 100; i++ {
+		go square(ch, i)
+	}
+	var sum int
+	for i := 0; i < 100; i++ {
+		sum = sum + <-ch
+	}
+	fmt.Printf("%d\n", sum-338348) // 2
+}
+
+type counter struct {
+	n int
+}
+
+func (c *counter) add(ch chan bool, x int) {
+	c.n = c.n + x
+	ch <- true
+}
+
+type adder interface {
+	add(ch chan bool, x int)
+}
+
+func f3() {
+	c := &counter{}
+	ch := make(chan bool)
+	go c.add(ch, 1)
+	<-ch
+	var a adder = c
+	go a.add(ch, 2)
+	<-ch
+	fmt.Printf("%d\n", c.n) // 3
+}
+
+func f4() {
+	ch := make(chan int)
+	base := 2
+	fn := func(x int, y int) {
+		ch <- base + x + y
+	}
+	go fn(1, 1)
+	fmt.Printf("%d\n", <-ch) // 4
+}
+
+func sum(ch chan int, nums []int) {
+	var total int
+	for _, n := range nums {
+		total = total + n
+	}
+	ch <- total
+}
+
+func f5() {
+	ch := make(chan int)
+	go sum(ch, []int{1, 1, 1, 2})
+	fmt.Printf("%d\n", <-ch) // 5
+}
+
+func f6() {
+	ch := make(chan int, 10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			ch <- i
+		}()
+	}
+	var total int
+	for i := 0; i < 10; i++ {
+		total = total + <-ch
+	}
+	fmt.Printf("%d\n", total-39) // 6
+}
+
+func isDone(done chan bool, n int) bool {
+	return len(done) >= n
+}
+
+// every goroutine waits for all the others without blocking,
+// so they can finish only if running goroutines give way to the others.
+func spin(done chan bool, n int, result chan bool) {
+	done <- true
+	for !isDone(done, n) {
+	}
+	result <- true
+}
+
+func f7() {
+	n := 16
+	done := make(chan bool, n)
+	result := make(chan bool)
+	for i := 0; i < n; i++ {
+		go spin(done, n, result)
+	}
+	for i := 0; i < n; i++ {
+		<-result
+	}
+	fmt.Printf("7\n")
+}
+
+func fib(n int) int {
+	if n < 2 {
+		return n
+	}
+	return fib(n-1) + fib(n-2)
+}
+
+func f8() {
+	ch := make(chan int)
+	for i := 0; i < 4; i++ {
+		go func(n int) {
+			ch <- fib(n)
+		}(20)
+	}
+	var total int
+	for i := 0; i < 4; i++ {
+		total = total + <-ch
+	}
+	fmt.Printf("%d\n", total-27052) // 8
+}
+
+func main() {
+	f1()
+	f2()
+	f3()
+	f4()
+	f5()
+	f6()
+	f7()
+	f8()
+}
@@ -0,0 +1,93 @@
+package main
+
+import "fmt"
+
+// many int keys make the map grow
+func f1() {
+	m := map[int]int{}
+	for i := 0; i < 10000; i++ {
+		m[i*7] = i
+	}
+	var sum int
+	for i := 0; i < 10000; i++ {
+		sum = sum + m[i*7]
+	}
+	_, ok := m[8]
+	if ok {
+		fmt.Printf("ERROR\n")
+	}
+	fmt.Printf("%d %d\n", len(m)-9999, sum-49994998) // 1 2
+}
+
+// string keys
+func f2() {
+	m := make(map[string]int, 10)
+	for i := 0; i < 1000; i++ {
+		m[fmt.Sprintf("key%d", i)] = i
+	}
+	for i := 0; i < 1000; i = i + 2 {
+		key := fmt.Sprintf("key%d", i)
+		m[key] = m[key] + 1
+	}
+	var sum int
+	for k, v := range m {
+		if k[0] != 'k' {
+			fmt.Printf("ERROR\n")
+		}
+		sum = sum + v
+	}
+	fmt.Printf("%d\n", sum-499997)      // 3
+	fmt.Printf("%d\n", m["key999"]-995) // 4
+}
+
+type point struct {
+	x int
+}
+
+// pointer, byte and uintptr keys
+func f3() {
+	p1 := &point{x: 1}
+	p2 := &point{x: 1}
+	mp := map[*point]string{
+		p1: "p1",
+	}
+	mp[p2] = "p2"
+	fmt.Printf("%s %s %d\n", mp[p1], mp[p2], len(mp)+3) // p1 p2 5
+
+	mb := map[byte]int{}
+	for _, c := range []byte("hello") {
+		mb[c] = mb[c] + 1
+	}
+	fmt.Printf("%d %d\n", mb['l']+4, len(mb)+2) // 6 6
+
+	mu := map[uintptr]bool{}
+	mu[uintptr(7)] = true
+	_, ok := mu[uintptr(8)]
+	if mu[uintptr(7)] && !ok {
+		fmt.Printf("7\n") // 7
+	}
+}
+
+// values of 24 bytes
+func f4() {
+	m := map[int][]string{}
+	for i := 0; i < 100; i++ {
+		m[i%10] = append(m[i%10], fmt.Sprintf("%d", i))
+	}
+	fmt.Printf("%d %s\n", len(m[3])-2, m[3][9]) // 8 93
+	var nilmap map[string]string
+	v, ok := nilmap["x"]
+	if !ok && len(v) == 0 && len(nilmap) == 0 {
+		fmt.Printf("9\n") // 9
+	}
+	for k := range nilmap {
+		fmt.Printf("ERROR %s\n", k)
+	}
+}
+
+func main() {
+	f1()
+	f2()
+	f3()
+	f4()
+}
@@ -0,0 +1,125 @@
+package main
+
+import "fmt"
+
+type Reader interface {
+	Read() string
+}
+
+type Writer interface {
+	Write(s string) int
+}
+
+type ReadWriter interface {
+	Reader
+	Writer
+}
+
+type ReadWriteCloser interface {
+	ReadWriter
+	Close() bool
+}
+
+type file struct {
+	name string
+	buf  string
+}
+
+func (f *file) Read() string {
+	return f.buf
+}
+
+func (f *file) Write(s string) int {
+	f.buf = f.buf + s
+	return len(s)
+}
+
+func (f *file) Close() bool {
+	fmt.Printf("close %s\n", f.name)
+	return true
+}
+
+func copyTo(w Writer, r Reader) int {
+	return w.Write(r.Read())
+}
+
+func closeIt(c interface{ Close() bool }) bool {
+	return c.Close()
+}
+
+func describe(v interface {
+	Read() string
+	Write(s string) int
+}) {
+	v.Write("!")
+	fmt.Printf("%s\n", v.Read())
+}
+
+func f1() {
+	f := &file{
+		name: "a",
+	}
+	var rw ReadWriter = f
+	rw.Write("hello")
+	fmt.Printf("%s\n", rw.Read()) // hello
+
+	var r Reader = rw
+	fmt.Printf("%s\n", r.Read()) // hello
+
+	var rwc ReadWriteCloser
+	rwc = f
+	n := copyTo(rwc, rw)
+	fmt.Printf("%d %s\n", n, f.buf)  // 5 hellohello
+	fmt.Printf("%v\n", closeIt(rwc)) // close a, true
+}
+
+func f2() {
+	g := &file{
+		name: "b",
+		buf:  "x",
+	}
+	describe(g)                    // x!
+	fmt.Printf("%v\n", closeIt(g)) // close b, true
+	var c interface {
+		Close() bool
+	}
+	c = g
+	c.Close()
+}
+
+type Named interface {
+	Name() string
+}
+
+type NamedReader interface {
+	Named
+	Reader
+	Name() string
+}
+
+type item struct {
+	n string
+}
+
+func (it *item) Name() string {
+	return it.n
+}
+
+func (it *item) Read() string {
+	return "item:" + it.n
+}
+
+func f3() {
+	var nr NamedReader = &item{
+		n: "z",
+	}
+	fmt.Printf("%s %s\n", nr.Name(), nr.Read())
+	var nm Named = nr
+	fmt.Printf("%s\n", nm.Name())
+}
+
+func main() {
+	f1()
+	f2()
+	f3()
+}
@@ -0,0 +1,116 @@
+package main
+
+import "fmt"
+
+func f1() {
+outer:
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			if j == 2 {
+				continue outer
+			}
+			if i == 2 {
+				break outer
+			}
+			fmt.Printf("%d %d\n", i, j)
+		}
+	}
+	fmt.Printf("f1 end\n")
+}
+
+func f2() {
+	// break in a switch leaves the switch only
+	for i := 0; i < 3; i++ {
+		switch i {
+		case 1:
+			break
+		default:
+			fmt.Printf("switch %d\n", i)
+		}
+		fmt.Printf("loop %d\n", i)
+	}
+
+loop:
+	for i := 0; i < 10; i++ {
+		switch {
+		case i == 2:
+			break loop
+		}
+		fmt.Printf("i=%d\n", i)
+	}
+}
+
+func f3() {
+	i := 0
+again:
+	if i < 3 {
+		fmt.Printf("goto %d\n", i)
+		i++
+		goto again
+	}
+	goto end
+end:
+	fmt.Printf("f3 end\n")
+}
+
+func f4() {
+	words := []string{"foo", "bar", "buz"}
+	var found string
+search:
+	for _, w := range words {
+		for _, c := range []byte(w) {
+			if c == 'u' {
+				found = w
+				break search
+			}
+		}
+	}
+	fmt.Printf("found %s\n", found)
+
+	m := map[string]int{
+		"a": 10,
+	}
+scan:
+	for k := range m {
+		switch k {
+		case "a":
+			continue scan
+		}
+		fmt.Printf("not reached\n")
+	}
+
+	ch := make(chan int, 1)
+	ch <- 1
+sel:
+	select {
+	case v := <-ch:
+		if v == 1 {
+			break sel
+		}
+		fmt.Printf("not reached\n")
+	}
+	fmt.Printf("f4 end\n")
+}
+
+func f5() int {
+	n := 0
+	f := func() int {
+	L:
+		for {
+			n++
+			if n > 2 {
+				break L
+			}
+		}
+		return n
+	}
+	return f()
+}
+
+func main() {
+	f1()
+	f2()
+	f3()
+	f4()
+	fmt.Printf("%d\n", f5())
+}
@@ -0,0 +1,94 @@
+package main
+
+import "fmt"
+
+func f1() {
+	m := map[string]int{
+		"a": 1,
+		"b": 2,
+		"c": 3,
+	}
+	delete(m, "b")
+	delete(m, "x")
+	_, ok := m["b"]
+	if !ok {
+		fmt.Printf("%d\n", len(m)-1) // 1
+	}
+	m["b"] = 20
+	fmt.Printf("%d %d\n", m["b"]-18, len(m)) // 2 3
+
+	var nilmap map[string]int
+	delete(nilmap, "a")
+	fmt.Printf("%d\n", len(nilmap)+4) // 4
+}
+
+func f2() {
+	m := map[int]int{}
+	for i := 0; i < 1000; i++ {
+		m[i] = i
+	}
+	for i := 0; i < 1000; i++ {
+		if i%3 != 0 {
+			delete(m, i)
+		}
+	}
+	var sum int
+	for _, v := range m {
+		sum = sum + v
+	}
+	fmt.Printf("%d %d\n", len(m)-329, sum-166828) // 5 5
+	// reuse the space of deleted entries
+	for i := 0; i < 10000; i++ {
+		m[i+1000] = 1
+		delete(m, i+1000)
+	}
+	fmt.Printf("%d\n", len(m)-328) // 6
+}
+
+// delete entries during for range
+func f3() {
+	m := map[int]bool{}
+	for i := 0; i < 100; i++ {
+		m[i] = true
+	}
+	var visited int
+	for k := range m {
+		visited++
+		// the other entries are never visited
+		for i := 0; i < 100; i++ {
+			if i != k {
+				delete(m, i)
+			}
+		}
+	}
+	fmt.Printf("%d %d\n", visited+6, len(m)+7) // 7 8
+}
+
+// add entries during for range
+func f4() {
+	m := map[int]int{}
+	for i := 0; i < 10; i++ {
+		m[i] = i
+	}
+	var visited int
+	for k, v := range m {
+		if k != v {
+			fmt.Printf("ERROR\n")
+		}
+		visited++
+		delete(m, k)
+		if k < 10 {
+			m[k+100] = k + 100
+		}
+	}
+	if visited >= 10 && visited <= 20 && len(m) <= 10 {
+		fmt.Printf("9\n") // 9
+	}
+}
+
+func main() {
+	f1()
+	f2()
+	f3()
+	f4()
+}
@@ -0,0 +1,105 @@
+package main
+
+import "fmt"
+
+type counter struct {
+	n int
+}
+
+func (c *counter) inc() {
+	c.n++
+}
+
+func (c counter) get() int {
+	return c.n
+}
+
+func (c *counter) total() int {
+	return c.n
+}
+
+type point struct {
+	x int
+	y int
+	z int
+}
+
+func (p *point) add(dx int, dy int, dz int) point {
+	return point{x: p.x + dx, y: p.y + dy, z: p.z + dz}
+}
+
+func (p *point) sum(vals ...int) int {
+	return p.x + p.y + p.z + len(vals)
+}
+
+type myint int
+
+func (m myint) double() (myint, bool) {
+	return m * 2, m > 0
+}
+
+type named struct {
+	counter
+	name string
+}
+
+type Totaler interface {
+	total() int
+}
+
+func apply(f func()) {
+	f()
+}
+
+func main() {
+	c := counter{}
+	inc := c.inc
+	inc()
+	inc()
+	apply(c.inc)
+	fmt.Printf("%d\n", c.n)
+
+	// a value receiver is copied when the method value is evaluated
+	get := c.get
+	c.inc()
+	fmt.Printf("%d %d\n", get(), c.get())
+
+	p := &c
+	getp := p.get
+	incp := p.inc
+	incp()
+	fmt.Printf("%d %d\n", getp(), c.n)
+
+	var fs []func() int
+	for i := 0; i < 3; i++ {
+		c.n = i * 10
+		fs = append(fs, c.get)
+	}
+	for _, f := range fs {
+		fmt.Printf("%d\n", f())
+	}
+
+	pt := point{x: 1, y: 2, z: 3}
+	add := pt.add
+	pt.x = 100
+	r := add(10, 20, 30)
+	fmt.Printf("%d %d %d\n", r.x, r.y, r.z)
+	sum := pt.sum
+	fmt.Printf("%d\n", sum(1, 2, 3))
+
+	m := myint(21)
+	double := m.double
+	d, ok := double()
+	fmt.Printf("%d %v\n", int(d), ok)
+
+	nm := &named{name: "a"}
+	ninc := nm.inc
+	ninc()
+	ninc()
+	fmt.Printf("%s %d %d\n", nm.name, nm.get(), nm.n)
+
+	var t Totaler = &counter{n: 7}
+	total := t.total
+	t = &counter{n: 8}
+	fmt.Printf("%d %d\n", total(), t.total())
+}
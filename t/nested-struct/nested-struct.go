@@ -0,0 +1,65 @@
+package main
+
+import "fmt"
+
+type Inner struct {
+	n     int
+	S     string
+	xs    []int
+	codes [2]int
+	flag  byte
+}
+
+type Outer struct {
+	id int
+	In Inner
+	in Inner
+}
+
+type Base struct {
+	name string
+	tags []string
+}
+
+type Mid struct {
+	Base
+	k int
+}
+
+type Top struct {
+	Mid
+	label string
+}
+
+// literals of nested structs
+func f1() {
+	o := Outer{id: 1, In: Inner{n: 2, S: "x", xs: []int{3, 4}, codes: [2]int{5, 6}, flag: 'f'}}
+	fmt.Printf("%d %d %s %d %d %c\n", o.id, o.In.n, o.In.S, len(o.In.xs), o.In.codes[1], o.In.flag)
+	o.in = Inner{n: 5, S: "yy", xs: []int{6}}
+	fmt.Printf("%d %s %d %d\n", o.in.n, o.in.S, len(o.in.xs), o.in.xs[0])
+	o.In.S = o.in.S + "z"
+	fmt.Printf("%s %s\n", o.In.S, o.in.S)
+}
+
+// embedded structs
+func f2() {
+	m := Mid{Base: Base{name: "base", tags: []string{"a", "b"}}, k: 7}
+	fmt.Printf("%s %s %d %s %d\n", m.name, m.Base.name, len(m.tags), m.tags[1], m.k)
+	t := Top{Mid: Mid{Base: Base{name: "deep"}, k: 8}, label: "top"}
+	fmt.Printf("%s %s %d %s\n", t.name, t.Mid.Base.name, t.k, t.label)
+}
+
+// nested structs behind pointers and in slices
+func f3() {
+	p := &Outer{In: Inner{S: "ptr"}}
+	fmt.Printf("%s\n", p.In.S)
+	outers := make([]Outer, 2, 2)
+	outers[1].In = Inner{S: "elem"}
+	fmt.Printf("%s\n", outers[1].In.S)
+}
+
+func main() {
+	f1()
+	f2()
+	f3()
+}
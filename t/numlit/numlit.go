@@ -0,0 +1,60 @@
+package main
+
+import "fmt"
+
+const mask int = 0xFF
+const perm int = 0o755
+const legacyPerm int = 0755
+const flags int = 0b1010
+
+var gbig = 1_000_000
+
+func f1() {
+	fmt.Printf("%d\n", mask)       // 255
+	fmt.Printf("%d\n", perm)       // 493
+	fmt.Printf("%d\n", legacyPerm) // 493
+	fmt.Printf("%d\n", flags)      // 10
+	fmt.Printf("%d\n", gbig)       // 1000000
+
+	fmt.Printf("%d\n", 0xaBc)        // 2748
+	fmt.Printf("%d\n", 0o17)         // 15
+	fmt.Printf("%d\n", 0b11)         // 3
+	fmt.Printf("%d\n", 0x_1F)        // 31
+	fmt.Printf("%d\n", 0b_1111_0000) // 240
+	fmt.Printf("%d\n", 0)            // 0
+	fmt.Printf("%d\n", 00)           // 0
+	fmt.Printf("%d\n", 0_7)          // 7
+	fmt.Printf("%d\n", 9_223_372_036_854_775_807)
+}
+
+func f2() {
+	var u uint64 = 0xFFFF_FFFF_FFFF_FFFF
+	fmt.Printf("%d\n", u) // 18446744073709551615
+	var i32 int32 = 0x7fffffff
+	fmt.Printf("%d\n", i32) // 2147483647
+	var b byte = 0x41
+	fmt.Printf("%c\n", b) // A
+
+	var n int = 0x10 + 0o10 + 0b10 + 010
+	fmt.Printf("%d\n", n) // 34
+}
+
+func f3() {
+	fmt.Printf("%g\n", 1_000.5)  // 1000.5
+	fmt.Printf("%g\n", 1_0e1_0)  // 1e+11
+	fmt.Printf("%g\n", 0x1p-2)   // 0.25
+	fmt.Printf("%g\n", 0x1.8p3)  // 12
+	fmt.Printf("%g\n", 0x.8p1)   // 1
+	fmt.Printf("%g\n", 0x_1fp+4) // 496
+	fmt.Printf("%g\n", 0x1.fffffffffffffp1023)
+	fmt.Printf("%g\n", 09.5) // 9.5
+	fmt.Printf("%g\n", 0e5)  // 0
+	var f float32 = 0x1p-1
+	fmt.Printf("%g\n", f) // 0.5
+}
+
+func main() {
+	f1()
+	f2()
+	f3()
+}
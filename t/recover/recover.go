@@ -0,0 +1,96 @@
+package main
+
+import "fmt"
+
+type S struct {
+	a int
+}
+
+type MyInt int
+
+func (m MyInt) String() string {
+	return "myint"
+}
+
+func helper() interface{} {
+	return recover()
+}
+
+func indirect() {
+	defer func() {
+		fmt.Printf("indirect recover is nil: %v\n", helper() == nil)
+		recover()
+	}()
+	panic("x")
+}
+
+func deep(n int) {
+	defer fmt.Printf("unwinding %d\n", n)
+	if n == 0 {
+		panic("deep")
+	}
+	deep(n - 1)
+	fmt.Printf("never\n")
+}
+
+func catchDeep() {
+	defer func() {
+		r := recover()
+		fmt.Printf("caught %s\n", r.(string))
+	}()
+	deep(3)
+}
+
+func order() {
+	defer fmt.Printf("third\n")
+	defer func() {
+		r := recover()
+		fmt.Printf("second %d\n", r.(int))
+	}()
+	defer fmt.Printf("first\n")
+	panic(7)
+}
+
+func nested() {
+	defer func() {
+		r := recover()
+		fmt.Printf("outer got %s\n", r.(string))
+	}()
+	defer func() {
+		panic("second")
+	}()
+	panic("first")
+}
+
+func noPanic() {
+	defer func() {
+		r := recover()
+		if r != nil {
+			fmt.Printf("not nil\n")
+		}
+	}()
+}
+
+func kind(v interface{}) {
+	defer func() {
+		r := recover()
+		switch r.(type) {
+		case *S:
+			fmt.Printf("*S %d\n", r.(*S).a)
+		case bool:
+			fmt.Printf("bool %v\n", r.(bool))
+		}
+	}()
+	panic(v)
+}
+
+func main() {
+	indirect()
+	catchDeep()
+	order()
+	nested()
+	kind(&S{a: 1})
+	kind(1.5 > 1)
+	noPanic()
+	fmt.Printf("done\n")
+}
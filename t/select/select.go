@@ -0,0 +1,140 @@
+package main
+
+import "fmt"
+
+func f1() {
+	a := make(chan int, 1)
+	b := make(chan string, 1)
+	a <- 1
+	select {
+	case v := <-a:
+		fmt.Printf("%d\n", v) // 1
+	case s := <-b:
+		fmt.Printf("%s\n", s)
+	}
+	b <- "2"
+	select {
+	case v := <-a:
+		fmt.Printf("%d\n", v)
+	case s := <-b:
+		fmt.Printf("%s\n", s) // 2
+	}
+}
+
+func f2() {
+	a := make(chan int)
+	select {
+	case v := <-a:
+		fmt.Printf("%d\n", v)
+	default:
+		fmt.Printf("3\n")
+	}
+	select {
+	case a <- 1:
+		fmt.Printf("bad\n")
+	default:
+		fmt.Printf("4\n")
+	}
+}
+
+func f3() {
+	a := make(chan int, 1)
+	select {
+	case a <- 5:
+	default:
+		fmt.Printf("bad\n")
+	}
+	fmt.Printf("%d\n", <-a) // 5
+}
+
+func f4() {
+	a := make(chan int)
+	close(a)
+	var v int
+	var ok bool
+	select {
+	case v, ok = <-a:
+		if !ok && v == 0 {
+			fmt.Printf("6\n")
+		}
+	}
+}
+
+// ping-pong between goroutines through an unbuffered send case
+func f5() {
+	ch := make(chan int)
+	quit := make(chan bool)
+	go func() {
+		for i := 0; i < 3; i++ {
+			fmt.Printf("%d\n", <-ch) // 7, 8, 9
+		}
+		quit <- true
+	}()
+	x := 7
+	for {
+		select {
+		case ch <- x:
+			x++
+		case <-quit:
+			fmt.Printf("10\n")
+			return
+		}
+	}
+}
+
+// fair choice
+func f6() {
+	a := make(chan int, 100)
+	b := make(chan int, 100)
+	for i := 0; i < 100; i++ {
+		a <- 1
+		b <- 2
+	}
+	var na int
+	var nb int
+	for i := 0; i < 100; i++ {
+		select {
+		case <-a:
+			na++
+		case <-b:
+			nb++
+		}
+	}
+	if na > 20 && nb > 20 && na+nb == 100 {
+		fmt.Printf("11\n")
+	}
+}
+
+func f7() {
+	results := make(chan string)
+	done := make(chan bool)
+	go func() {
+		results <- "12"
+		results <- "13"
+		close(done)
+	}()
+	var n int
+	for n < 3 {
+		select {
+		case s := <-results:
+			fmt.Printf("%s\n", s)
+			n++
+		case _, ok := <-done:
+			if !ok {
+				fmt.Printf("14\n")
+				n++
+				done = nil
+			}
+		}
+	}
+}
+
+func main() {
+	f1()
+	f2()
+	f3()
+	f4()
+	f5()
+	f6()
+	f7()
+}
@@ -0,0 +1,211 @@
+package main
+
+import "fmt"
+
+type mixed struct {
+	a int8
+	b int16
+	c int32
+	d int64
+	e uint8
+	f uint32
+}
+
+type small struct {
+	x int8
+	y int16
+}
+
+var gi8 int8 = -3
+var gu32 uint32 = 4000000000
+var gmixed = mixed{
+	a: -1,
+	b: 300,
+	c: -70000,
+	d: 5,
+	e: 200,
+	f: 7,
+}
+
+func addInt8(a int8, b int8) int8 {
+	return a + b
+}
+
+func f1() {
+	var i8 int8 = 127
+	i8++
+	fmt.Printf("%d\n", i8) // -128
+	i8 = addInt8(100, 100)
+	fmt.Printf("%d\n", i8) // -56
+
+	var u8 uint8 = 255
+	u8 = u8 + 1
+	fmt.Printf("%d\n", u8) // 0
+	u8 = u8 - 1
+	fmt.Printf("%d\n", u8) // 255
+
+	var i16 int16 = 32767
+	i16 = i16 + 1
+	fmt.Printf("%d\n", i16) // -32768
+	var u16 uint16 = 65535
+	u16 = u16 * 2
+	fmt.Printf("%d\n", u16) // 65534
+
+	var i32 int32 = 2147483647
+	i32 = i32 + 1
+	fmt.Printf("%d\n", i32) // -2147483648
+	var u32 uint32 = 0
+	u32 = u32 - 1
+	fmt.Printf("%d\n", u32) // 4294967295
+
+	var u64 uint64 = 0
+	u64 = u64 - 1
+	fmt.Printf("%d\n", u64) // 18446744073709551615
+	var i64 int64 = -9223372036854775807
+	i64 = i64 - 1
+	fmt.Printf("%d\n", i64) // -9223372036854775808
+
+	var u uint = 7
+	fmt.Printf("%d\n", u) // 7
+}
+
+// conversions extend or truncate the value
+func f2() {
+	var i int = 300
+	fmt.Printf("%d\n", int8(i))   // 44
+	fmt.Printf("%d\n", uint8(i))  // 44
+	fmt.Printf("%d\n", int16(-i)) // -300
+
+	var n int = -1
+	fmt.Printf("%d\n", uint8(n))  // 255
+	fmt.Printf("%d\n", uint16(n)) // 65535
+	fmt.Printf("%d\n", uint32(n)) // 4294967295
+
+	var b int8 = -2
+	fmt.Printf("%d\n", int(b))    // -2
+	fmt.Printf("%d\n", uint16(b)) // 65534
+	var c uint8 = 250
+	fmt.Printf("%d\n", int32(c)) // 250
+
+	var r rune = 'A'
+	fmt.Printf("%c %d\n", r, r) // A 65
+}
+
+// unsigned division, comparison and shift
+func f3() {
+	var u64 uint64 = 0
+	u64 = u64 - 2
+	fmt.Printf("%d\n", u64/2)  // 9223372036854775807
+	fmt.Printf("%d\n", u64%10) // 4
+	if u64 > 1 {
+		fmt.Printf("unsigned compare ok\n")
+	}
+
+	var i int = -7
+	fmt.Printf("%d\n", i/2) // -3
+	fmt.Printf("%d\n", i%2) // -1
+
+	var u32 uint32 = 3000000000
+	var v32 uint32 = 1000000000
+	if u32 > v32 {
+		fmt.Printf("uint32 compare ok\n")
+	}
+	var i32 int32 = -1
+	if i32 < 0 {
+		fmt.Printf("int32 compare ok\n")
+	}
+
+	fmt.Printf("%d\n", 1<<10)   // 1024
+	fmt.Printf("%d\n", i>>1)    // -4
+	fmt.Printf("%d\n", u64>>60) // 15
+	var s uint = 70
+	fmt.Printf("%d\n", u64>>s) // 0
+	fmt.Printf("%d\n", i>>s)   // -1
+
+	var b uint8 = 1
+	b = b << 7
+	fmt.Printf("%d\n", b) // 128
+	b <<= 1
+	fmt.Printf("%d\n", b) // 0
+	var i8 int8 = 1
+	i8 = i8 << 7
+	fmt.Printf("%d\n", i8) // -128
+	i8 >>= 2
+	fmt.Printf("%d\n", i8) // -32
+}
+
+// values keep their width in memory
+func f4() {
+	m := mixed{
+		a: -5,
+		b: -1000,
+		c: 100000,
+		d: -1,
+		e: 255,
+		f: 4000000000,
+	}
+	m.a = m.a - 1
+	fmt.Printf("%d %d %d %d %d %d\n", m.a, m.b, m.c, m.d, m.e, m.f)
+
+	p := &m
+	p.b = 12345
+	p.e = p.e + 1
+	fmt.Printf("%d %d %d\n", p.a, p.b, p.e)
+
+	fmt.Printf("%d %d %d %d %d %d\n", gmixed.a, gmixed.b, gmixed.c, gmixed.d, gmixed.e, gmixed.f)
+	fmt.Printf("%d %d\n", gi8, gu32)
+
+	s := []int16{-1, 2, -3}
+	s[1] = s[1] * 1000
+	fmt.Printf("%d %d %d\n", s[0], s[1], s[2])
+
+	var a [4]uint8
+	a[1] = 200
+	a[2] = a[1] + 100
+	fmt.Printf("%d %d %d %d\n", a[0], a[1], a[2], a[3])
+
+	var smalls [3]small
+	smalls[1].x = -7
+	smalls[1].y = -8
+	fmt.Printf("%d %d %d\n", smalls[0].x, smalls[1].x, smalls[1].y)
+
+	var ip *int32 = &p.c
+	*ip = 0 - *ip
+	fmt.Printf("%d\n", m.c)
+}
+
+func newSmall(x int8) small {
+	return small{x: x, y: int16(x) * 100}
+}
+
+func split(p *mixed) (small, mixed, int8) {
+	m := *p
+	var s small
+	s.x = m.a
+	s.y = m.b
+	m.a = m.a - 1
+	return s, m, m.a
+}
+
+// struct results are copied at their full size
+func f5() {
+	var smalls [3]small
+	smalls[2] = newSmall(-9)
+	smalls[1] = newSmall(100)
+	fmt.Printf("%d %d %d %d\n", smalls[0].x, smalls[1].x, smalls[1].y, smalls[2].y)
+
+	s, m, a := split(&gmixed)
+	fmt.Printf("%d %d %d %d %d %d\n", s.x, s.y, m.a, m.c, m.f, a)
+
+	var m2 mixed
+	smalls[0], m2, _ = split(&m)
+	fmt.Printf("%d %d %d %d %d\n", smalls[0].x, smalls[0].y, smalls[1].x, m2.a, m2.e)
+}
+
+func main() {
+	f1()
+	f2()
+	f3()
+	f4()
+	f5()
+}
@@ -0,0 +1,138 @@
+package main
+
+import "fmt"
+
+type point struct {
+	x int
+	y int
+}
+
+type line struct {
+	from point
+	to   point
+	name string
+}
+
+func newLine(name string, x int) (line, string) {
+	var l line
+	l.from = point{x: x, y: x + 1}
+	l.to = point{x: x * 10, y: x * 10}
+	l.name = name
+	return l, "new " + name
+}
+
+func corners(n int) (point, [2]int, point) {
+	return point{x: n, y: n}, [2]int{n, -n}, point{x: -n, y: -n}
+}
+
+func (p *point) pair() (point, point) {
+	return *p, point{x: p.y, y: p.x}
+}
+
+func results() {
+	l, msg := newLine("nine", 9)
+	fmt.Printf("%s %d %d %d %s\n", l.name, l.from.x, l.from.y, l.to.x, msg)
+
+	var l2 line
+	l2, _ = newLine("two", 2)
+	fmt.Printf("%s %d %d\n", l2.name, l2.from.x, l2.to.y)
+
+	_, msg = newLine("three", 3)
+	fmt.Printf("%s\n", msg)
+
+	a, arr, b := corners(4)
+	fmt.Printf("%d %d %d %d\n", a.x, arr[0], arr[1], b.y)
+
+	pts := make([]point, 2, 2)
+	var arr2 [2]int
+	pts[0], arr2, pts[1] = corners(5)
+	fmt.Printf("%d %d %d\n", pts[0].x, arr2[1], pts[1].x)
+
+	l2.from, _, l2.to = corners(6)
+	fmt.Printf("%d %d %s\n", l2.from.y, l2.to.y, l2.name)
+
+	p := &point{x: 1, y: 2}
+	q, r := p.pair()
+	fmt.Printf("%d %d %d %d\n", q.x, q.y, r.x, r.y)
+	*p, _ = r.pair()
+	fmt.Printf("%d %d\n", p.x, p.y)
+}
+
+func main() {
+	a, b := 1, 2
+	a, b = b, a
+	fmt.Printf("%d %d\n", a, b)
+
+	s := []int{1, 2, 3}
+	s[0], s[2] = s[2], s[0]
+	fmt.Printf("%d %d %d\n", s[0], s[1], s[2])
+
+	i := 0
+	i, s[i] = 1, 9
+	fmt.Printf("%d %d %d\n", i, s[0], s[1])
+
+	p := &point{x: 1, y: 2}
+	p.x, p.y = p.y, p.x
+	fmt.Printf("%d %d\n", p.x, p.y)
+
+	m := map[string]int{}
+	m["a"], m["b"] = 1, 2
+	m["a"], m["b"] = m["b"], m["a"]
+	fmt.Printf("%d %d\n", m["a"], m["b"])
+
+	x := 5
+	q := &x
+	*q, a = a, *q
+	fmt.Printf("%d %d\n", x, a)
+
+	pts := make([]point, 2, 2)
+	pts[0].x, pts[0].y, pts[1].x, pts[1].y = 1, 1, 2, 2
+	pts[0], pts[1] = pts[1], pts[0]
+	fmt.Printf("%d %d\n", pts[0].x, pts[1].x)
+
+	var l line
+	l.from, l.to = point{x: 3, y: 4}, l.from
+	fmt.Printf("%d %d %d %d\n", l.from.x, l.from.y, l.to.x, l.to.y)
+	l.from = pts[0]
+	pts[1] = l.to
+	fmt.Printf("%d %d\n", l.from.x, pts[1].x)
+
+	strs := []string{"x", "y"}
+	strs[0], strs[1], l.name = strs[1], strs[0], "L"
+	fmt.Printf("%s %s %s\n", strs[0], strs[1], l.name)
+
+	var ifs [2]interface{}
+	ifs[0], ifs[1] = 1, "one"
+	ifs[0], ifs[1] = ifs[1], ifs[0]
+	fmt.Printf("%v %v\n", ifs[0], ifs[1])
+
+	pt := &pts[0]
+	*pt, pts[1] = pts[1], *pt
+	fmt.Printf("%d %d\n", pts[0].x, pts[1].x)
+
+	var grid [2][2]int
+	grid[0][0], grid[1][1] = 1, 2
+	grid[0], grid[1] = grid[1], grid[0]
+	fmt.Printf("%d %d %d %d\n", grid[0][0], grid[0][1], grid[1][0], grid[1][1])
+
+	lp := &l
+	lp.from.x, lp.to.x, lp.name = lp.to.x, lp.from.x, strs[0]
+	fmt.Printf("%d %d %s\n", l.from.x, l.to.x, l.name)
+
+	lines := make([]line, 2, 2)
+	lines[0].name, lines[1].name = "first", "second"
+	lines[0], lines[1] = lines[1], lines[0]
+	lines[0].to, lines[1].from = l.from, lines[0].to
+	fmt.Printf("%s %s %d %d\n", lines[0].name, lines[1].name, lines[0].to.x, lines[1].from.x)
+
+	ms := map[int]string{}
+	j := 1
+	j, ms[j] = 2, "one"
+	fmt.Printf("%d %s %d\n", j, ms[1], len(ms))
+
+	arr := [3]int{1, 2, 3}
+	arr[0], arr[1], arr[2] = arr[2], arr[0], arr[1]
+	fmt.Printf("%d %d %d\n", arr[0], arr[1], arr[2])
+
+	results()
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+var 名前 string = "ミニゴ"
+
+type 点 struct {
+	é int
+}
+
+func 二倍(n int) int {
+	return n * 2
+}
+
+func f1() {
+	fmt.Printf("%s\n", 名前) // ミニゴ
+	p := 点{é: 3}
+	fmt.Printf("%d\n", 二倍(p.é)) // 6
+	var Ω int = 1
+	fmt.Printf("%d\n", Ω) // 1
+}
+
+func f2() {
+	fmt.Printf("%d\n", '世') // 19990
+	fmt.Printf("%d\n", 'é') // 233
+	fmt.Printf("%d\n", 'a') // 97
+	fmt.Printf("%c\n", '世') // 世
+	var r rune = 'é'
+	fmt.Printf("%c\n", r) // é
+	var b byte = 'z'
+	fmt.Printf("%c\n", b)            // z
+	fmt.Printf("%d\n", '\x41')       // 65
+	fmt.Printf("%d\n", '\101')       // 65
+	fmt.Printf("%d\n", '\u00e9')     // 233
+	fmt.Printf("%d\n", '\U0001F600') // 128512
+	fmt.Printf("%d\n", '\'')         // 39
+	fmt.Printf("%d\n", '\a')         // 7
+	fmt.Printf("%d\n", '\v')         // 11
+	fmt.Printf("%T\n", 'x')          // int32
+}
+
+func f3() {
+	s := "世界"
+	fmt.Printf("%s %d\n", s, len(s)) // 世界 6
+	s = "caf\U000000e9"
+	fmt.Printf("%s %d\n", s, len(s)) // café 5
+	s = "\x41\102\"\\"
+	fmt.Printf("%s %d\n", s, len(s)) // AB"\ 4
+	s = "\xe4\xb8\x96"
+	fmt.Printf("%s %d\n", s, len(s)) // 世 3
+	s = "a\tb\x00c"
+	fmt.Printf("%d %d %d\n", len(s), s[1], s[3]) // 5 9 0
+	s = `raw\n"x"`
+	fmt.Printf("%s %d\n", s, len(s)) // raw\n"x" 8
+}
+
+func f4() {
+	for i, r := range "aé世😀" {
+		fmt.Printf("%d %d %c\n", i, r, r)
+	}
+	s := "日本語"
+	var n int
+	for i := range s {
+		fmt.Printf("%d\n", i)
+		n++
+	}
+	fmt.Printf("%d\n", n) // 3
+	// invalid bytes decode to U+FFFD
+	for i, r := range "a\xffb" {
+		fmt.Printf("%d %d\n", i, r)
+	}
+	for _, r := range s {
+		if r == '本' {
+			continue
+		}
+		fmt.Printf("%s\n", string(r))
+	}
+}
+
+func f5() {
+	fmt.Printf("%s\n", string(rune(0x4e16))) // 世
+	fmt.Printf("%s\n", string(rune(65)))     // A
+	var buf []byte
+	buf = utf8.AppendRune(buf, 'é')
+	buf = utf8.AppendRune(buf, '界')
+	fmt.Printf("%s %d\n", string(buf), len(buf)) // é界 5
+	r, size := utf8.DecodeRuneInString("界x")
+	fmt.Printf("%d %d\n", r, size) // 30028 3
+	r, size = utf8.DecodeRune([]byte{0xff})
+	fmt.Printf("%d %d\n", r, size)        // 65533 1
+	fmt.Printf("%d\n", utf8.RuneLen('😀')) // 4
+}
+
+// conversions between strings and rune slices
+func f6() {
+	r := []rune("héllo")
+	fmt.Printf("%d %d %d\n", len(r), r[1], r[4]) // 5 233 111
+	for _, c := range r {
+		fmt.Printf("%d ", c)
+	}
+	fmt.Printf("\n")
+	s := string([]rune{19990, 30028})
+	fmt.Printf("%s %d\n", s, len(s)) // 世界 6
+	var rs []rune
+	rs = []rune("世界!😀")
+	fmt.Printf("%d %d %d\n", len(rs), cap(rs), rs[3]) // 4 4 128512
+	rs[2] = 'é'
+	fmt.Printf("%s\n", string(rs))
+	fmt.Printf("%d %d\n", len([]rune("")), len([]rune("日本語"))) // 0 3
+	fmt.Printf("%s\n", string([]rune{0x110000, 'a'}))          // invalid code point
+	fmt.Printf("%d\n", len([]rune("a\xffb")))                  // 3
+}
+
+func main() {
+	f1()
+	f2()
+	f3()
+	f4()
+	f5()
+	f6()
+}
@@ -0,0 +1,34 @@
+package main
+
+const X = 1
+
+type P struct {
+	x int
+}
+
+func (p P) get() int {
+	return p.x
+}
+
+func two() (int, int) {
+	return 1, 2
+}
+
+func main() {
+	s := "abc"
+	s[0] = 'x'
+	X = 2
+	x, y := two(), 1
+	var a [3]int
+	a[3] = x
+	p := &a
+	p[y+4] = 0
+	a[-1] = 0
+	m := map[string]P{}
+	m["k"].x = 1
+	s[1]++
+	var q P
+	q.get = nil
+	var n int = q.get
+	_ = n
+}
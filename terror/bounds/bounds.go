@@ -0,0 +1,13 @@
+package main
+
+import "fmt"
+
+func get(s []int, i int) int {
+	defer fmt.Printf("unwinding get\n")
+	return s[i]
+}
+
+func main() {
+	var s []int = []int{1, 2, 3}
+	fmt.Printf("%d\n", get(s, 5))
+}
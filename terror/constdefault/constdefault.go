@@ -0,0 +1,19 @@
+package main
+
+const (
+	k0 int8 = 1 << (iota * 3)
+	k1
+	k2
+	k3
+)
+
+func main() {
+	x := 1 << 100
+	y := 1e400
+	n, f := 1, 2.5e310
+	var i int
+	var j int
+	i, j = 1, 1<<70
+	z := 1 << 62
+	_, _, _, _, _, _, _ = x, y, n, f, i, j, z
+}
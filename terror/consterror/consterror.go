@@ -0,0 +1,26 @@
+package main
+
+const big = 1 << 100
+
+const (
+	a int8 = 100
+	b      = a * 2
+)
+
+const c uint = -1
+
+const d float32 = 1e40
+
+func main() {
+	var n int = 5
+	const e = n
+	var i int = big
+	var x int8 = 1
+	_ = x + 300
+	_ = int8(300)
+	_ = int(2.5)
+	_ = n / 0
+	var f float64 = 1
+	_ = f / 0
+	_ = i
+}
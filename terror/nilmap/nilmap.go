@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func set(m map[string]int, k string) {
+	defer fmt.Printf("unwinding set\n")
+	m[k] = 1
+}
+
+func try(m map[string]int) {
+	defer func() {
+		e := recover()
+		switch e.(type) {
+		case string:
+			fmt.Fprintf(os.Stderr, "recovered a string\n")
+		default:
+			fmt.Fprintf(os.Stderr, "recovered a run-time error\n")
+		}
+	}()
+	set(m, "a")
+}
+
+func main() {
+	var m map[string]int
+	try(m)
+	set(m, "b")
+}
@@ -0,0 +1,27 @@
+package main
+
+import "fmt"
+
+type Shape interface {
+	Area() int
+	Perimeter() int
+}
+
+type square struct {
+	side int
+}
+
+func (s *square) Area() int {
+	return s.side * s.side
+}
+
+func printArea(s Shape) {
+	fmt.Printf("%d\n", s.Area())
+}
+
+func main() {
+	sq := &square{
+		side: 2,
+	}
+	printArea(sq)
+}
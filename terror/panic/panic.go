@@ -1,7 +1,7 @@
 package main
 
 func f1() {
-	panic([]byte("Help me!"))
+	panic("Help me!")
 }
 
 func main() {
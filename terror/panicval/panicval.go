@@ -0,0 +1,47 @@
+package main
+
+type MyInt int
+type Name string
+type Ratio float64
+type Flag bool
+type Small int8
+
+type S struct {
+	a int
+}
+
+// every deferred call panics again, so that all the values are printed
+func f() {
+	defer func() {
+		panic(&S{})
+	}()
+	defer func() {
+		panic(nil)
+	}()
+	defer func() {
+		panic(float32(0.1))
+	}()
+	defer func() {
+		panic(Small(-3))
+	}()
+	defer func() {
+		panic(Flag(true))
+	}()
+	defer func() {
+		panic(Ratio(-2.5))
+	}()
+	defer func() {
+		panic(Name("x"))
+	}()
+	defer func() {
+		panic(1e21)
+	}()
+	defer func() {
+		panic(MyInt(3))
+	}()
+	panic(1.5)
+}
+
+func main() {
+	f()
+}
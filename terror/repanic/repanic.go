@@ -0,0 +1,23 @@
+package main
+
+import "fmt"
+
+func f2() {
+	defer func() {
+		panic("second")
+	}()
+	defer func() {
+		r := recover()
+		panic(r)
+	}()
+	panic("first")
+}
+
+func f1() {
+	defer fmt.Printf("unwinding f1\n")
+	f2()
+}
+
+func main() {
+	f1()
+}
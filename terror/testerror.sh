@@ -38,4 +38,114 @@ if [[ $status -eq 0 ]]; then
     exit 1
 fi
 
+# the final message of an unrecovered panic
+${progname} terror/repanic/repanic.go > /tmp/out/a.s 2>/tmp/out/err.txt
+status=$?
+if [[ $progname != "go run" ]]; then
+  as -o /tmp/out/a.o /tmp/out/a.s && ld -o a.out /tmp/out/a.o && ./a.out >/dev/null 2>/tmp/out/err.txt
+  status=$?
+fi
+if [[ $status -eq 0 ]] || grep -Fxvq -f /tmp/out/err.txt terror/repanic/expected.txt; then
+    echo "FAILED: terror/repanic"
+    exit 1
+fi
+
+# the values of panics
+# GODEBUG makes go run report panic(nil) even without a module
+GODEBUG=panicnil=0 ${progname} terror/panicval/panicval.go > /tmp/out/a.s 2>/tmp/out/err.txt
+status=$?
+if [[ $progname != "go run" ]]; then
+  as -o /tmp/out/a.o /tmp/out/a.s && ld -o a.out /tmp/out/a.o && ./a.out >/dev/null 2>/tmp/out/err.txt
+  status=$?
+fi
+if [[ $status -eq 0 ]] || grep -Fxvq -f /tmp/out/err.txt terror/panicval/expected.txt; then
+    echo "FAILED: terror/panicval"
+    exit 1
+fi
+
+# a run-time panic
+${progname} terror/bounds/bounds.go > /tmp/out/a.s 2>/tmp/out/err.txt
+status=$?
+if [[ $progname != "go run" ]]; then
+  as -o /tmp/out/a.o /tmp/out/a.s && ld -o a.out /tmp/out/a.o && ./a.out >/dev/null 2>/tmp/out/err.txt
+  status=$?
+fi
+if [[ $status -eq 0 ]] || grep -Fxvq -f /tmp/out/err.txt terror/bounds/expected.txt; then
+    echo "FAILED: terror/bounds"
+    exit 1
+fi
+
+# assignment to a nil map
+${progname} terror/nilmap/nilmap.go > /tmp/out/a.s 2>/tmp/out/err.txt
+status=$?
+if [[ $progname != "go run" ]]; then
+  as -o /tmp/out/a.o /tmp/out/a.s && ld -o a.out /tmp/out/a.o && ./a.out >/dev/null 2>/tmp/out/err.txt
+  status=$?
+fi
+if [[ $status -eq 0 ]] || grep -Fxvq -f /tmp/out/err.txt terror/nilmap/expected.txt; then
+    echo "FAILED: terror/nilmap"
+    exit 1
+fi
+
+# a traceback of a run-time panic
+${progname} terror/traceback/traceback.go > /tmp/out/a.s 2>/tmp/out/err.txt
+status=$?
+if [[ $progname != "go run" ]]; then
+  as -o /tmp/out/a.o /tmp/out/a.s && ld -o a.out /tmp/out/a.o && ./a.out >/dev/null 2>/tmp/out/err.txt
+  status=$?
+fi
+if [[ $status -eq 0 ]] || grep -Fxvq -f /tmp/out/err.txt terror/traceback/expected.txt; then
+    echo "FAILED: terror/traceback"
+    grep -Fxv -f /tmp/out/err.txt terror/traceback/expected.txt
+    exit 1
+fi
+
+# compile errors
+${progname} terror/notimpl/notimpl.go > /tmp/out/a.s 2>/tmp/out/err.txt
+status=$?
+if [[ $status -eq 0 ]] || ! grep -q '\*square does not implement Shape (missing method Perimeter)' /tmp/out/err.txt; then
+    echo "FAILED: terror/notimpl"
+    exit 1
+fi
+
+${progname} terror/typeerror/typeerror.go > /tmp/out/a.s 2>/tmp/out/err.txt
+status=$?
+if [[ $status -eq 0 ]] || grep -Fxvq -f /tmp/out/err.txt terror/typeerror/expected.txt; then
+    echo "FAILED: terror/typeerror"
+    grep -Fxv -f /tmp/out/err.txt terror/typeerror/expected.txt
+    exit 1
+fi
+
+${progname} terror/consterror/consterror.go > /tmp/out/a.s 2>/tmp/out/err.txt
+status=$?
+if [[ $status -eq 0 ]] || grep -Fxvq -f /tmp/out/err.txt terror/consterror/expected.txt; then
+    echo "FAILED: terror/consterror"
+    grep -Fxv -f /tmp/out/err.txt terror/consterror/expected.txt
+    exit 1
+fi
+
+${progname} terror/constdefault/constdefault.go > /tmp/out/a.s 2>/tmp/out/err.txt
+status=$?
+if [[ $status -eq 0 ]] || grep -Fxvq -f /tmp/out/err.txt terror/constdefault/expected.txt; then
+    echo "FAILED: terror/constdefault"
+    grep -Fxv -f /tmp/out/err.txt terror/constdefault/expected.txt
+    exit 1
+fi
+
+${progname} terror/undefined/undefined.go > /tmp/out/a.s 2>/tmp/out/err.txt
+status=$?
+if [[ $status -eq 0 ]] || grep -Fxvq -f /tmp/out/err.txt terror/undefined/expected.txt; then
+    echo "FAILED: terror/undefined"
+    grep -Fxv -f /tmp/out/err.txt terror/undefined/expected.txt
+    exit 1
+fi
+
+${progname} terror/assign/assign.go > /tmp/out/a.s 2>/tmp/out/err.txt
+status=$?
+if [[ $status -eq 0 ]] || grep -Fxvq -f /tmp/out/err.txt terror/assign/expected.txt; then
+    echo "FAILED: terror/assign"
+    grep -Fxv -f /tmp/out/err.txt terror/assign/expected.txt
+    exit 1
+fi
+
 echo "ok"
@@ -0,0 +1,12 @@
+package main
+
+var zero int
+
+//go:noinline
+func divide() int {
+	return 1 / zero
+}
+
+func main() {
+	divide()
+}
@@ -0,0 +1,28 @@
+package main
+
+func pair() (int, string) {
+	return 1, "a"
+}
+
+func add(a int, b int) int {
+	return a + b
+}
+
+func twice(s string) (string, string) {
+	return s, s, s
+}
+
+func main() {
+	var i int = "one"
+	var s string = "two"
+	var f float64 = 1.5
+	i, s, f = pair()
+	add(1)
+	add(i, s)
+	if i {
+		s = s + 1
+	}
+	i = -s
+	i = f % 2
+	i = 2.5
+}
@@ -0,0 +1,28 @@
+package main
+
+type T int
+
+type S struct {
+	a int
+}
+
+func (t T) get() int {
+	return int(t)
+}
+
+func main() {
+	var a []int
+	a.foo()
+	var m map[string]int
+	m.bar(1)
+	var t T
+	t.get()
+	t.set(1)
+	p := &t
+	p.set(2)
+	var s S
+	s.nope = 1
+	x := s.nope + 1
+	ps := &s
+	ps.a, ps.nope2 = x, 2
+}
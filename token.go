@@ -2,7 +2,6 @@ package main
 
 import (
 	"github.com/DQNEO/minigo/stdlib/fmt"
-	"github.com/DQNEO/minigo/stdlib/strconv"
 	"os"
 )
 
@@ -42,6 +41,7 @@ type TokenType int
 const (
 	T_EOF TokenType = iota
 	T_INT
+	T_FLOAT
 	T_STRING
 	T_CHAR
 	T_IDENT
@@ -55,6 +55,8 @@ func typeToGostring(typ TokenType) string {
 		return "EOF"
 	case T_INT:
 		return "int"
+	case T_FLOAT:
+		return "float"
 	case T_STRING:
 		return "string"
 	case T_CHAR:
@@ -137,8 +139,31 @@ func (tok *Token) getIdent() identifier {
 	return identifier(tok.sval)
 }
 
+// the value of an integer literal like "255", "0xff", "0o377", "0377" or "0b11111111".
+// A value beyond the range of int wraps around.
 func (tok *Token) getIntval() int {
-	val, _ := strconv.Atoi(string(tok.sval))
+	digits := tok.sval
+	base := 10
+	if len(digits) >= 2 && digits[0] == '0' {
+		switch digits[1] {
+		case 'x':
+			base = 16
+			digits = digits[2:]
+		case 'o':
+			base = 8
+			digits = digits[2:]
+		case 'b':
+			base = 2
+			digits = digits[2:]
+		default:
+			base = 8
+			digits = digits[1:]
+		}
+	}
+	var val int
+	for _, c := range []byte(digits) {
+		val = val*base + digitVal(c)
+	}
 	return val
 }
 
@@ -154,6 +179,10 @@ func (tok *Token) isTypeInt() bool {
 	return tok != nil && tok.typ == T_INT
 }
 
+func (tok *Token) isTypeFloat() bool {
+	return tok != nil && tok.typ == T_FLOAT
+}
+
 func (tok *Token) isTypeChar() bool {
 	return tok != nil && tok.typ == T_CHAR
 }
@@ -166,6 +195,16 @@ func (tok *Token) isTypeIdent() bool {
 	return tok != nil && tok.typ == T_IDENT
 }
 
+// whether a type can begin with the token
+func (tok *Token) isTypeStart() bool {
+	if tok.isTypeIdent() {
+		return true
+	}
+	return tok.isPunct("*") || tok.isPunct("[") || tok.isKeyword("map") ||
+		tok.isKeyword("func") || tok.isKeyword("struct") || tok.isKeyword("interface") ||
+		tok.isKeyword("chan") || tok.isPunct("<-")
+}
+
 func (tok *Token) isSemicolon() bool {
 	return tok.isPunct(";")
 }
@@ -1,5 +1,7 @@
 package main
 
+import "github.com/DQNEO/minigo/util"
+
 type EType int
 
 const undefinedSize = -1
@@ -14,6 +16,15 @@ const (
 	G_BYTE
 	G_UINT_PTR
 	G_UINT_16
+	G_INT_8
+	G_INT_16
+	G_INT_32
+	G_INT_64
+	G_UINT
+	G_UINT_32
+	G_UINT_64
+	G_FLOAT64
+	G_FLOAT32
 	// end of primitives
 	G_STRUCT
 	G_ARRAY
@@ -23,6 +34,7 @@ const (
 	G_POINTER
 	G_FUNC
 	G_INTERFACE
+	G_CHAN
 )
 
 type signature struct {
@@ -40,14 +52,47 @@ type Gtype struct {
 	origType       *Gtype                      // for pointer
 	fields         []*Gtype                    // for struct
 	fieldname      identifier                  // for struct field
+	isEmbedded     bool                        // for struct field
 	offset         int                         // for struct field
 	padding        int                         // for struct field
+	align          int                         // for struct
 	length         int                         // for array, string (len without the terminating \0)
-	elementType    *Gtype                      // for array, slice
+	elementType    *Gtype                      // for array, slice, chan
 	imethods       map[identifier]*signature   // for interface
+	embeddeds      []*Gtype                    // for interface
 	methods        map[identifier]*ExprFuncRef // for G_NAMED
 	mapKey         *Gtype                      // for map
 	mapValue       *Gtype                      // for map
+	sig            *signature                  // for func
+}
+
+// func(int, string) (int, error)
+func (sig *signature) String() string {
+	var s string = "func("
+	for i, param := range sig.params {
+		if i > 0 {
+			s = s + ", "
+		}
+		if param.isVariadic {
+			s = s + "..." + param.gtype.elementType.String()
+		} else {
+			s = s + param.gtype.String()
+		}
+	}
+	s = s + ")"
+	if len(sig.rettypes) == 1 {
+		s = s + " " + sig.rettypes[0].String()
+	} else if len(sig.rettypes) > 1 {
+		s = s + " ("
+		for i, rettype := range sig.rettypes {
+			if i > 0 {
+				s = s + ", "
+			}
+			s = s + rettype.String()
+		}
+		s = s + ")"
+	}
+	return s
 }
 
 func imethodGet(imethods map[identifier]*signature, name identifier) (*signature, bool) {
@@ -121,6 +166,44 @@ func (gtype *Gtype) isBytesSlice() bool {
 	return false
 }
 
+func (gtype *Gtype) isRunesSlice() bool {
+	underLying := gtype.Underlying()
+	return underLying.kind == G_SLICE && underLying.elementType.getKind() == G_INT_32
+}
+
+func (gtype *Gtype) isFloat() bool {
+	kind := gtype.getKind()
+	return kind == G_FLOAT64 || kind == G_FLOAT32
+}
+
+// unsigned integers are zero-extended in a register, and signed integers are sign-extended.
+func (gtype *Gtype) isUnsigned() bool {
+	switch gtype.getKind() {
+	case G_BYTE, G_UINT_16, G_UINT_32, G_UINT_64, G_UINT, G_UINT_PTR:
+		return true
+	default:
+		return false
+	}
+}
+
+func (gtype *Gtype) isInteger() bool {
+	kind := gtype.getKind()
+	return G_INT <= kind && kind <= G_UINT_64 && kind != G_BOOL
+}
+
+func (gtype *Gtype) isNumeric() bool {
+	return gtype.isInteger() || gtype.isFloat()
+}
+
+// whether nil can be assigned to a value of the type
+func (gtype *Gtype) isNilable() bool {
+	switch gtype.getKind() {
+	case G_POINTER, G_SLICE, G_MAP, G_FUNC, G_INTERFACE, G_CHAN, G_UINT_PTR:
+		return true
+	}
+	return false
+}
+
 func (gtype *Gtype) isString() bool {
 	return gtype.getKind() == G_STRING
 }
@@ -143,14 +226,14 @@ func (gtype *Gtype) getSize() int {
 				gtype.calcStructOffset()
 			}
 			return gtype.size
-		case G_POINTER, G_UINT_PTR:
+		case G_POINTER, G_UINT_PTR, G_FUNC:
 			return ptrSize
 		case G_INTERFACE:
 			//     data    ,  receiverTypeId, dtype
 			return ptrSize + ptrSize + ptrSize
 		case G_SLICE:
 			return ptrSize + IntSize + IntSize
-		case G_MAP:
+		case G_MAP, G_CHAN:
 			return ptrSize
 		case G_STRING:
 			return ptrSize * 3
@@ -180,6 +263,24 @@ func (gtype *Gtype) String() string {
 				return "uintptr"
 			case G_UINT_16:
 				return "uint16"
+			case G_INT_8:
+				return "int8"
+			case G_INT_16:
+				return "int16"
+			case G_INT_32:
+				return "int32"
+			case G_INT_64:
+				return "int64"
+			case G_UINT:
+				return "uint"
+			case G_UINT_32:
+				return "uint32"
+			case G_UINT_64:
+				return "uint64"
+			case G_FLOAT64:
+				return "float64"
+			case G_FLOAT32:
+				return "float32"
 			case G_BOOL:
 				return "bool"
 			case G_BYTE:
@@ -188,6 +289,11 @@ func (gtype *Gtype) String() string {
 				return "string"
 			case G_FUNC:
 				return "func"
+			case G_SLICE:
+				// printed as []byte by fmt
+				if child.isBytesSlice() {
+					return child.String()
+				}
 			}
 		}
 		s := Sprintf("G_NAMED(%s.%s)",
@@ -199,6 +305,24 @@ func (gtype *Gtype) String() string {
 		return "uintptr"
 	case G_UINT_16:
 		return "uint16"
+	case G_INT_8:
+		return "int8"
+	case G_INT_16:
+		return "int16"
+	case G_INT_32:
+		return "int32"
+	case G_INT_64:
+		return "int64"
+	case G_UINT:
+		return "uint"
+	case G_UINT_32:
+		return "uint32"
+	case G_UINT_64:
+		return "uint64"
+	case G_FLOAT64:
+		return "float64"
+	case G_FLOAT32:
+		return "float32"
 	case G_BOOL:
 		return "bool"
 	case G_BYTE:
@@ -225,7 +349,10 @@ func (gtype *Gtype) String() string {
 	case G_STRING:
 		return "string"
 	case G_FUNC:
-		return "func"
+		if gtype.sig == nil {
+			return "func"
+		}
+		return gtype.sig.String()
 	case G_INTERFACE:
 		if len(gtype.imethods) == 0 {
 			return "interface{}"
@@ -234,12 +361,91 @@ func (gtype *Gtype) String() string {
 		}
 	case G_MAP:
 		return "map"
+	case G_CHAN:
+		s := Sprintf("chan %s", gtype.elementType.String())
+		return s
 	default:
 		errorf("gtype.String() error: invalid gtype.type=%d", gtype.kind)
 	}
 	return ""
 }
 
+// typeString returns a type in the Go syntax for diagnostics.
+// Named types of the main package, the universe and the package being checked are not qualified.
+func (gtype *Gtype) typeString() string {
+	if gtype == nil {
+		return "NO_TYPE"
+	}
+	switch gtype.kind {
+	case G_NAMED:
+		pkg := gtype.relation.pkg
+		if pkg == "" || pkg == "main" || pkg == "builtin" || pkg == IRuntimePkgName || pkg == checkingPkg {
+			return string(gtype.relation.name)
+		}
+		return Sprintf("%s.%s", gtype.relation.pkg, gtype.relation.name)
+	case G_POINTER:
+		return "*" + gtype.origType.typeString()
+	case G_SLICE:
+		return "[]" + gtype.elementType.typeString()
+	case G_ARRAY:
+		return Sprintf("[%d]%s", gtype.length, gtype.elementType.typeString())
+	case G_MAP:
+		return Sprintf("map[%s]%s", gtype.mapKey.typeString(), gtype.mapValue.typeString())
+	case G_CHAN:
+		return "chan " + gtype.elementType.typeString()
+	case G_FUNC:
+		if gtype.sig == nil {
+			return "func()"
+		}
+		return "func" + gtype.sig.typeString()
+	case G_INTERFACE:
+		var names []string
+		for name, _ := range gtype.imethods {
+			names = append(names, string(name))
+		}
+		util.SortStrings(names)
+		var s string = "interface{"
+		for i, name := range names {
+			if i > 0 {
+				s = s + "; "
+			}
+			sig, _ := imethodGet(gtype.imethods, identifier(name))
+			s = s + name + sig.typeString()
+		}
+		return s + "}"
+	}
+	return gtype.String()
+}
+
+// (int, string) (int, error)
+func (sig *signature) typeString() string {
+	var s string = "("
+	for i, param := range sig.params {
+		if i > 0 {
+			s = s + ", "
+		}
+		if param.isVariadic {
+			s = s + "..." + param.gtype.elementType.typeString()
+		} else {
+			s = s + param.gtype.typeString()
+		}
+	}
+	s = s + ")"
+	if len(sig.rettypes) == 1 {
+		s = s + " " + sig.rettypes[0].typeString()
+	} else if len(sig.rettypes) > 1 {
+		s = s + " ("
+		for i, rettype := range sig.rettypes {
+			if i > 0 {
+				s = s + ", "
+			}
+			s = s + rettype.typeString()
+		}
+		s = s + ")"
+	}
+	return s
+}
+
 func (strct *Gtype) getField(name identifier) *Gtype {
 	assertNotNil(strct != nil, nil)
 	assert(strct.kind == G_STRUCT, nil, "assume G_STRUCT type")
@@ -252,19 +458,213 @@ func (strct *Gtype) getField(name identifier) *Gtype {
 	return nil
 }
 
+// the declared type of an embedded field T or *T
+func (field *Gtype) embeddedType() *Gtype {
+	gtype := field
+	if gtype.kind == G_POINTER {
+		gtype = gtype.origType
+	}
+	return gtype.relation.gtype
+}
+
+// whether a type declares a field or a method named name by itself.
+// Wrappers of promoted methods are not counted.
+func (gtype *Gtype) hasOwnMember(name identifier) bool {
+	if ref, ok := methodGet(gtype.methods, name); ok && !ref.funcdef.isWrapper {
+		return true
+	}
+	underlying := gtype.Underlying()
+	switch underlying.kind {
+	case G_STRUCT:
+		for _, field := range underlying.fields {
+			if string(field.fieldname) == string(name) {
+				return true
+			}
+		}
+	case G_INTERFACE:
+		if _, ok := imethodGet(underlying.imethods, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+type embeddedPath struct {
+	gtype *Gtype       // the declared type of an embedded field
+	names []identifier // embedded fields to go through from the outermost struct
+}
+
+// lookupPromoted finds a field or a method promoted from embedded fields.
+// It returns the names of the embedded fields to go through to reach the owner of name,
+// or reports ambiguity when there are more than one at the shallowest depth.
+// https://golang.org/ref/spec#Selectors
+func (gtype *Gtype) lookupPromoted(name identifier) ([]identifier, bool) {
+	var current []*embeddedPath = []*embeddedPath{&embeddedPath{gtype: gtype}}
+	var seen []*Gtype
+	for len(current) > 0 {
+		for _, ep := range current {
+			seen = append(seen, ep.gtype)
+		}
+		var next []*embeddedPath
+		var found []identifier
+		var count int
+		for _, ep := range current {
+			strct := ep.gtype.Underlying()
+			if strct.kind != G_STRUCT {
+				continue
+			}
+			for _, field := range strct.fields {
+				if !field.isEmbedded {
+					continue
+				}
+				var names []identifier
+				for _, n := range ep.names {
+					names = append(names, n)
+				}
+				names = append(names, field.fieldname)
+				embedded := field.embeddedType()
+				if embedded.hasOwnMember(name) {
+					count++
+					found = names
+				}
+				if !inGtypes(embedded, seen) {
+					next = append(next, &embeddedPath{gtype: embedded, names: names})
+				}
+			}
+		}
+		if count > 1 {
+			return nil, true
+		}
+		if count == 1 {
+			return found, false
+		}
+		current = next
+	}
+	return nil, false
+}
+
+func inGtypes(gtype *Gtype, gtypes []*Gtype) bool {
+	for _, g := range gtypes {
+		if g == gtype {
+			return true
+		}
+	}
+	return false
+}
+
+// https://golang.org/ref/spec#Type_identity
+func identicalTypes(a *Gtype, b *Gtype) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	a = a.unwrapPredeclared()
+	b = b.unwrapPredeclared()
+	if a.kind != b.kind {
+		return false
+	}
+	switch a.kind {
+	case G_NAMED:
+		// refer to the same type declaration
+		return a.relation.gtype == b.relation.gtype
+	case G_POINTER:
+		return identicalTypes(a.origType, b.origType)
+	case G_SLICE, G_CHAN:
+		return identicalTypes(a.elementType, b.elementType)
+	case G_ARRAY:
+		return a.length == b.length && identicalTypes(a.elementType, b.elementType)
+	case G_MAP:
+		return identicalTypes(a.mapKey, b.mapKey) && identicalTypes(a.mapValue, b.mapValue)
+	case G_FUNC:
+		if a.sig == nil || b.sig == nil {
+			return a.sig == b.sig
+		}
+		return identicalSignatures(a.sig, b.sig)
+	case G_STRUCT:
+		if len(a.fields) != len(b.fields) {
+			return false
+		}
+		for i, field := range a.fields {
+			if string(field.fieldname) != string(b.fields[i].fieldname) || field.isEmbedded != b.fields[i].isEmbedded {
+				return false
+			}
+			if !identicalTypes(field, b.fields[i]) {
+				return false
+			}
+		}
+		return true
+	case G_INTERFACE:
+		if len(a.imethods) != len(b.imethods) {
+			return false
+		}
+		for name, sig := range a.imethods {
+			other, ok := imethodGet(b.imethods, name)
+			if !ok || !identicalSignatures(sig, other) {
+				return false
+			}
+		}
+		return true
+	}
+	return true
+}
+
+// a predeclared type like "int" refers to its body in the universe
+func (gtype *Gtype) unwrapPredeclared() *Gtype {
+	if gtype.kind == G_NAMED && gtype.relation.gtype != nil {
+		body := gtype.relation.gtype
+		if (G_INT <= body.kind && body.kind <= G_FLOAT32) || body.kind == G_STRING {
+			return body
+		}
+	}
+	return gtype
+}
+
+func identicalSignatures(a *signature, b *signature) bool {
+	if len(a.params) != len(b.params) || len(a.rettypes) != len(b.rettypes) {
+		return false
+	}
+	for i, param := range a.params {
+		if param.isVariadic != b.params[i].isVariadic || !identicalTypes(param.gtype, b.params[i].gtype) {
+			return false
+		}
+	}
+	for i, rettype := range a.rettypes {
+		if !identicalTypes(rettype, b.rettypes[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// natural alignment of a type
+func (gtype *Gtype) getAlign() int {
+	switch gtype.getKind() {
+	case G_ARRAY:
+		return gtype.Underlying().elementType.getAlign()
+	case G_STRUCT:
+		strct := gtype.Underlying()
+		strct.getSize()
+		return strct.align
+	default:
+		size := gtype.getSize()
+		if size > MaxAlign {
+			return MaxAlign
+		}
+		return size
+	}
+}
+
 func (strct *Gtype) calcStructOffset() {
 	assert(strct.getKind() == G_STRUCT, nil, "assume G_STRUCT type, but got %s", strct.String())
 	var offset int
+	var maxAlign int = 1
 	for _, fieldtype := range strct.fields {
-		var align int
-		if fieldtype.getSize() < MaxAlign {
-			align = fieldtype.getSize()
-			assert(align > 0, nil, "field size should be > 0: filed=%s", fieldtype.String())
-		} else {
-			align = MaxAlign
+		fieldAlign := fieldtype.getAlign()
+		assert(fieldAlign > 0, nil, "field align should be > 0: filed=%s", fieldtype.String())
+		if fieldAlign > maxAlign {
+			maxAlign = fieldAlign
 		}
-		if offset%align != 0 {
-			padding := align - offset%align
+		if offset%fieldAlign != 0 {
+			padding := fieldAlign - offset%fieldAlign
 			fieldtype.padding = padding
 			offset += padding
 		}
@@ -272,7 +672,9 @@ func (strct *Gtype) calcStructOffset() {
 		offset += fieldtype.getSize()
 	}
 
-	strct.size = offset
+	// the size is a multiple of the alignment so that elements of an array are aligned
+	strct.align = maxAlign
+	strct.size = align(offset, maxAlign)
 }
 
 func (rel *Relation) getGtype() *Gtype {
@@ -291,26 +693,38 @@ func (e *ExprStructLiteral) getGtype() *Gtype {
 }
 
 func (e *ExprFuncallOrConversion) getGtype() *Gtype {
+	if e.typ != nil {
+		// (*T)(e)
+		return e.typ
+	}
 	assert(e.rel.expr != nil || e.rel.gtype != nil, e.token(), "")
 	if e.rel.expr != nil {
-		funcref, ok := e.rel.expr.(*ExprFuncRef)
-		assert(ok, e.token(), "it should be a ExprFuncRef")
-		firstRetType := funcref.funcdef.rettypes[0]
-		return firstRetType
+		rettypes := e.getRettypes()
+		if len(rettypes) == 0 {
+			return nil
+		}
+		return rettypes[0]
 	} else if e.rel.gtype != nil {
-		return e.rel.gtype
+		// T(x)
+		return &Gtype{
+			kind:     G_NAMED,
+			relation: e.rel,
+		}
 	}
 	assertNotReached(e.token())
 	return nil
 }
 
 func (e *ExprMethodcall) getGtype() *Gtype {
-	gtype := e.receiver.getGtype()
-	if gtype.kind == G_POINTER {
-		gtype = gtype.origType
+	if field := e.getFuncField(); field != nil {
+		rettypes := field.Underlying().sig.rettypes
+		if len(rettypes) == 0 {
+			return nil
+		}
+		return rettypes[0]
 	}
-
-	underlyingType := gtype.relation.gtype // I forgot the reason to do this :(
+	gtype := e.receiver.getGtype()
+	underlyingType := e.getOrigType()
 	if underlyingType.kind == G_INTERFACE {
 		methodsig, ok := imethodGet(underlyingType.imethods, e.fname)
 		if !ok {
@@ -319,15 +733,44 @@ func (e *ExprMethodcall) getGtype() *Gtype {
 		assertNotNil(methodsig != nil, e.tok)
 		return methodsig.rettypes[0]
 	} else {
-		method, ok := methodGet(underlyingType.methods, e.fname)
-		if !ok {
-			errorft(e.token(), "method %s not found in %s %s", e.fname, gtype, e.tok)
-		}
-		assertNotNil(method != nil, e.tok)
+		method := e.getMethod()
 		return method.funcdef.rettypes[0]
 	}
 }
 
+// the type of an arithmetic or comparison on floats, or nil if neither operand is a float.
+// An untyped constant operand takes the type of the other operand.
+func (e *ExprBinop) floatGtype() *Gtype {
+	left := e.left.getGtype()
+	right := e.right.getGtype()
+	if left.isFloat() && !isUntypedConst(e.left) {
+		return left
+	}
+	if right.isFloat() && !isUntypedConst(e.right) {
+		return right
+	}
+	if left.isFloat() {
+		return left
+	}
+	if right.isFloat() {
+		return right
+	}
+	return nil
+}
+
+// the type of the operands of an arithmetic or comparison.
+// An untyped constant operand takes the type of the other operand.
+func (e *ExprBinop) operandGtype() *Gtype {
+	if isUntypedConst(e.left) && !isUntypedConst(e.right) {
+		return e.right.getGtype()
+	}
+	if e.left.getGtype() == nil && isUntypedIntConst(e.left) {
+		// the default type of an untyped integer constant
+		return gInt
+	}
+	return e.left.getGtype()
+}
+
 func (e *ExprUop) getGtype() *Gtype {
 	sop := string(e.op)
 	switch sop {
@@ -341,7 +784,7 @@ func (e *ExprUop) getGtype() *Gtype {
 	case "!":
 		return gBool
 	case "-":
-		return gInt
+		return e.operand.getGtype()
 	}
 	errorf("internal error")
 	return nil
@@ -350,9 +793,32 @@ func (e *ExprUop) getGtype() *Gtype {
 func (f *ExprFuncRef) getGtype() *Gtype {
 	return &Gtype{
 		kind: G_FUNC,
+		sig:  f.funcdef.getSignature(),
+	}
+}
+
+func (f *ExprFuncLiteral) getGtype() *Gtype {
+	return &Gtype{
+		kind: G_FUNC,
+		sig:  f.funcdef.getSignature(),
 	}
 }
 
+func (e *ExprMethodValue) getGtype() *Gtype {
+	return &Gtype{
+		kind: G_FUNC,
+		sig:  e.funcdef.getSignature(),
+	}
+}
+
+func (e *ExprFuncValueCall) getGtype() *Gtype {
+	rettypes := e.getRettypes()
+	if len(rettypes) == 0 {
+		return nil
+	}
+	return rettypes[0]
+}
+
 func (e *ExprSlice) getGtype() *Gtype {
 	var kind EType
 	if e.collection.getGtype().isString() {
@@ -395,17 +861,20 @@ func (e *ExprIndex) getSecondGtype() *Gtype {
 	return nil
 }
 
-func (e *ExprStructField) getGtype() *Gtype {
+// the named type of x in x.f, or of *x if x is a pointer
+func (e *ExprStructField) getStrctType() *Gtype {
 	gstruct := e.strct.getGtype()
 
 	assert(gstruct != gInt, e.tok, "struct should not be gInt")
 
-	var strctType *Gtype
 	if gstruct.kind == G_POINTER {
-		strctType = gstruct.origType
-	} else {
-		strctType = gstruct
+		return gstruct.origType
 	}
+	return gstruct
+}
+
+func (e *ExprStructField) getGtype() *Gtype {
+	strctType := e.getStrctType()
 
 	fields := strctType.relation.gtype.fields
 	//debugf(S("fields=%v"), fields)
@@ -415,17 +884,75 @@ func (e *ExprStructField) getGtype() *Gtype {
 			return field
 		}
 	}
+	if sig := e.getMethodSig(); sig != nil {
+		return &Gtype{
+			kind: G_FUNC,
+			sig:  sig,
+		}
+	}
+	if e.expandPromoted(strctType.relation.gtype) {
+		return e.getGtype()
+	}
 	return nil
 }
 
+// getMethodSig returns the signature of the method if x.f is a method value,
+// or nil if f is a field.
+func (e *ExprStructField) getMethodSig() *signature {
+	origType := e.getStrctType().relation.gtype
+	for _, field := range origType.fields {
+		if string(e.fieldname) == string(field.fieldname) {
+			return nil
+		}
+	}
+	if origType.kind == G_INTERFACE {
+		sig, _ := imethodGet(origType.imethods, e.fieldname)
+		return sig
+	}
+	ref, ok := methodGet(origType.methods, e.fieldname)
+	if !ok {
+		return nil
+	}
+	return ref.funcdef.getSignature()
+}
+
+// expandPromoted rewrites a selector of a promoted field x.f into x.T.f
+func (e *ExprStructField) expandPromoted(strctType *Gtype) bool {
+	path, ambiguous := strctType.lookupPromoted(e.fieldname)
+	if ambiguous {
+		errorft(e.token(), "ambiguous selector %s", e.fieldname)
+	}
+	if path == nil {
+		return false
+	}
+	for _, name := range path {
+		e.strct = &ExprStructField{
+			tok:       e.tok,
+			strct:     e.strct,
+			fieldname: name,
+		}
+	}
+	return true
+}
+
 func (e *ExprArrayLiteral) getGtype() *Gtype {
 	return e.gtype
 }
 
 func (e *ExprNumberLiteral) getGtype() *Gtype {
+	if e.isRune {
+		return gInt32
+	}
 	return gInt
 }
 
+func (e *ExprFloatLiteral) getGtype() *Gtype {
+	if e.gtype != nil {
+		return e.gtype
+	}
+	return gFloat64
+}
+
 func (e *ExprStringLiteral) getGtype() *Gtype {
 	return gString
 }
@@ -443,17 +970,28 @@ func (e *ExprVariable) getGtype() *Gtype {
 }
 
 func (e *ExprConstVariable) getGtype() *Gtype {
-	return e.gtype
+	if e.gtype != nil {
+		return e.gtype
+	}
+	if c := evalConst(e); c != nil {
+		// the default type of an untyped constant
+		return c.getGtype()
+	}
+	return nil
 }
 
 func (e *ExprBinop) getGtype() *Gtype {
 	switch e.op {
 	case "<", ">", "<=", ">=", "!=", "==", "&&", "||":
 		return gBool
-	case "+":
+	case "+", "-", "*", "%", "/":
+		if gtype := e.floatGtype(); gtype != nil {
+			return gtype
+		}
+		return e.operandGtype()
+	case "<<", ">>":
+		// the type of a shift is that of the left operand
 		return e.left.getGtype()
-	case "-", "*", "%", "/":
-		return gInt
 	}
 	errorf("internal error")
 	return nil
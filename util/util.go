@@ -17,3 +17,24 @@ func InArray(item string, list []string) bool {
 	}
 	return false
 }
+
+// SortStrings sorts a slice of strings in increasing order
+func SortStrings(list []string) {
+	for i := 1; i < len(list); i++ {
+		for j := i; j > 0 && Less(list[j], list[j-1]); j-- {
+			tmp := list[j]
+			list[j] = list[j-1]
+			list[j-1] = tmp
+		}
+	}
+}
+
+// Less reports whether a sorts before b in byte order
+func Less(a string, b string) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}